@@ -25,10 +25,14 @@ type AppConfig struct {
 
 // FeatureFlags controls optional application features
 type FeatureFlags struct {
-	EnableRegistration bool
-	EnableUserProfiles bool
+	EnableRegistration  bool
+	EnableUserProfiles  bool
 	EnableNotifications bool
-	MaintenanceMode    bool
+	EnableMagicLinks    bool
+	// RequireEmailVerification blocks login until the email is verified;
+	// otherwise unverified users can log in and are nagged to verify
+	RequireEmailVerification bool
+	MaintenanceMode          bool
 }
 
 // PaginationConfig controls pagination behavior
@@ -59,10 +63,12 @@ func InitAppConfig() *AppConfig {
 		DistPath:    config.GetEnv("APP_DIST_PATH", "static/dist").(string),
 
 		Features: FeatureFlags{
-			EnableRegistration:  config.GetEnv("FEATURE_REGISTRATION", true).(bool),
-			EnableUserProfiles:  config.GetEnv("FEATURE_USER_PROFILES", true).(bool),
-			EnableNotifications: config.GetEnv("FEATURE_NOTIFICATIONS", false).(bool),
-			MaintenanceMode:     config.GetEnv("MAINTENANCE_MODE", false).(bool),
+			EnableRegistration:       config.GetEnv("FEATURE_REGISTRATION", true).(bool),
+			EnableUserProfiles:       config.GetEnv("FEATURE_USER_PROFILES", true).(bool),
+			EnableNotifications:      config.GetEnv("FEATURE_NOTIFICATIONS", false).(bool),
+			EnableMagicLinks:         config.GetEnv("FEATURE_MAGIC_LINKS", false).(bool),
+			RequireEmailVerification: config.GetEnv("FEATURE_REQUIRE_EMAIL_VERIFICATION", false).(bool),
+			MaintenanceMode:          config.GetEnv("MAINTENANCE_MODE", false).(bool),
 		},
 
 		Pagination: PaginationConfig{
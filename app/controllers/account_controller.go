@@ -0,0 +1,349 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gohst/app/services"
+	"gohst/internal/auth"
+	"gohst/internal/middleware"
+	"gohst/internal/oidc"
+	"gohst/internal/ratelimit"
+	"gohst/internal/routes"
+	"gohst/internal/session"
+	accountviews "gohst/views/account"
+)
+
+type AccountController struct {
+	*AppController
+}
+
+func NewAccountController() *AccountController {
+	return &AccountController{
+		AppController: NewAppController(),
+	}
+}
+
+func (c *AccountController) RegisterRoutes(g *routes.Group) {
+	store := ratelimit.NewStore()
+	// Codes are limited per user across every form that checks one
+	codeLimiter := ratelimit.NewLimiter(store, ratelimit.AuthSensitivePolicy(), ratelimit.KeyByUserElseIP())
+
+	g.Use(
+		session.SM.SessionMiddleware,
+		middleware.Remember,
+		middleware.CSRF,
+		middleware.Auth,
+		middleware.Verified,
+	)
+
+	// Two-factor enrollment stays reachable for users who are required to enroll
+	g.HandleFunc("GET /two-factor", c.TwoFactor).Name("account.two-factor")
+	g.HandleFunc("POST /two-factor/enable", c.HandleEnableTwoFactor, middleware.NotImpersonating).Name("account.two-factor.enable")
+	g.HandleFunc("POST /two-factor/confirm", c.HandleConfirmTwoFactor, middleware.NotImpersonating, codeLimiter.Middleware).Name("account.two-factor.confirm")
+	g.HandleFunc("POST /two-factor/recovery-codes", c.HandleRegenerateRecoveryCodes, middleware.NotImpersonating, codeLimiter.Middleware).Name("account.two-factor.recovery-codes")
+	g.HandleFunc("POST /two-factor/disable", c.HandleDisableTwoFactor, middleware.NotImpersonating, codeLimiter.Middleware).Name("account.two-factor.disable")
+
+	// Everything below needs enrollment first, so an admin cannot, say,
+	// create an access token before setting up two-factor authentication
+	g.Use(middleware.TwoFactorSetup)
+
+	g.HandleFunc("GET /sessions", c.Sessions).Name("account.sessions")
+	g.HandleFunc("POST /sessions/{handle}/revoke", c.HandleRevokeSession, middleware.NotImpersonating).Name("account.sessions.revoke")
+	g.HandleFunc("POST /sessions/revoke-others", c.HandleRevokeOtherSessions, middleware.NotImpersonating).Name("account.sessions.revoke-others")
+
+	g.HandleFunc("GET /tokens", c.AccessTokens).Name("account.tokens")
+	g.HandleFunc("POST /tokens", c.HandleCreateAccessToken, middleware.NotImpersonating).Name("account.tokens.create")
+	g.HandleFunc("POST /tokens/{id}/revoke", c.HandleRevokeAccessToken, middleware.NotImpersonating).Name("account.tokens.revoke")
+
+	g.HandleFunc("GET /identities", c.Identities).Name("account.identities")
+	g.HandleFunc("POST /identities/{id}/unlink", c.HandleUnlinkIdentity, middleware.NotImpersonating).Name("account.identities.unlink")
+}
+
+// Sessions lists the devices the user is logged in on
+func (c *AccountController) Sessions(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	sessions, err := auth.Sessions(sess)
+	if err != nil {
+		log.ErrorContext(r.Context(), "listing sessions", "err", err)
+		if errors.Is(err, session.ErrStatelessStore) {
+			sess.SetFlash("error", "Session management is not available with the current session store")
+		} else {
+			sess.SetFlash("error", "Could not load your sessions")
+		}
+	}
+
+	c.Render(w, r, accountviews.SessionsPage(accountviews.SessionsPageData{
+		Sessions: sessions,
+	}))
+}
+
+// HandleRevokeSession logs out a single other device
+func (c *AccountController) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	if err := auth.RevokeSession(sess, r.PathValue("handle")); err != nil {
+		log.ErrorContext(r.Context(), "revoking session", "err", err)
+		sess.SetFlash("error", "That session could not be logged out")
+	} else {
+		sess.SetFlash("success", "The session has been logged out")
+	}
+
+	c.Redirect(w, r, routes.URL("account.sessions"), http.StatusSeeOther)
+}
+
+// HandleRevokeOtherSessions logs out every device except this one
+func (c *AccountController) HandleRevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	revoked, err := auth.LogoutOtherSessions(sess)
+	if err != nil {
+		log.ErrorContext(r.Context(), "revoking sessions", "err", err)
+		sess.SetFlash("error", "Your other sessions could not be logged out")
+	} else {
+		sess.SetFlash("success", fmt.Sprintf("Logged out of %d other session(s)", revoked))
+	}
+
+	c.Redirect(w, r, routes.URL("account.sessions"), http.StatusSeeOther)
+}
+
+// TwoFactor shows the two-factor status, or the QR code while enrolling
+func (c *AccountController) TwoFactor(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	authData, ok := auth.GetAuthData(sess).(*services.AuthData)
+	if !ok {
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+		return
+	}
+
+	setup, err := services.GetTwoFactorSetup(authData.UserID, authData.Email)
+	if err != nil {
+		log.ErrorContext(r.Context(), "loading two-factor setup", "err", err)
+		sess.SetFlash("error", "Could not load your two-factor settings")
+		setup = &services.TwoFactorSetup{}
+	}
+
+	codes := sess.GetFlash("recovery_codes")
+	recoveryCodes, _ := codes.([]string)
+
+	c.Render(w, r, accountviews.TwoFactorPage(accountviews.TwoFactorPageData{
+		Setup:         setup,
+		RecoveryCodes: recoveryCodes,
+	}))
+}
+
+// HandleEnableTwoFactor starts enrollment by generating a secret
+func (c *AccountController) HandleEnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	authData, ok := auth.GetAuthData(sess).(*services.AuthData)
+	if !ok {
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+		return
+	}
+
+	if err := services.BeginTwoFactorSetup(authData.UserID); err != nil {
+		log.ErrorContext(r.Context(), "starting two-factor setup", "err", err)
+		sess.SetFlash("error", "Two-factor authentication could not be set up")
+	}
+	c.Redirect(w, r, routes.URL("account.two-factor"), http.StatusSeeOther)
+}
+
+// HandleConfirmTwoFactor finishes enrollment with a code from the app
+func (c *AccountController) HandleConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		c.SetError(r, "Failed to parse form data")
+		c.Redirect(w, r, routes.URL("account.two-factor"), http.StatusSeeOther)
+		return
+	}
+
+	codes, err := services.ConfirmTwoFactor(sess, r.FormValue("code"))
+	if err != nil {
+		if !errors.Is(err, services.ErrInvalidTwoFactorCode) && !errors.Is(err, services.ErrTwoFactorNotPending) {
+			log.ErrorContext(r.Context(), "confirming two-factor setup", "err", err)
+		}
+		sess.SetFlash("error", "That code is not valid, please try again")
+		c.Redirect(w, r, routes.URL("account.two-factor"), http.StatusSeeOther)
+		return
+	}
+
+	sess.SetFlash("success", "Two-factor authentication is on. Save these recovery codes somewhere safe.")
+	sess.SetFlash("recovery_codes", codes)
+	c.Redirect(w, r, routes.URL("account.two-factor"), http.StatusSeeOther)
+}
+
+// HandleRegenerateRecoveryCodes replaces the recovery codes
+func (c *AccountController) HandleRegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	authData, ok := auth.GetAuthData(sess).(*services.AuthData)
+	if !ok || !authData.TwoFactorEnabled {
+		c.Redirect(w, r, routes.URL("account.two-factor"), http.StatusSeeOther)
+		return
+	}
+
+	codes, err := services.RegenerateRecoveryCodes(authData.UserID)
+	if err != nil {
+		log.ErrorContext(r.Context(), "regenerating recovery codes", "err", err)
+		sess.SetFlash("error", "New recovery codes could not be created")
+	} else {
+		sess.SetFlash("success", "Your old recovery codes no longer work. Save these new ones somewhere safe.")
+		sess.SetFlash("recovery_codes", codes)
+	}
+	c.Redirect(w, r, routes.URL("account.two-factor"), http.StatusSeeOther)
+}
+
+// HandleDisableTwoFactor turns two-factor authentication off after a password check
+func (c *AccountController) HandleDisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		c.SetError(r, "Failed to parse form data")
+		c.Redirect(w, r, routes.URL("account.two-factor"), http.StatusSeeOther)
+		return
+	}
+
+	if err := services.DisableTwoFactor(sess, r.FormValue("password")); err != nil {
+		log.ErrorContext(r.Context(), "disabling two-factor authentication", "err", err)
+		sess.SetFlash("error", "Two-factor authentication could not be turned off. Check your password.")
+	} else {
+		sess.SetFlash("success", "Two-factor authentication is off")
+	}
+	c.Redirect(w, r, routes.URL("account.two-factor"), http.StatusSeeOther)
+}
+
+// AccessTokens lists the user's API tokens with a form to create one
+func (c *AccountController) AccessTokens(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	authData, ok := auth.GetAuthData(sess).(*services.AuthData)
+	if !ok {
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+		return
+	}
+
+	tokens, err := services.AccessTokens(authData.UserID)
+	if err != nil {
+		log.ErrorContext(r.Context(), "listing access tokens", "err", err)
+		sess.SetFlash("error", "Could not load your API tokens")
+	}
+
+	newToken, _ := sess.GetFlash("access_token").(string)
+
+	c.Render(w, r, accountviews.TokensPage(accountviews.TokensPageData{
+		Tokens:    tokens,
+		Abilities: services.AccessTokenAbilities,
+		NewToken:  newToken,
+	}))
+}
+
+// HandleCreateAccessToken mints a token and shows it once
+func (c *AccountController) HandleCreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	authData, ok := auth.GetAuthData(sess).(*services.AuthData)
+	if !ok {
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		c.SetError(r, "Failed to parse form data")
+		c.Redirect(w, r, routes.URL("account.tokens"), http.StatusSeeOther)
+		return
+	}
+
+	days, err := strconv.Atoi(r.FormValue("expires"))
+	if err != nil || days < 0 {
+		days = 0
+	}
+
+	token, _, err := services.CreateAccessToken(
+		r.Context(),
+		authData.UserID,
+		r.FormValue("name"),
+		r.Form["abilities"],
+		time.Duration(days)*24*time.Hour,
+	)
+	if err != nil {
+		if errors.Is(err, services.ErrAccessTokenNameRequired) || errors.Is(err, services.ErrUnknownAbility) {
+			sess.SetFlash("error", err.Error())
+		} else {
+			log.ErrorContext(r.Context(), "creating access token", "err", err)
+			sess.SetFlash("error", "The token could not be created")
+		}
+		c.Redirect(w, r, routes.URL("account.tokens"), http.StatusSeeOther)
+		return
+	}
+
+	sess.SetFlash("success", "Token created")
+	sess.SetFlash("access_token", token)
+	c.Redirect(w, r, routes.URL("account.tokens"), http.StatusSeeOther)
+}
+
+// HandleRevokeAccessToken deletes one of the user's tokens
+func (c *AccountController) HandleRevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	authData, ok := auth.GetAuthData(sess).(*services.AuthData)
+	if !ok {
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err == nil {
+		err = services.RevokeAccessToken(authData.UserID, id)
+	}
+	if err != nil {
+		log.ErrorContext(r.Context(), "revoking access token", "err", err)
+		sess.SetFlash("error", "That token could not be revoked")
+	} else {
+		sess.SetFlash("success", "The token has been revoked")
+	}
+	c.Redirect(w, r, routes.URL("account.tokens"), http.StatusSeeOther)
+}
+
+// Identities lists the provider accounts linked to the user
+func (c *AccountController) Identities(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	authData, ok := auth.GetAuthData(sess).(*services.AuthData)
+	if !ok {
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+		return
+	}
+
+	identities, err := services.Identities(authData.UserID)
+	if err != nil {
+		log.ErrorContext(r.Context(), "listing linked accounts", "err", err)
+		sess.SetFlash("error", "Could not load your linked accounts")
+	}
+
+	c.Render(w, r, accountviews.IdentitiesPage(accountviews.IdentitiesPageData{
+		Identities: identities,
+		Providers:  oidc.Providers(),
+	}))
+}
+
+// HandleUnlinkIdentity removes one of the user's linked provider accounts
+func (c *AccountController) HandleUnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	authData, ok := auth.GetAuthData(sess).(*services.AuthData)
+	if !ok {
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err == nil {
+		err = services.UnlinkIdentity(authData.UserID, id)
+	}
+	if err != nil {
+		log.ErrorContext(r.Context(), "unlinking account", "err", err)
+		sess.SetFlash("error", "That account could not be unlinked")
+	} else {
+		sess.SetFlash("success", "The account has been unlinked")
+	}
+	c.Redirect(w, r, routes.URL("account.identities"), http.StatusSeeOther)
+}
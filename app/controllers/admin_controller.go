@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gohst/app/services"
+	"gohst/internal/audit"
+	"gohst/internal/auth"
+	"gohst/internal/middleware"
+	"gohst/internal/ratelimit"
+	"gohst/internal/routes"
+	"gohst/internal/session"
+	adminviews "gohst/views/admin"
+)
+
+const (
+	// ADMIN_USERS_PER_PAGE is how many users the admin list shows at a time
+	ADMIN_USERS_PER_PAGE = 50
+	// IMPERSONATION_LOG_LIMIT is how many log entries the admin log shows
+	IMPERSONATION_LOG_LIMIT = 200
+	// AUDIT_LOG_LIMIT is how many audit events the admin log shows
+	AUDIT_LOG_LIMIT = 200
+)
+
+// AdminController holds admin tools: the user list, impersonation and the
+// audit log
+type AdminController struct {
+	*AppController
+}
+
+func NewAdminController() *AdminController {
+	return &AdminController{
+		AppController: NewAppController(),
+	}
+}
+
+func (c *AdminController) RegisterRoutes(g *routes.Group) {
+	canImpersonate := middleware.Can("users.impersonate")
+
+	g.Use(
+		session.SM.SessionMiddleware,
+		middleware.Remember,
+		middleware.CSRF,
+		middleware.Auth,
+	)
+
+	// The impersonated user lacks the admin's permissions and may not have
+	// verified their email, so stopping only needs a login
+	g.HandleFunc("POST /impersonation/stop", c.HandleStopImpersonating).Name("admin.impersonation.stop")
+
+	g.Use(middleware.Verified, middleware.TwoFactorSetup)
+
+	g.HandleFunc("GET /users", c.Users, middleware.Can("users.view")).Name("admin.users")
+	g.HandleFunc("POST /users/{id}/impersonate", c.HandleImpersonate, middleware.NotImpersonating, canImpersonate).Name("admin.users.impersonate")
+	g.HandleFunc("GET /impersonations", c.Impersonations, canImpersonate).Name("admin.impersonations")
+	g.HandleFunc("GET /audit", c.Audit, middleware.Can("audit.view")).Name("admin.audit")
+}
+
+// Users lists user accounts a page at a time
+func (c *AdminController) Users(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	// Ask for one extra row to know whether a next page exists
+	users, err := services.ListUsers(ADMIN_USERS_PER_PAGE+1, (page-1)*ADMIN_USERS_PER_PAGE)
+	if err != nil {
+		log.ErrorContext(r.Context(), "listing users", "err", err)
+		sess.SetFlash("error", "Could not load the users")
+	}
+	hasNext := len(users) > ADMIN_USERS_PER_PAGE
+	if hasNext {
+		users = users[:ADMIN_USERS_PER_PAGE]
+	}
+
+	c.Render(w, r, adminviews.UsersPage(adminviews.UsersPageData{
+		Users:   users,
+		Page:    page,
+		HasNext: hasNext,
+	}))
+}
+
+// HandleImpersonate starts viewing the app as another user
+func (c *AdminController) HandleImpersonate(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	user, err := services.StartImpersonation(sess, id, ratelimit.ClientIP(r), r.UserAgent())
+	if err != nil {
+		if errors.Is(err, services.ErrCannotImpersonate) || errors.Is(err, auth.ErrAlreadyImpersonating) {
+			sess.SetFlash("error", err.Error())
+		} else {
+			log.ErrorContext(r.Context(), "starting impersonation", "err", err)
+			sess.SetFlash("error", "Could not impersonate that user")
+		}
+		c.Redirect(w, r, routes.URL("admin.users"), http.StatusSeeOther)
+		return
+	}
+
+	sess.SetFlash("success", "You are now viewing the app as "+user.Email)
+	c.Redirect(w, r, routes.URL("home"), http.StatusSeeOther)
+}
+
+// HandleStopImpersonating returns to the admin's own account
+func (c *AdminController) HandleStopImpersonating(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	if err := services.StopImpersonation(sess, ratelimit.ClientIP(r), r.UserAgent()); err != nil {
+		if !errors.Is(err, auth.ErrNotImpersonating) {
+			log.ErrorContext(r.Context(), "stopping impersonation", "err", err)
+		}
+		c.Redirect(w, r, routes.URL("home"), http.StatusSeeOther)
+		return
+	}
+
+	sess.SetFlash("success", "You are back in your own account")
+	c.Redirect(w, r, routes.URL("admin.users"), http.StatusSeeOther)
+}
+
+// Impersonations shows the impersonation log
+func (c *AdminController) Impersonations(w http.ResponseWriter, r *http.Request) {
+	entries, err := services.ImpersonationLogs(IMPERSONATION_LOG_LIMIT)
+	if err != nil {
+		log.ErrorContext(r.Context(), "listing impersonation log", "err", err)
+	}
+
+	c.Render(w, r, adminviews.ImpersonationsPage(adminviews.ImpersonationsPageData{
+		Entries: entries,
+	}))
+}
+
+// Audit shows the security audit log, filtered by event type, user (ID or
+// email) and IP address
+func (c *AdminController) Audit(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	query := r.URL.Query()
+
+	data := adminviews.AuditPageData{
+		Type: query.Get("type"),
+		User: query.Get("user"),
+		IP:   query.Get("ip"),
+	}
+
+	userID, err := services.AuditUserID(data.User)
+	if err != nil {
+		sess.SetFlash("error", "No account uses that email")
+		c.Render(w, r, adminviews.AuditPage(data))
+		return
+	}
+
+	data.Events, err = services.AuditEvents(r.Context(), audit.Filter{
+		Type:   data.Type,
+		UserID: userID,
+		IP:     data.IP,
+		Limit:  AUDIT_LOG_LIMIT,
+	})
+	if err != nil {
+		log.ErrorContext(r.Context(), "searching audit log", "err", err)
+		sess.SetFlash("error", "Could not load the audit log")
+	}
+
+	c.Render(w, r, adminviews.AuditPage(data))
+}
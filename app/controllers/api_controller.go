@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"net/http"
+
+	"gohst/app/services"
+	"gohst/internal/auth"
+	"gohst/internal/middleware"
+	"gohst/internal/ratelimit"
+	"gohst/internal/routes"
+	"gohst/internal/session"
+)
+
+// ApiController serves the JSON API. Requests authenticate with a personal
+// access token instead of a session cookie, so there is no CSRF check.
+type ApiController struct {
+	*AppController
+}
+
+func NewApiController() *ApiController {
+	return &ApiController{
+		AppController: NewAppController(),
+	}
+}
+
+func (c *ApiController) RegisterRoutes(g *routes.Group) {
+	limiter := ratelimit.NewAPIDefaultLimiter(ratelimit.NewStore())
+
+	g.Use(
+		limiter.Middleware,
+		middleware.BearerAuth,
+	)
+
+	g.HandleFunc("GET /me", c.Me, middleware.Ability("user:read")).Name("api.me")
+}
+
+// Me returns the user the access token belongs to
+func (c *ApiController) Me(w http.ResponseWriter, r *http.Request) {
+	authData, ok := auth.GetAuthData(session.FromContext(r.Context())).(*services.AuthData)
+	if !ok {
+		c.JSON(w, http.StatusUnauthorized, map[string]string{"error": http.StatusText(http.StatusUnauthorized)})
+		return
+	}
+
+	response := struct {
+		ID            uint64 `json:"id"`
+		Email         string `json:"email"`
+		Name          string `json:"name"`
+		EmailVerified bool   `json:"email_verified"`
+	}{
+		ID:            authData.UserID,
+		Email:         authData.Email,
+		Name:          authData.Name,
+		EmailVerified: authData.EmailVerified,
+	}
+
+	c.JSON(w, http.StatusOK, response)
+}
@@ -2,8 +2,11 @@ package controllers
 
 import (
 	"gohst/internal/controllers"
+	"gohst/internal/logger"
 )
 
+var log = logger.Component("controllers")
+
 // AppController provides application-specific functionality that extends the framework's BaseController.
 // This is the base controller for all application controllers and should be embedded rather than used directly.
 //
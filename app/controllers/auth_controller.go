@@ -1,13 +1,17 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
+	appConfig "gohst/app/config"
 	"gohst/app/services"
 	"gohst/internal/auth"
 	"gohst/internal/forms"
 	"gohst/internal/middleware"
+	"gohst/internal/oidc"
 	"gohst/internal/ratelimit"
+	"gohst/internal/routes"
 	"gohst/internal/session"
 	"gohst/internal/utils"
 	"gohst/internal/validation"
@@ -26,52 +30,40 @@ func NewAuthController() *AuthController {
 	return a
 }
 
-func (c *AuthController) RegisterRoutes() http.Handler {
+func (c *AuthController) RegisterRoutes(g *routes.Group) {
 	store := ratelimit.NewStore()
 	authLimiter := ratelimit.NewAuthSensitiveLimiter(store, "email")
+	// The code form has no email; limit guesses per challenged user instead
+	twoFactorLimiter := ratelimit.NewLimiter(store, ratelimit.AuthSensitivePolicy(), services.KeyByTwoFactorChallenge())
 
-	guestMux := http.NewServeMux()
-	guestMux.HandleFunc("GET /login", c.Login)
-	guestMux.HandleFunc("POST /login", c.HandleLogin)
-	guestMux.HandleFunc("GET /register", c.Register)
-	guestMux.HandleFunc("POST /register", c.HandleRegister)
-
-	guestRoutes := middleware.Chain(
-		guestMux,
+	g.Use(
 		session.SM.SessionMiddleware,
+		middleware.Remember,
 		middleware.CSRF,
-		middleware.Logger,
-		middleware.Guest,
-		authLimiter.Middleware,
 	)
 
-	authMux := http.NewServeMux()
-	authMux.HandleFunc("POST /logout", c.HandleLogout)
+	guest := g.Group("", middleware.Guest, authLimiter.Middleware)
+	guest.HandleFunc("GET /login", c.Login).Name("auth.login")
+	guest.HandleFunc("POST /login", c.HandleLogin).Name("auth.login.submit")
+	guest.HandleFunc("GET /register", c.Register).Name("auth.register")
+	guest.HandleFunc("POST /register", c.HandleRegister).Name("auth.register.submit")
+	guest.HandleFunc("GET /two-factor", c.TwoFactorChallenge).Name("auth.two-factor")
+	guest.HandleFunc("POST /two-factor", c.HandleTwoFactorChallenge, twoFactorLimiter.Middleware).Name("auth.two-factor.submit")
+	guest.HandleFunc("GET /unlock", c.Unlock).Name("auth.unlock")
 
-	authRoutes := middleware.Chain(
-		authMux,
-		session.SM.SessionMiddleware,
-		middleware.CSRF,
-		middleware.Logger,
-		middleware.Auth,
-	)
-
-	parentMux := http.NewServeMux()
-	parentMux.Handle("/logout", authRoutes)
-	parentMux.Handle("/", guestRoutes)
-
-	return parentMux
+	g.HandleFunc("POST /logout", c.HandleLogout, middleware.Auth).Name("auth.logout")
 }
 
 func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 	sess := session.FromContext(r.Context())
 
 	emailValue, _ := sess.PeekOld("email")
+	rememberValue, _ := sess.PeekOld("remember")
 
 	data := authviews.LoginPageData{
 		Form: forms.Form{
 			Method: "POST",
-			Action: "/auth/login",
+			Action: routes.URL("auth.login.submit"),
 			Fields: forms.Fields{
 				"email": forms.Field{
 					Input: forms.Text{
@@ -97,6 +89,17 @@ func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 				"submit": {Type: "submit", Text: "Login"},
 			},
 		},
+		Remember: forms.CheckBox{
+			Name: "remember",
+			Options: []forms.CheckBoxOption{{
+				Option:  forms.Option{Value: "1", Label: "Remember me"},
+				Name:    "remember",
+				ID:      "remember",
+				Checked: utils.StringOr(rememberValue, "") == "1",
+			}},
+		},
+		Providers:  oidc.Providers(),
+		MagicLinks: appConfig.App != nil && appConfig.App.Features.EnableMagicLinks,
 	}
 
 	c.Render(w, r, authviews.LoginPage(data))
@@ -105,7 +108,7 @@ func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 // Handle the login info that is submitted from the form
 func (c *AuthController) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	sess := session.FromContext(r.Context())
-	loginUri := "/auth/login"
+	loginUri := routes.URL("auth.login")
 	// Parse the form data
 	if err := r.ParseForm(); err != nil {
 		c.SetError(r, "Failed to parse form data")
@@ -116,7 +119,11 @@ func (c *AuthController) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	// Get email and password from the form
 	email := r.FormValue("email")
 	password := r.FormValue("password")
+	remember := r.FormValue("remember") == "1"
 	sess.SetOld("email", email)
+	if remember {
+		sess.SetOld("remember", "1")
+	}
 
 	// Validate input
 	if email == "" || password == "" {
@@ -133,7 +140,21 @@ func (c *AuthController) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
     // Find user in database
-	user, err := services.Login(sess, email, password)
+	user, err := services.Login(r.Context(), sess, email, password, remember)
+	if errors.Is(err, services.ErrTwoFactorRequired) {
+		c.Redirect(w, r, routes.URL("auth.two-factor"), http.StatusSeeOther)
+		return
+	}
+	if errors.Is(err, services.ErrAccountLocked) {
+		sess.SetFlash("login_error", "Too many failed login attempts. Check your email or try again later.")
+		c.Redirect(w, r, loginUri, http.StatusSeeOther)
+		return
+	}
+	if errors.Is(err, services.ErrEmailNotVerified) {
+		sess.SetFlash("verify_error", err.Error())
+		c.Redirect(w, r, routes.URL("verification.notice"), http.StatusSeeOther)
+		return
+	}
 	if err != nil {
 		sess.SetFlash("login_error", "Invalid email or password")
 		c.Redirect(w, r, loginUri, http.StatusSeeOther)
@@ -141,15 +162,95 @@ func (c *AuthController) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify password
-	passwordOk, _ := utils.CheckPassword(password, user.PasswordHash)
+	passwordOk, _, _ := utils.CheckPassword(password, user.PasswordHash)
 	if !passwordOk {
 		sess.SetFlash("login_error", "Invalid email or password")
 		c.Redirect(w, r, loginUri, http.StatusSeeOther)
 		return
 	}
 
-	// Redirect to dashboard or home page
-	c.Redirect(w, r, "/", http.StatusSeeOther)
+	// Back to the page that asked for a login, or the home page
+	c.Redirect(w, r, auth.PullIntendedURL(sess), http.StatusSeeOther)
+}
+
+// TwoFactorChallenge asks for the authenticator or recovery code after the
+// password has been checked
+func (c *AuthController) TwoFactorChallenge(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	challenge, ok := services.PendingTwoFactorChallenge(sess)
+	if !ok {
+		sess.SetFlash("login_error", services.ErrTwoFactorChallengeExpired.Error())
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+		return
+	}
+
+	data := authviews.TwoFactorChallengePageData{
+		Email: challenge.Email,
+		Form: forms.Form{
+			Method: "POST",
+			Action: routes.URL("auth.two-factor.submit"),
+			Fields: forms.Fields{
+				"code": forms.Field{
+					Input: forms.Text{Name: "code", Type: "text", ID: "code", Placeholder: "123456"},
+					Label: forms.Label{For: "code", Text: "Authentication or recovery code"},
+				},
+			},
+			Buttons: map[string]forms.Button{
+				"submit": {Type: "submit", Text: "Verify"},
+			},
+		},
+	}
+
+	c.Render(w, r, authviews.TwoFactorChallengePage(data))
+}
+
+// HandleTwoFactorChallenge finishes a login with the second factor
+func (c *AuthController) HandleTwoFactorChallenge(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		c.SetError(r, "Failed to parse form data")
+		c.Redirect(w, r, routes.URL("auth.two-factor"), http.StatusSeeOther)
+		return
+	}
+
+	err := services.CompleteTwoFactorChallenge(r.Context(), sess, r.FormValue("code"))
+	switch {
+	case err == nil:
+		c.Redirect(w, r, auth.PullIntendedURL(sess), http.StatusSeeOther)
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		sess.SetFlash("two_factor_error", err.Error())
+		c.Redirect(w, r, routes.URL("auth.two-factor"), http.StatusSeeOther)
+	case errors.Is(err, services.ErrTwoFactorChallengeExpired):
+		sess.SetFlash("login_error", err.Error())
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+	case errors.Is(err, services.ErrAccountLocked):
+		sess.SetFlash("login_error", "Too many failed login attempts. Check your email or try again later.")
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+	default:
+		log.ErrorContext(r.Context(), "completing two-factor login", "err", err)
+		sess.SetFlash("two_factor_error", "Something went wrong, please try again")
+		c.Redirect(w, r, routes.URL("auth.two-factor"), http.StatusSeeOther)
+	}
+}
+
+// Unlock lifts a lockout through the signed link emailed when the account
+// was locked
+func (c *AuthController) Unlock(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	if _, err := services.UnlockAccountFromLink(r.URL); err != nil {
+		if !errors.Is(err, services.ErrInvalidUnlockLink) {
+			log.ErrorContext(r.Context(), "unlocking account", "err", err)
+		}
+		sess.SetFlash("login_error", services.ErrInvalidUnlockLink.Error())
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+		return
+	}
+
+	sess.SetFlash("login_success", "Your account is unlocked. You can now log in.")
+	c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
 }
 
 func (c *AuthController) Register(w http.ResponseWriter, r *http.Request) {
@@ -163,7 +264,7 @@ func (c *AuthController) Register(w http.ResponseWriter, r *http.Request) {
 	data := authviews.RegisterPageData{
 		Form: forms.Form{
 			Method: "POST",
-			Action: "/auth/register",
+			Action: routes.URL("auth.register.submit"),
 			Fields: forms.Fields{
 				"first_name": forms.Field{
 					Input: forms.Text{Name: "first_name", Type: "text", ID: "first_name", Placeholder: "Your first name", Value: utils.StringOr(firstName, "")},
@@ -201,7 +302,7 @@ func (c *AuthController) Register(w http.ResponseWriter, r *http.Request) {
 
 func (c *AuthController) HandleRegister(w http.ResponseWriter, r *http.Request) {
 	sess := session.FromContext(r.Context())
-	registerUri := "/auth/register"
+	registerUri := routes.URL("auth.register")
 
 	// Parse the form data
 	if err := r.ParseForm(); err != nil {
@@ -260,7 +361,7 @@ func (c *AuthController) HandleRegister(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Register the user
-	err := services.Register(email, firstName, lastName, password)
+	err := services.Register(r.Context(), email, firstName, lastName, password)
 	if err != nil {
 		sess.SetFlash("register_error", err.Error())
 		c.Redirect(w, r, registerUri, http.StatusSeeOther)
@@ -268,22 +369,22 @@ func (c *AuthController) HandleRegister(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Set success message
-	sess.SetFlash("login_success", "Registration successful! You can now log in.")
+	sess.SetFlash("login_success", "Registration successful! Check your email for a link to verify your address.")
 
 	// Redirect to login page
-	c.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+	c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
 }
 
 // HandleLogout processes logout requests
 func (c *AuthController) HandleLogout(w http.ResponseWriter, r *http.Request) {
 	sess := session.FromContext(r.Context())
 
-	// Use the auth package's logout function (no error to check)
-	auth.Logout(sess)
+	// Records the logout, then clears the login (no error to check)
+	services.Logout(r.Context(), sess)
 
 	// Set a success message (after regeneration)
 	sess.SetFlash("success", "You have been logged out successfully")
 
 	// Redirect to home page
-	c.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+	c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
 }
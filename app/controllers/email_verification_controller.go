@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"gohst/app/services"
+	"gohst/internal/auth"
+	"gohst/internal/forms"
+	"gohst/internal/middleware"
+	"gohst/internal/ratelimit"
+	"gohst/internal/routes"
+	"gohst/internal/session"
+	"gohst/internal/utils"
+	"gohst/internal/validation"
+	authviews "gohst/views/auth"
+)
+
+// EmailVerificationController confirms signed verification links and lets
+// users ask for a new one.
+type EmailVerificationController struct {
+	*AppController
+}
+
+func NewEmailVerificationController() *EmailVerificationController {
+	c := &EmailVerificationController{
+		AppController: NewAppController(),
+	}
+	c.View.SetLayout("layouts/auth")
+	return c
+}
+
+func (c *EmailVerificationController) RegisterRoutes(g *routes.Group) {
+	store := ratelimit.NewStore()
+	authLimiter := ratelimit.NewAuthSensitiveLimiter(store, "email")
+
+	g.Use(
+		session.SM.SessionMiddleware,
+		middleware.Remember,
+		middleware.CSRF,
+		authLimiter.Middleware,
+	)
+
+	g.HandleFunc("GET /notice", c.Notice).Name("verification.notice")
+	g.HandleFunc("POST /resend", c.HandleResend).Name("verification.resend")
+	g.HandleFunc("GET /confirm", c.Confirm).Name("verification.confirm")
+}
+
+// Notice explains that the email must be verified and offers a new link.
+// Logged-in users resend to their own address; guests enter theirs.
+func (c *EmailVerificationController) Notice(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	authenticated := auth.IsAuthenticated(sess)
+	if authenticated && services.RefreshEmailVerified(sess) {
+		c.Redirect(w, r, routes.URL("home"), http.StatusSeeOther)
+		return
+	}
+
+	emailValue, _ := sess.PeekOld("email")
+	data := authviews.VerifyNoticePageData{
+		Authenticated: authenticated,
+		Form: forms.Form{
+			Method: "POST",
+			Action: routes.URL("verification.resend"),
+			Fields: forms.Fields{
+				"email": forms.Field{
+					Input: forms.Text{Name: "email", Type: "email", ID: "email", Placeholder: "Enter your email.", Value: utils.StringOr(emailValue, "")},
+					Label: forms.Label{For: "email", Text: "Email"},
+				},
+			},
+			Buttons: map[string]forms.Button{
+				"submit": {Type: "submit", Text: "Resend Verification Email"},
+			},
+		},
+	}
+
+	c.Render(w, r, authviews.VerifyNoticePage(data))
+}
+
+// HandleResend sends a new verification link
+func (c *EmailVerificationController) HandleResend(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	noticeUri := routes.URL("verification.notice")
+
+	if err := r.ParseForm(); err != nil {
+		c.SetError(r, "Failed to parse form data")
+		c.Redirect(w, r, noticeUri, http.StatusSeeOther)
+		return
+	}
+
+	email := r.FormValue("email")
+	if authData, ok := auth.GetAuthData(sess).(*services.AuthData); ok {
+		email = authData.Email
+	} else {
+		sess.SetOld("email", email)
+	}
+
+	if !validation.IsEmail(email) {
+		sess.SetFlash("verify_error", "Please enter a valid email address")
+		c.Redirect(w, r, noticeUri, http.StatusSeeOther)
+		return
+	}
+
+	if err := services.ResendVerificationEmail(r.Context(), email); err != nil {
+		log.ErrorContext(r.Context(), "resending verification email", "err", err)
+	}
+
+	sess.SetFlash("verify_success", "If that account still needs verifying, a new link has been sent.")
+	c.Redirect(w, r, noticeUri, http.StatusSeeOther)
+}
+
+// Confirm verifies the signed link from the email
+func (c *EmailVerificationController) Confirm(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	user, err := services.VerifyEmail(r.URL)
+	if err != nil {
+		if !errors.Is(err, services.ErrInvalidVerificationLink) {
+			log.ErrorContext(r.Context(), "verifying email", "err", err)
+		}
+		sess.SetFlash("verify_error", services.ErrInvalidVerificationLink.Error())
+		c.Redirect(w, r, routes.URL("verification.notice"), http.StatusSeeOther)
+		return
+	}
+
+	if authData, ok := auth.GetAuthData(sess).(*services.AuthData); ok && authData.UserID == user.ID {
+		authData.EmailVerified = true
+		sess.Set(auth.AuthKey, authData)
+		sess.SetFlash("success", "Thanks, your email address is verified.")
+		c.Redirect(w, r, routes.URL("home"), http.StatusSeeOther)
+		return
+	}
+
+	sess.SetFlash("login_success", "Thanks, your email address is verified. You can now log in.")
+	c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+}
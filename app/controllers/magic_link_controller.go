@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	appConfig "gohst/app/config"
+	"gohst/app/services"
+	"gohst/internal/auth"
+	"gohst/internal/forms"
+	"gohst/internal/middleware"
+	"gohst/internal/ratelimit"
+	"gohst/internal/routes"
+	"gohst/internal/session"
+	"gohst/internal/utils"
+	"gohst/internal/validation"
+	authviews "gohst/views/auth"
+)
+
+// MagicLinkController handles passwordless login: requesting a one-time
+// login link by email and logging in from that link. The routes exist only
+// while FEATURE_MAGIC_LINKS is on.
+type MagicLinkController struct {
+	*AppController
+}
+
+func NewMagicLinkController() *MagicLinkController {
+	c := &MagicLinkController{
+		AppController: NewAppController(),
+	}
+	c.View.SetLayout("layouts/auth")
+	return c
+}
+
+func (c *MagicLinkController) RegisterRoutes(g *routes.Group) {
+	store := ratelimit.NewStore()
+	authLimiter := ratelimit.NewAuthSensitiveLimiter(store, "email")
+
+	g.Use(
+		magicLinksEnabled,
+		session.SM.SessionMiddleware,
+		middleware.Remember,
+		middleware.CSRF,
+		middleware.Guest,
+		authLimiter.Middleware,
+	)
+
+	g.HandleFunc("GET /request", c.Request).Name("magic.request")
+	g.HandleFunc("POST /request", c.HandleRequest).Name("magic.request.submit")
+	g.HandleFunc("GET /verify", c.Verify).Name("magic.verify")
+}
+
+// magicLinksEnabled answers 404 while magic links are turned off
+func magicLinksEnabled(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if appConfig.App == nil || !appConfig.App.Features.EnableMagicLinks {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Request shows the form asking for the account email
+func (c *MagicLinkController) Request(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	emailValue, _ := sess.PeekOld("email")
+	rememberValue, _ := sess.PeekOld("remember")
+
+	data := authviews.MagicLinkPageData{
+		Form: forms.Form{
+			Method: "POST",
+			Action: routes.URL("magic.request.submit"),
+			Fields: forms.Fields{
+				"email": forms.Field{
+					Input: forms.Text{Name: "email", Type: "email", ID: "email", Placeholder: "Enter your email.", Value: utils.StringOr(emailValue, "")},
+					Label: forms.Label{For: "email", Text: "Email"},
+				},
+			},
+			Buttons: map[string]forms.Button{
+				"submit": {Type: "submit", Text: "Send Login Link"},
+			},
+		},
+		Remember: forms.CheckBox{
+			Name: "remember",
+			Options: []forms.CheckBoxOption{{
+				Option:  forms.Option{Value: "1", Label: "Remember me"},
+				Name:    "remember",
+				ID:      "remember",
+				Checked: utils.StringOr(rememberValue, "") == "1",
+			}},
+		},
+	}
+
+	c.Render(w, r, authviews.MagicLinkPage(data))
+}
+
+// HandleRequest emails a login link. The response is the same whether or not
+// the email belongs to an account.
+func (c *MagicLinkController) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	requestUri := routes.URL("magic.request")
+
+	if err := r.ParseForm(); err != nil {
+		c.SetError(r, "Failed to parse form data")
+		c.Redirect(w, r, requestUri, http.StatusSeeOther)
+		return
+	}
+
+	email := r.FormValue("email")
+	remember := r.FormValue("remember") == "1"
+	sess.SetOld("email", email)
+	if remember {
+		sess.SetOld("remember", "1")
+	}
+
+	if !validation.IsEmail(email) {
+		sess.SetFlash("magic_error", "Please enter a valid email address")
+		c.Redirect(w, r, requestUri, http.StatusSeeOther)
+		return
+	}
+
+	if err := services.RequestMagicLink(r.Context(), sess, email, remember); err != nil {
+		log.ErrorContext(r.Context(), "requesting login link", "err", err)
+	}
+
+	sess.SetFlash("login_success", "If an account exists for that email, a login link has been sent. Open it in this browser.")
+	c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+}
+
+// Verify logs the user in from an emailed link
+func (c *MagicLinkController) Verify(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	// Keep the token out of Referer headers sent by the next page
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	_, err := services.LoginWithMagicLink(r.Context(), sess, r.URL)
+	switch {
+	case err == nil:
+		c.Redirect(w, r, auth.PullIntendedURL(sess), http.StatusSeeOther)
+	case errors.Is(err, services.ErrTwoFactorRequired):
+		c.Redirect(w, r, routes.URL("auth.two-factor"), http.StatusSeeOther)
+	case errors.Is(err, services.ErrInvalidMagicLink), errors.Is(err, services.ErrMagicLinkOtherBrowser):
+		sess.SetFlash("magic_error", err.Error())
+		c.Redirect(w, r, routes.URL("magic.request"), http.StatusSeeOther)
+	case errors.Is(err, services.ErrAccountInactive):
+		sess.SetFlash("login_error", "Your account is inactive")
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+	default:
+		log.ErrorContext(r.Context(), "logging in with login link", "err", err)
+		sess.SetFlash("magic_error", "Could not log you in, please request a new link")
+		c.Redirect(w, r, routes.URL("magic.request"), http.StatusSeeOther)
+	}
+}
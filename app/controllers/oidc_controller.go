@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"gohst/app/services"
+	"gohst/internal/auth"
+	"gohst/internal/middleware"
+	"gohst/internal/oidc"
+	"gohst/internal/ratelimit"
+	"gohst/internal/routes"
+	"gohst/internal/session"
+)
+
+// OIDCController logs users in with OpenID Connect providers, and links
+// provider accounts to the logged-in user
+type OIDCController struct {
+	*AppController
+}
+
+func NewOIDCController() *OIDCController {
+	return &OIDCController{
+		AppController: NewAppController(),
+	}
+}
+
+func (c *OIDCController) RegisterRoutes(g *routes.Group) {
+	store := ratelimit.NewStore()
+	limiter := ratelimit.NewLimiter(store, ratelimit.AuthSensitivePolicy(), ratelimit.KeyByIPAndRoute())
+
+	g.Use(
+		session.SM.SessionMiddleware,
+		middleware.Remember,
+		middleware.CSRF,
+		limiter.Middleware,
+	)
+
+	g.HandleFunc("GET /{provider}", c.Start).Name("oidc.start")
+	g.HandleFunc("GET /{provider}/callback", c.Callback).Name("oidc.callback")
+}
+
+// Start sends the user to the provider to log in
+func (c *OIDCController) Start(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	provider, err := oidc.Get(r.PathValue("provider"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	authURL, err := oidc.Begin(r.Context(), sess, provider)
+	if err != nil {
+		log.ErrorContext(r.Context(), "starting provider login", "provider", provider.Name, "err", err)
+		sess.SetFlash("login_error", provider.DisplayName+" login is not available right now")
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback finishes a login at the provider. A logged-in user links the
+// provider account instead.
+func (c *OIDCController) Callback(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	provider, err := oidc.Get(r.PathValue("provider"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	claims, err := oidc.Complete(r.Context(), sess, provider, r)
+	if err != nil {
+		log.ErrorContext(r.Context(), "completing provider login", "provider", provider.Name, "err", err)
+		message := provider.DisplayName + " login failed, please try again"
+		if errors.Is(err, oidc.ErrInvalidState) {
+			message = "That login link has expired, please try again"
+		}
+		sess.SetFlash("login_error", message)
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+		return
+	}
+
+	if authData, ok := auth.GetAuthData(sess).(*services.AuthData); ok {
+		c.link(w, r, sess, authData, provider, claims)
+		return
+	}
+
+	_, err = services.SocialLogin(r.Context(), sess, provider.Name, claims)
+	switch {
+	case err == nil:
+		c.Redirect(w, r, auth.PullIntendedURL(sess), http.StatusSeeOther)
+	case errors.Is(err, services.ErrTwoFactorRequired):
+		c.Redirect(w, r, routes.URL("auth.two-factor"), http.StatusSeeOther)
+	case errors.Is(err, services.ErrEmailNotVerified):
+		sess.SetFlash("verify_error", err.Error())
+		c.Redirect(w, r, routes.URL("verification.notice"), http.StatusSeeOther)
+	case errors.Is(err, services.ErrSocialAccountExists):
+		sess.SetOld("email", claims.Email)
+		sess.SetFlash("login_error", "An account with this email already exists. Log in with your password, then link "+
+			provider.DisplayName+" from your account settings.")
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+	case errors.Is(err, services.ErrSocialEmailRequired), errors.Is(err, services.ErrSocialRegistrationClosed):
+		sess.SetFlash("login_error", "No account matches your "+provider.DisplayName+" login")
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+	case errors.Is(err, services.ErrAccountInactive):
+		sess.SetFlash("login_error", "Your account is inactive")
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+	default:
+		log.ErrorContext(r.Context(), "logging in with provider", "provider", provider.Name, "err", err)
+		sess.SetFlash("login_error", provider.DisplayName+" login failed, please try again")
+		c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+	}
+}
+
+// link adds the provider account to the logged-in user's linked accounts
+func (c *OIDCController) link(w http.ResponseWriter, r *http.Request, sess *session.Session, authData *services.AuthData, provider *oidc.Provider, claims *oidc.Claims) {
+	var err error
+	if auth.IsImpersonating(sess) {
+		err = auth.ErrAlreadyImpersonating
+	} else {
+		err = services.LinkIdentity(authData.UserID, provider.Name, claims)
+	}
+	switch {
+	case err == nil:
+		sess.SetFlash("success", provider.DisplayName+" is linked to your account")
+	case errors.Is(err, auth.ErrAlreadyImpersonating):
+		sess.SetFlash("error", "Accounts cannot be linked while impersonating a user")
+	case errors.Is(err, services.ErrIdentityLinkedElsewhere):
+		sess.SetFlash("error", "That "+provider.DisplayName+" account is linked to another user")
+	default:
+		log.ErrorContext(r.Context(), "linking provider account", "provider", provider.Name, "err", err)
+		sess.SetFlash("error", provider.DisplayName+" could not be linked")
+	}
+	c.Redirect(w, r, routes.URL("account.identities"), http.StatusSeeOther)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"gohst/internal/middleware"
+	"gohst/internal/routes"
 	"gohst/internal/session"
 	"gohst/views/pages"
 )
@@ -44,16 +45,15 @@ func (c *PagesController) NotFound(w http.ResponseWriter, r *http.Request) {
     c.Render(w, r, pages.NotFoundPage())
 }
 
-func (c *PagesController) RegisterRoutes() http.Handler {
-    mux := http.NewServeMux()
-    mux.HandleFunc("GET /{$}", c.Index)
-    mux.HandleFunc("GET /post/{id}", c.Post)
-    mux.HandleFunc("GET /", c.NotFound)
-
-    return middleware.Chain(
-        mux,
+func (c *PagesController) RegisterRoutes(g *routes.Group) {
+    g.Use(
         session.SM.SessionMiddleware,
+        middleware.Remember,
         middleware.CSRF,
-        middleware.Logger,
+        middleware.TwoFactorSetup,
     )
+
+    g.HandleFunc("GET /{$}", c.Index).Name("home")
+    g.HandleFunc("GET /post/{id}", c.Post).Name("pages.post")
+    g.HandleFunc("GET /", c.NotFound)
 }
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"gohst/app/services"
+	"gohst/internal/forms"
+	"gohst/internal/middleware"
+	"gohst/internal/ratelimit"
+	"gohst/internal/routes"
+	"gohst/internal/session"
+	"gohst/internal/utils"
+	"gohst/internal/validation"
+	authviews "gohst/views/auth"
+)
+
+// PasswordResetController handles forgotten passwords: requesting a reset
+// link by email and choosing a new password from that link.
+type PasswordResetController struct {
+	*AppController
+}
+
+func NewPasswordResetController() *PasswordResetController {
+	c := &PasswordResetController{
+		AppController: NewAppController(),
+	}
+	c.View.SetLayout("layouts/auth")
+	return c
+}
+
+func (c *PasswordResetController) RegisterRoutes(g *routes.Group) {
+	store := ratelimit.NewStore()
+	authLimiter := ratelimit.NewAuthSensitiveLimiter(store, "email")
+
+	g.Use(
+		session.SM.SessionMiddleware,
+		middleware.Remember,
+		middleware.CSRF,
+		middleware.Guest,
+		authLimiter.Middleware,
+	)
+
+	g.HandleFunc("GET /forgot", c.Forgot).Name("password.forgot")
+	g.HandleFunc("POST /forgot", c.HandleForgot).Name("password.forgot.submit")
+	g.HandleFunc("GET /reset", c.Reset).Name("password.reset")
+	g.HandleFunc("POST /reset", c.HandleReset).Name("password.reset.submit")
+}
+
+// Forgot shows the form asking for the account email
+func (c *PasswordResetController) Forgot(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	emailValue, _ := sess.PeekOld("email")
+
+	data := authviews.ForgotPasswordPageData{
+		Form: forms.Form{
+			Method: "POST",
+			Action: routes.URL("password.forgot.submit"),
+			Fields: forms.Fields{
+				"email": forms.Field{
+					Input: forms.Text{Name: "email", Type: "email", ID: "email", Placeholder: "Enter your email.", Value: utils.StringOr(emailValue, "")},
+					Label: forms.Label{For: "email", Text: "Email"},
+				},
+			},
+			Buttons: map[string]forms.Button{
+				"submit": {Type: "submit", Text: "Send Reset Link"},
+			},
+		},
+	}
+
+	c.Render(w, r, authviews.ForgotPasswordPage(data))
+}
+
+// HandleForgot emails a reset link. The response is the same whether or not
+// the email belongs to an account.
+func (c *PasswordResetController) HandleForgot(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	forgotUri := routes.URL("password.forgot")
+
+	if err := r.ParseForm(); err != nil {
+		c.SetError(r, "Failed to parse form data")
+		c.Redirect(w, r, forgotUri, http.StatusSeeOther)
+		return
+	}
+
+	email := r.FormValue("email")
+	sess.SetOld("email", email)
+
+	if !validation.IsEmail(email) {
+		sess.SetFlash("reset_error", "Please enter a valid email address")
+		c.Redirect(w, r, forgotUri, http.StatusSeeOther)
+		return
+	}
+
+	if err := services.RequestPasswordReset(r.Context(), email); err != nil {
+		log.ErrorContext(r.Context(), "requesting password reset", "err", err)
+	}
+
+	sess.SetFlash("login_success", "If an account exists for that email, a password reset link has been sent.")
+	c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+}
+
+// Reset shows the new password form for a reset link
+func (c *PasswordResetController) Reset(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+	token := r.URL.Query().Get("token")
+
+	// Keep the token out of Referer headers sent by this page
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	if err := services.ValidatePasswordResetToken(token); err != nil {
+		sess.SetFlash("reset_error", err.Error())
+		c.Redirect(w, r, routes.URL("password.forgot"), http.StatusSeeOther)
+		return
+	}
+
+	data := authviews.ResetPasswordPageData{
+		Token: token,
+		Form: forms.Form{
+			Method: "POST",
+			Action: routes.URL("password.reset.submit"),
+			Fields: forms.Fields{
+				"password": forms.Field{
+					Input: forms.Text{Name: "password", Type: "password", ID: "password", Placeholder: "Enter a new password."},
+					Label: forms.Label{For: "password", Text: "New Password"},
+				},
+				"password_confirm": forms.Field{
+					Input: forms.Text{Name: "password_confirm", Type: "password", ID: "password_confirm", Placeholder: "Confirm your new password."},
+					Label: forms.Label{For: "password_confirm", Text: "Confirm Password"},
+				},
+			},
+			Buttons: map[string]forms.Button{
+				"submit": {Type: "submit", Text: "Reset Password"},
+			},
+		},
+	}
+
+	c.Render(w, r, authviews.ResetPasswordPage(data))
+}
+
+// HandleReset sets the new password and logs the user out everywhere
+func (c *PasswordResetController) HandleReset(w http.ResponseWriter, r *http.Request) {
+	sess := session.FromContext(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		c.SetError(r, "Failed to parse form data")
+		c.Redirect(w, r, routes.URL("password.forgot"), http.StatusSeeOther)
+		return
+	}
+
+	token := r.FormValue("token")
+	password := r.FormValue("password")
+	passwordConfirm := r.FormValue("password_confirm")
+	resetUri := routes.URL("password.reset", "token", token)
+
+	if password == "" || passwordConfirm == "" {
+		sess.SetFlash("reset_error", "All fields are required")
+		c.Redirect(w, r, resetUri, http.StatusSeeOther)
+		return
+	}
+
+	if password != passwordConfirm {
+		sess.SetFlash("reset_error", "Passwords do not match")
+		c.Redirect(w, r, resetUri, http.StatusSeeOther)
+		return
+	}
+
+	if len(password) < 8 {
+		sess.SetFlash("reset_error", "Password must be at least 8 characters")
+		c.Redirect(w, r, resetUri, http.StatusSeeOther)
+		return
+	}
+
+	if err := services.ResetPassword(r.Context(), token, password); err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			sess.SetFlash("reset_error", err.Error())
+			c.Redirect(w, r, routes.URL("password.forgot"), http.StatusSeeOther)
+			return
+		}
+		log.ErrorContext(r.Context(), "resetting password", "err", err)
+		sess.SetFlash("reset_error", "Could not reset your password, please try again")
+		c.Redirect(w, r, resetUri, http.StatusSeeOther)
+		return
+	}
+
+	// Start a fresh session so nothing from before the reset carries over
+	sess.RegenerateNew()
+	sess.SetFlash("login_success", "Your password has been reset. You can now log in.")
+	c.Redirect(w, r, routes.URL("auth.login"), http.StatusSeeOther)
+}
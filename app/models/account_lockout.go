@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AccountLockout tracks failed logins of a user. FailedAttempts counts the
+// failures since the last lockout and Lockouts the lockouts in a row, which
+// makes each lockout longer than the previous one. Both reset on a
+// successful login or an unlock.
+type AccountLockout struct {
+	ID             uint64       `db:"id"`
+	UserID         uint64       `db:"user_id"`
+	FailedAttempts int          `db:"failed_attempts"`
+	Lockouts       int          `db:"lockouts"`
+	LockedUntil    sql.NullTime `db:"locked_until"`
+	LastFailedAt   sql.NullTime `db:"last_failed_at"`
+	Timestamps
+}
+
+// IsLocked reports whether the account is locked at now
+func (l *AccountLockout) IsLocked(now time.Time) bool {
+	return l.LockedUntil.Valid && now.Before(l.LockedUntil.Time)
+}
+
+type AccountLockoutModel struct {
+	*AppModel[AccountLockout]
+}
+
+func NewAccountLockoutModel() *AccountLockoutModel {
+	return &AccountLockoutModel{
+		AppModel: NewAppModel[AccountLockout]("account_lockouts"),
+	}
+}
+
+// FindByUserID returns the user's lockout record, or sql.ErrNoRows when the
+// user has no failed logins
+func (m *AccountLockoutModel) FindByUserID(userID uint64) (*AccountLockout, error) {
+	return m.FindOneByField("user_id", userID)
+}
+
+// RecordFailure counts a failed login and returns the failures since the
+// last lockout and the lockouts in a row. Failures before forgetBefore are
+// forgotten, so occasional typos never add up to a lockout.
+func (m *AccountLockoutModel) RecordFailure(userID uint64, forgetBefore time.Time) (int, int, error) {
+	query := `
+		INSERT INTO ` + m.GetTableName() + ` (user_id, failed_attempts, last_failed_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+			failed_attempts = CASE
+				WHEN account_lockouts.last_failed_at < $3 THEN 1
+				ELSE account_lockouts.failed_attempts + 1
+			END,
+			last_failed_at = $2
+		RETURNING failed_attempts, lockouts`
+
+	var failed, lockouts int
+	err := m.GetDB().QueryRow(query, userID, time.Now(), forgetBefore).Scan(&failed, &lockouts)
+	return failed, lockouts, err
+}
+
+// Lock locks the account until the given time as lockout number lockouts.
+// It reports false when a concurrent request already locked it, so only one
+// request notifies the user.
+func (m *AccountLockoutModel) Lock(userID uint64, lockouts int, until time.Time) (bool, error) {
+	query := "UPDATE " + m.GetTableName() + " SET failed_attempts = 0, lockouts = $2, locked_until = $3 WHERE user_id = $1 AND failed_attempts > 0"
+	result, err := m.GetDB().Exec(query, userID, lockouts, until)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// Clear forgets the user's failed logins and lifts any lockout
+func (m *AccountLockoutModel) Clear(userID uint64) error {
+	_, err := m.GetDB().Exec("DELETE FROM "+m.GetTableName()+" WHERE user_id = $1", userID)
+	return err
+}
@@ -2,12 +2,14 @@ package models
 
 import (
 	"database/sql"
-	"log"
 	"time"
 
+	"gohst/internal/logger"
 	"gohst/internal/models"
 )
 
+var log = logger.Component("models")
+
 // AppModel provides basic app-specific functionality that all app models can inherit
 type AppModel[T any] struct {
 	*models.Model[T]
@@ -28,7 +30,7 @@ func NewAppModel[T any](tableName string) *AppModel[T] {
 
 // LogActivity logs model activity (app-specific logging)
 func (a *AppModel[T]) LogActivity(action string, recordID uint64) {
-	log.Printf("Model Activity: %s on table %s, record %d", action, a.GetTableName(), recordID)
+	log.Info("model activity", "action", action, "table", a.GetTableName(), "record", recordID)
 }
 
 // WithAppTransaction wraps database transactions with app-specific logging
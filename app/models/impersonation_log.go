@@ -0,0 +1,48 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	IMPERSONATION_START = "start"
+	IMPERSONATION_STOP  = "stop"
+)
+
+// ImpersonationLog records an admin starting or stopping to view the app as
+// another user. Rows are never updated; the user columns are cleared when
+// an account is deleted so the trail survives it.
+type ImpersonationLog struct {
+	ID             uint64        `db:"id"`
+	ImpersonatorID sql.NullInt64 `db:"impersonator_id"`
+	UserID         sql.NullInt64 `db:"user_id"`
+	Action         string        `db:"action"`
+	IPAddress      string        `db:"ip_address"`
+	UserAgent      string        `db:"user_agent"`
+	Timestamps
+}
+
+type ImpersonationLogModel struct {
+	*AppModel[ImpersonationLog]
+}
+
+func NewImpersonationLogModel() *ImpersonationLogModel {
+	return &ImpersonationLogModel{
+		AppModel: NewAppModel[ImpersonationLog]("impersonation_logs"),
+	}
+}
+
+// Create appends an entry to the log
+func (m *ImpersonationLogModel) Create(entry *ImpersonationLog) (int64, error) {
+	now := time.Now()
+	entry.CreatedAt = now
+	entry.UpdatedAt = now
+	return m.Insert(entry)
+}
+
+// Recent lists the newest entries first
+func (m *ImpersonationLogModel) Recent(limit int) ([]ImpersonationLog, error) {
+	query := "SELECT * FROM " + m.GetTableName() + " ORDER BY created_at DESC, id DESC LIMIT $1"
+	return m.AllOf(query, limit)
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// MagicLink is a single-use passwordless login token. Only the SHA-256 hash
+// of the token is stored; the token itself exists only in the emailed link.
+type MagicLink struct {
+	ID        uint64       `db:"id"`
+	UserID    uint64       `db:"user_id"`
+	TokenHash string       `db:"token_hash"`
+	ExpiresAt time.Time    `db:"expires_at"`
+	UsedAt    sql.NullTime `db:"used_at"`
+	Timestamps
+}
+
+type MagicLinkModel struct {
+	*AppModel[MagicLink]
+}
+
+func NewMagicLinkModel() *MagicLinkModel {
+	return &MagicLinkModel{
+		AppModel: NewAppModel[MagicLink]("magic_links"),
+	}
+}
+
+// Create stores a new login token for the user
+func (m *MagicLinkModel) Create(link *MagicLink) (int64, error) {
+	now := time.Now()
+	link.CreatedAt = now
+	link.UpdatedAt = now
+	return m.Insert(link)
+}
+
+// FindValid returns the unused, unexpired link with the given token hash
+func (m *MagicLinkModel) FindValid(tokenHash string) (*MagicLink, error) {
+	query := "SELECT * FROM " + m.GetTableName() + " WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2 LIMIT 1"
+	return m.FirstOf(query, tokenHash, time.Now())
+}
+
+// CountSince counts the links sent to the user since the given time
+func (m *MagicLinkModel) CountSince(userID uint64, since time.Time) (int, error) {
+	query := "SELECT COUNT(*) FROM " + m.GetTableName() + " WHERE user_id = $1 AND created_at > $2"
+	return m.CountOf(query, userID, since)
+}
+
+// Consume marks the link as used. It reports false when the link was
+// already used, so concurrent clicks on one link cannot both log in.
+func (m *MagicLinkModel) Consume(id uint64) (bool, error) {
+	query := "UPDATE " + m.GetTableName() + " SET used_at = $1 WHERE id = $2 AND used_at IS NULL"
+	result, err := m.GetDB().Exec(query, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// DeleteForUser removes every outstanding link of the user
+func (m *MagicLinkModel) DeleteForUser(userID uint64) error {
+	query := "DELETE FROM " + m.GetTableName() + " WHERE user_id = $1 AND used_at IS NULL"
+	_, err := m.GetDB().Exec(query, userID)
+	return err
+}
+
+// DeleteExpired removes links that can no longer be used
+func (m *MagicLinkModel) DeleteExpired() error {
+	query := "DELETE FROM " + m.GetTableName() + " WHERE expires_at <= $1 OR used_at IS NOT NULL"
+	_, err := m.GetDB().Exec(query, time.Now())
+	return err
+}
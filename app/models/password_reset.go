@@ -0,0 +1,70 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PasswordReset is a single-use password reset token. Only the SHA-256 hash
+// of the token is stored; the token itself exists only in the emailed link.
+type PasswordReset struct {
+	ID        uint64       `db:"id"`
+	UserID    uint64       `db:"user_id"`
+	TokenHash string       `db:"token_hash"`
+	ExpiresAt time.Time    `db:"expires_at"`
+	UsedAt    sql.NullTime `db:"used_at"`
+	Timestamps
+}
+
+type PasswordResetModel struct {
+	*AppModel[PasswordReset]
+}
+
+func NewPasswordResetModel() *PasswordResetModel {
+	return &PasswordResetModel{
+		AppModel: NewAppModel[PasswordReset]("password_resets"),
+	}
+}
+
+// Create stores a new reset token for the user
+func (m *PasswordResetModel) Create(reset *PasswordReset) (int64, error) {
+	now := time.Now()
+	reset.CreatedAt = now
+	reset.UpdatedAt = now
+	return m.Insert(reset)
+}
+
+// FindValid returns the unused, unexpired reset with the given token hash
+func (m *PasswordResetModel) FindValid(tokenHash string) (*PasswordReset, error) {
+	query := "SELECT * FROM " + m.GetTableName() + " WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2 LIMIT 1"
+	return m.FirstOf(query, tokenHash, time.Now())
+}
+
+// Consume marks the reset as used. It reports false when the token was
+// already used, so concurrent submissions of one link cannot both succeed.
+func (m *PasswordResetModel) Consume(id uint64) (bool, error) {
+	query := "UPDATE " + m.GetTableName() + " SET used_at = $1 WHERE id = $2 AND used_at IS NULL"
+	result, err := m.GetDB().Exec(query, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// DeleteForUser removes every outstanding reset of the user
+func (m *PasswordResetModel) DeleteForUser(userID uint64) error {
+	query := "DELETE FROM " + m.GetTableName() + " WHERE user_id = $1 AND used_at IS NULL"
+	_, err := m.GetDB().Exec(query, userID)
+	return err
+}
+
+// DeleteExpired removes resets that can no longer be used
+func (m *PasswordResetModel) DeleteExpired() error {
+	query := "DELETE FROM " + m.GetTableName() + " WHERE expires_at <= $1 OR used_at IS NOT NULL"
+	_, err := m.GetDB().Exec(query, time.Now())
+	return err
+}
@@ -0,0 +1,74 @@
+package models
+
+// Permission is a named action, e.g. "users.edit", granted to roles through
+// the role_permissions table
+type Permission struct {
+	ID          uint64 `db:"id"`
+	Name        string `db:"name"`
+	Description string `db:"description"`
+	Timestamps
+}
+
+type PermissionModel struct {
+	*AppModel[Permission]
+}
+
+func NewPermissionModel() *PermissionModel {
+	return &PermissionModel{
+		AppModel: NewAppModel[Permission]("permissions"),
+	}
+}
+
+// Catalog returns the permission catalog ordered by name
+func (m *PermissionModel) Catalog() ([]Permission, error) {
+	return m.AllOf("SELECT * FROM " + m.GetTableName() + " ORDER BY name")
+}
+
+// NamesForRole returns the permissions granted to the role directly or
+// through any role it inherits from
+func (m *PermissionModel) NamesForRole(roleID uint64) ([]string, error) {
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_id, 0 AS depth FROM roles WHERE id = $1
+			UNION ALL
+			SELECT r.id, r.parent_id, c.depth + 1
+			FROM roles r
+			JOIN chain c ON r.id = c.parent_id
+			WHERE c.depth < $2
+		)
+		SELECT DISTINCT p.name
+		FROM chain c
+		JOIN role_permissions rp ON rp.role_id = c.id
+		JOIN permissions p ON p.id = rp.permission_id
+		ORDER BY p.name`
+
+	rows, err := m.GetDB().Query(query, roleID, ROLE_MAX_DEPTH)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// Grant gives a role a permission, ignoring grants that already exist
+func (m *PermissionModel) Grant(roleID uint64, permissionID uint64) error {
+	query := "INSERT INTO role_permissions (role_id, permission_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	_, err := m.GetDB().Exec(query, roleID, permissionID)
+	return err
+}
+
+// Revoke takes a permission away from a role
+func (m *PermissionModel) Revoke(roleID uint64, permissionID uint64) error {
+	query := "DELETE FROM role_permissions WHERE role_id = $1 AND permission_id = $2"
+	_, err := m.GetDB().Exec(query, roleID, permissionID)
+	return err
+}
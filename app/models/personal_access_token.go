@@ -0,0 +1,83 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// PersonalAccessToken authenticates API requests on behalf of a user. Only
+// the SHA-256 hash of the token is stored; the token is shown once when it
+// is created. Abilities is a space-separated list of granted abilities.
+type PersonalAccessToken struct {
+	ID         uint64       `db:"id"`
+	UserID     uint64       `db:"user_id"`
+	Name       string       `db:"name"`
+	TokenHash  string       `db:"token_hash"`
+	Abilities  string       `db:"abilities"`
+	LastUsedAt sql.NullTime `db:"last_used_at"`
+	ExpiresAt  sql.NullTime `db:"expires_at"`
+	Timestamps
+}
+
+// AbilityList returns the granted abilities
+func (t *PersonalAccessToken) AbilityList() []string {
+	return strings.Fields(t.Abilities)
+}
+
+// IsExpired reports whether the token has passed its expiry, if it has one
+func (t *PersonalAccessToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt.Valid && !now.Before(t.ExpiresAt.Time)
+}
+
+type PersonalAccessTokenModel struct {
+	*AppModel[PersonalAccessToken]
+}
+
+func NewPersonalAccessTokenModel() *PersonalAccessTokenModel {
+	return &PersonalAccessTokenModel{
+		AppModel: NewAppModel[PersonalAccessToken]("personal_access_tokens"),
+	}
+}
+
+// Create stores a new token
+func (m *PersonalAccessTokenModel) Create(token *PersonalAccessToken) (int64, error) {
+	now := time.Now()
+	token.CreatedAt = now
+	token.UpdatedAt = now
+	return m.Insert(token)
+}
+
+// FindByHash returns the token with the given hash, expired or not
+func (m *PersonalAccessTokenModel) FindByHash(tokenHash string) (*PersonalAccessToken, error) {
+	return m.FindOneByField("token_hash", tokenHash)
+}
+
+// ForUser lists the user's tokens, newest first
+func (m *PersonalAccessTokenModel) ForUser(userID uint64) ([]PersonalAccessToken, error) {
+	query := "SELECT * FROM " + m.GetTableName() + " WHERE user_id = $1 ORDER BY created_at DESC"
+	return m.AllOf(query, userID)
+}
+
+// Touch records that the token was used
+func (m *PersonalAccessTokenModel) Touch(id uint64, at time.Time) error {
+	_, err := m.GetDB().Exec("UPDATE "+m.GetTableName()+" SET last_used_at = $1 WHERE id = $2", at, id)
+	return err
+}
+
+// DeleteForUser revokes one of the user's tokens. It reports false when the
+// user has no token with that ID.
+func (m *PersonalAccessTokenModel) DeleteForUser(userID uint64, id uint64) (bool, error) {
+	result, err := m.GetDB().Exec("DELETE FROM "+m.GetTableName()+" WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// DeleteExpired removes tokens that can no longer be used
+func (m *PersonalAccessTokenModel) DeleteExpired() error {
+	_, err := m.GetDB().Exec("DELETE FROM "+m.GetTableName()+" WHERE expires_at <= $1", time.Now())
+	return err
+}
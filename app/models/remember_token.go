@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// RememberToken keeps a user logged in across sessions. The selector looks
+// the token up; only the SHA-256 hash of the validator half is stored.
+type RememberToken struct {
+	ID            uint64    `db:"id"`
+	UserID        uint64    `db:"user_id"`
+	Selector      string    `db:"selector"`
+	ValidatorHash string    `db:"validator_hash"`
+	ExpiresAt     time.Time `db:"expires_at"`
+	Timestamps
+}
+
+type RememberTokenModel struct {
+	*AppModel[RememberToken]
+}
+
+func NewRememberTokenModel() *RememberTokenModel {
+	return &RememberTokenModel{
+		AppModel: NewAppModel[RememberToken]("remember_tokens"),
+	}
+}
+
+// Create stores a new token
+func (m *RememberTokenModel) Create(token *RememberToken) (int64, error) {
+	now := time.Now()
+	token.CreatedAt = now
+	token.UpdatedAt = now
+	return m.Insert(token)
+}
+
+// FindBySelector returns the token with the given selector, expired or not
+func (m *RememberTokenModel) FindBySelector(selector string) (*RememberToken, error) {
+	return m.FindOneByField("selector", selector)
+}
+
+// RotateValidator swaps the validator hash of the token with the selector
+// from oldHash to newHash, and reports whether the token still had oldHash
+func (m *RememberTokenModel) RotateValidator(selector string, oldHash string, newHash string) (bool, error) {
+	query := "UPDATE " + m.GetTableName() + " SET validator_hash = $1 WHERE selector = $2 AND validator_hash = $3"
+	result, err := m.GetDB().Exec(query, newHash, selector, oldHash)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// DeleteBySelector removes a single token
+func (m *RememberTokenModel) DeleteBySelector(selector string) error {
+	_, err := m.GetDB().Exec("DELETE FROM "+m.GetTableName()+" WHERE selector = $1", selector)
+	return err
+}
+
+// DeleteForUser removes every token of the user except exceptSelector,
+// which may be empty
+func (m *RememberTokenModel) DeleteForUser(userID uint64, exceptSelector string) error {
+	query := "DELETE FROM " + m.GetTableName() + " WHERE user_id = $1 AND selector <> $2"
+	_, err := m.GetDB().Exec(query, userID, exceptSelector)
+	return err
+}
+
+// DeleteExpired removes tokens that can no longer be used
+func (m *RememberTokenModel) DeleteExpired() error {
+	_, err := m.GetDB().Exec("DELETE FROM "+m.GetTableName()+" WHERE expires_at <= $1", time.Now())
+	return err
+}
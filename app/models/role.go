@@ -1,12 +1,21 @@
 package models
 
+import "database/sql"
+
+// Role groups permissions. A role also has every permission of its parent,
+// so admin can extend manager which extends user.
 type Role struct {
 	ID		  	uint64 `db:"id"`
 	Name        string `db:"name"`
 	Description string `db:"description"`
 	Timestamps
+	ParentID    sql.NullInt64 `db:"parent_id"`
 }
 
+// ROLE_MAX_DEPTH bounds how far role inheritance is followed, so a parent
+// cycle in the data cannot loop forever
+const ROLE_MAX_DEPTH = 16
+
 type RoleModel struct {
 	*AppModel[Role]
 }
@@ -27,6 +36,7 @@ func (m *RoleModel) FindByID(id uint64) (*Role, error) {
 		&role.Description,
 		&role.CreatedAt,
 		&role.UpdatedAt,
+		&role.ParentID,
 	)
 	if err != nil {
 		return nil, err
@@ -44,9 +54,41 @@ func (m *RoleModel) FindByName(name string) (*Role, error) {
 		&role.Description,
 		&role.CreatedAt,
 		&role.UpdatedAt,
+		&role.ParentID,
 	)
 	if err != nil {
 		return nil, err
 	}
 	return role, nil
 }
+
+// Chain returns the names of the role and every role it inherits from,
+// starting with the role itself
+func (m *RoleModel) Chain(roleID uint64) ([]string, error) {
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT id, name, parent_id, 0 AS depth FROM roles WHERE id = $1
+			UNION ALL
+			SELECT r.id, r.name, r.parent_id, c.depth + 1
+			FROM roles r
+			JOIN chain c ON r.id = c.parent_id
+			WHERE c.depth < $2
+		)
+		SELECT name FROM chain ORDER BY depth`
+
+	rows, err := m.GetDB().Query(query, roleID, ROLE_MAX_DEPTH)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
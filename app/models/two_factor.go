@@ -0,0 +1,138 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TwoFactorCredential is a user's TOTP secret. It only protects logins once
+// ConfirmedAt is set, i.e. after the user proved their app produces codes.
+// LastUsedStep is the newest TOTP time step accepted, so codes cannot be replayed.
+type TwoFactorCredential struct {
+	ID           uint64       `db:"id"`
+	UserID       uint64       `db:"user_id"`
+	Secret       string       `db:"secret"`
+	ConfirmedAt  sql.NullTime `db:"confirmed_at"`
+	LastUsedStep int64        `db:"last_used_step"`
+	Timestamps
+}
+
+// IsConfirmed reports whether enrollment was completed
+func (c *TwoFactorCredential) IsConfirmed() bool {
+	return c.ConfirmedAt.Valid
+}
+
+type TwoFactorModel struct {
+	*AppModel[TwoFactorCredential]
+}
+
+func NewTwoFactorModel() *TwoFactorModel {
+	return &TwoFactorModel{
+		AppModel: NewAppModel[TwoFactorCredential]("two_factor_credentials"),
+	}
+}
+
+// FindByUserID returns the user's credential, confirmed or not
+func (m *TwoFactorModel) FindByUserID(userID uint64) (*TwoFactorCredential, error) {
+	return m.FindOneByField("user_id", userID)
+}
+
+// IsEnabled reports whether the user has confirmed two-factor authentication
+func (m *TwoFactorModel) IsEnabled(userID uint64) (bool, error) {
+	count, err := m.CountOf("SELECT COUNT(*) FROM "+m.GetTableName()+" WHERE user_id = $1 AND confirmed_at IS NOT NULL", userID)
+	return count > 0, err
+}
+
+// Create stores a new, unconfirmed credential, replacing any previous one
+func (m *TwoFactorModel) Create(cred *TwoFactorCredential) (int64, error) {
+	if err := m.DeleteForUser(cred.UserID); err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	cred.CreatedAt = now
+	cred.UpdatedAt = now
+	return m.Insert(cred)
+}
+
+// Confirm completes enrollment, recording the step of the confirming code
+func (m *TwoFactorModel) Confirm(id uint64, step int64) error {
+	query := "UPDATE " + m.GetTableName() + " SET confirmed_at = $1, last_used_step = $2 WHERE id = $3"
+	_, err := m.GetDB().Exec(query, time.Now(), step, id)
+	return err
+}
+
+// UseStep records a step as used. It reports false when that step or a
+// later one was already accepted, so each code works only once.
+func (m *TwoFactorModel) UseStep(id uint64, step int64) (bool, error) {
+	query := "UPDATE " + m.GetTableName() + " SET last_used_step = $1 WHERE id = $2 AND last_used_step < $1"
+	result, err := m.GetDB().Exec(query, step, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// DeleteForUser removes the user's credential
+func (m *TwoFactorModel) DeleteForUser(userID uint64) error {
+	_, err := m.GetDB().Exec("DELETE FROM "+m.GetTableName()+" WHERE user_id = $1", userID)
+	return err
+}
+
+// RecoveryCode is a hashed single-use code for logging in without the app.
+type RecoveryCode struct {
+	ID       uint64       `db:"id"`
+	UserID   uint64       `db:"user_id"`
+	CodeHash string       `db:"code_hash"`
+	UsedAt   sql.NullTime `db:"used_at"`
+	Timestamps
+}
+
+type RecoveryCodeModel struct {
+	*AppModel[RecoveryCode]
+}
+
+func NewRecoveryCodeModel() *RecoveryCodeModel {
+	return &RecoveryCodeModel{
+		AppModel: NewAppModel[RecoveryCode]("two_factor_recovery_codes"),
+	}
+}
+
+// Replace swaps the user's recovery codes for new hashes in one transaction
+func (m *RecoveryCodeModel) Replace(userID uint64, hashes []string) error {
+	return m.WithTransaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM "+m.GetTableName()+" WHERE user_id = $1", userID); err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, hash := range hashes {
+			query := "INSERT INTO " + m.GetTableName() + " (user_id, code_hash, created_at, updated_at) VALUES ($1, $2, $3, $3)"
+			if _, err := tx.Exec(query, userID, hash, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Consume marks an unused code as used, reporting whether one matched
+func (m *RecoveryCodeModel) Consume(userID uint64, hash string) (bool, error) {
+	query := "UPDATE " + m.GetTableName() + " SET used_at = $1, updated_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL"
+	result, err := m.GetDB().Exec(query, time.Now(), userID, hash)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// CountUnused returns how many recovery codes the user has left
+func (m *RecoveryCodeModel) CountUnused(userID uint64) (int, error) {
+	return m.CountOf("SELECT COUNT(*) FROM "+m.GetTableName()+" WHERE user_id = $1 AND used_at IS NULL", userID)
+}
+
+// DeleteForUser removes all of the user's recovery codes
+func (m *RecoveryCodeModel) DeleteForUser(userID uint64) error {
+	_, err := m.GetDB().Exec("DELETE FROM "+m.GetTableName()+" WHERE user_id = $1", userID)
+	return err
+}
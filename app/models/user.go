@@ -1,6 +1,7 @@
 package models
 
 import (
+	"database/sql"
 	"time"
 )
 
@@ -13,6 +14,13 @@ type User struct {
 	RoleID       uint64 `db:"role_id"`
 	Active       bool   `db:"active"`
 	Timestamps
+	// Added after the timestamps, so it comes last in SELECT * order
+	EmailVerifiedAt sql.NullTime `db:"email_verified_at"`
+}
+
+// IsEmailVerified reports whether the user has confirmed their email
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt.Valid
 }
 
 type UserModel struct {
@@ -45,3 +53,39 @@ func (m *UserModel) Create(user *User) (int64, error) {
     // Let the generic Insert handle all the fields
     return m.Insert(user)
 }
+
+// UpdatePassword replaces the user's password hash
+func (m *UserModel) UpdatePassword(id uint64, passwordHash string) error {
+	query := "UPDATE " + m.GetTableName() + " SET password_hash = $1, updated_at = $2 WHERE id = $3"
+	_, err := m.GetDB().Exec(query, passwordHash, time.Now(), id)
+	return err
+}
+
+// UpdateRole moves the user to another role
+func (m *UserModel) UpdateRole(id uint64, roleID uint64) error {
+	query := "UPDATE " + m.GetTableName() + " SET role_id = $1, updated_at = $2 WHERE id = $3"
+	_, err := m.GetDB().Exec(query, roleID, time.Now(), id)
+	return err
+}
+
+// UpgradePasswordHash replaces a password hash with a stronger hash of the
+// same password. It does nothing if the password changed in the meantime.
+func (m *UserModel) UpgradePasswordHash(id uint64, oldHash string, newHash string) error {
+	query := "UPDATE " + m.GetTableName() + " SET password_hash = $1 WHERE id = $2 AND password_hash = $3"
+	_, err := m.GetDB().Exec(query, newHash, id, oldHash)
+	return err
+}
+
+// MarkEmailVerified records that the user confirmed their email
+func (m *UserModel) MarkEmailVerified(id uint64) error {
+	now := time.Now()
+	query := "UPDATE " + m.GetTableName() + " SET email_verified_at = $1, updated_at = $1 WHERE id = $2 AND email_verified_at IS NULL"
+	_, err := m.GetDB().Exec(query, now, id)
+	return err
+}
+
+// Page lists users by email, limit at a time starting at offset
+func (m *UserModel) Page(limit int, offset int) ([]User, error) {
+	query := "SELECT * FROM " + m.GetTableName() + " ORDER BY email LIMIT $1 OFFSET $2"
+	return m.AllOf(query, limit, offset)
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// UserIdentity links a user to an account at an OpenID Connect provider.
+// Subject is the provider's stable user ID; Email is what the provider
+// reported at the last login and is only shown to the user.
+type UserIdentity struct {
+	ID          uint64       `db:"id"`
+	UserID      uint64       `db:"user_id"`
+	Provider    string       `db:"provider"`
+	Subject     string       `db:"subject"`
+	Email       string       `db:"email"`
+	LastLoginAt sql.NullTime `db:"last_login_at"`
+	Timestamps
+}
+
+type UserIdentityModel struct {
+	*AppModel[UserIdentity]
+}
+
+func NewUserIdentityModel() *UserIdentityModel {
+	return &UserIdentityModel{
+		AppModel: NewAppModel[UserIdentity]("user_identities"),
+	}
+}
+
+// Create links a new identity
+func (m *UserIdentityModel) Create(identity *UserIdentity) (int64, error) {
+	now := time.Now()
+	identity.CreatedAt = now
+	identity.UpdatedAt = now
+	return m.Insert(identity)
+}
+
+// FindByProviderSubject returns the identity a provider account is linked to
+func (m *UserIdentityModel) FindByProviderSubject(provider string, subject string) (*UserIdentity, error) {
+	query := "SELECT * FROM " + m.GetTableName() + " WHERE provider = $1 AND subject = $2"
+	return m.FirstOf(query, provider, subject)
+}
+
+// ForUser lists the user's linked identities, oldest first
+func (m *UserIdentityModel) ForUser(userID uint64) ([]UserIdentity, error) {
+	query := "SELECT * FROM " + m.GetTableName() + " WHERE user_id = $1 ORDER BY created_at"
+	return m.AllOf(query, userID)
+}
+
+// Touch records a login through the identity and the email it reported
+func (m *UserIdentityModel) Touch(id uint64, email string, at time.Time) error {
+	query := "UPDATE " + m.GetTableName() + " SET email = $1, last_login_at = $2 WHERE id = $3"
+	_, err := m.GetDB().Exec(query, email, at, id)
+	return err
+}
+
+// DeleteForUser unlinks one of the user's identities. It reports false when
+// the user has no identity with that ID.
+func (m *UserIdentityModel) DeleteForUser(userID uint64, id uint64) (bool, error) {
+	result, err := m.GetDB().Exec("DELETE FROM "+m.GetTableName()+" WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
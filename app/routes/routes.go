@@ -4,6 +4,9 @@ import (
 	"net/http"
 
 	"gohst/app/controllers"
+	"gohst/internal/health"
+	"gohst/internal/middleware"
+	"gohst/internal/routes"
 )
 
 type AppRouter struct{}
@@ -12,17 +15,40 @@ func NewAppRouter() *AppRouter {
 	return &AppRouter{}
 }
 
-// Set up all routes and return the mux for the application.
+// Set up all routes and return the handler for the application.
 func (r *AppRouter) SetupRoutes() http.Handler {
-	mainMux := http.NewServeMux()
+	router := routes.New()
 
+	account := controllers.NewAccountController()
+	admin := controllers.NewAdminController()
+	api := controllers.NewApiController()
 	auth := controllers.NewAuthController()
+	oidcLogin := controllers.NewOIDCController()
+	magicLink := controllers.NewMagicLinkController()
+	passwordReset := controllers.NewPasswordResetController()
+	verification := controllers.NewEmailVerificationController()
 	pages := controllers.NewPagesController()
 
-	fileServer := http.FileServer(http.Dir("static"))
-	mainMux.Handle("/static/", http.StripPrefix("/static/", fileServer))
-	mainMux.Handle("/auth/", http.StripPrefix("/auth", auth.RegisterRoutes()))
-	mainMux.Handle("/", pages.RegisterRoutes())
+	router.Mount("GET /static", http.FileServer(http.Dir("static"))).Name("static")
 
-	return mainMux
+	// Probes run outside every controller group: no sessions or rate limits
+	router.HandleFunc("GET /healthz", health.Liveness).Name("health.live")
+	router.HandleFunc("GET /readyz", health.Readiness).Name("health.ready")
+
+	passwordReset.RegisterRoutes(router.Group("/auth/password"))
+	verification.RegisterRoutes(router.Group("/auth/verify"))
+	magicLink.RegisterRoutes(router.Group("/auth/magic"))
+	oidcLogin.RegisterRoutes(router.Group("/auth/oidc"))
+	auth.RegisterRoutes(router.Group("/auth"))
+	api.RegisterRoutes(router.Group("/api"))
+	admin.RegisterRoutes(router.Group("/admin"))
+	account.RegisterRoutes(router.Group("/account"))
+	pages.RegisterRoutes(router.Group("/"))
+
+	// Framework middleware redirects to app pages by name, so moving a group
+	// moves its redirects too
+	middleware.TwoFactorSetupPath = routes.URL("account.two-factor")
+	middleware.VerifyNoticePath = routes.URL("verification.notice")
+
+	return router
 }
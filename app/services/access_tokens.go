@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gohst/app/models"
+	"gohst/internal/audit"
+	"gohst/internal/auth"
+)
+
+const (
+	// ACCESS_TOKEN_PREFIX marks personal access tokens so they are easy to
+	// recognise, e.g. by secret scanners
+	ACCESS_TOKEN_PREFIX = "gohst_pat_"
+	// ACCESS_TOKEN_TOUCH_INTERVAL limits how often last_used_at is written
+	ACCESS_TOKEN_TOUCH_INTERVAL = time.Minute
+)
+
+var (
+	// ErrAccessTokenNameRequired is returned when a token has no name.
+	ErrAccessTokenNameRequired = errors.New("give the token a name")
+	// ErrUnknownAbility is returned when a token asks for an ability that does not exist.
+	ErrUnknownAbility = errors.New("unknown token ability")
+	// ErrAccessTokenNotFound is returned when revoking a token the user does not have.
+	ErrAccessTokenNotFound = errors.New("access token not found")
+)
+
+// AccessTokenAbility is an ability personal access tokens can be granted
+type AccessTokenAbility struct {
+	Name        string
+	Description string
+}
+
+// AccessTokenAbilities lists the abilities API routes check with
+// middleware.Ability. Add an entry when a new API route needs its own.
+var AccessTokenAbilities = []AccessTokenAbility{
+	{Name: "user:read", Description: "Read your profile"},
+}
+
+func init() {
+	auth.SetTokenAuthenticator(authenticateAccessToken)
+}
+
+// CreateAccessToken mints a token for the user and returns it in plain text
+// along with the stored record. The plain token cannot be recovered later.
+// A zero ttl means the token does not expire.
+func CreateAccessToken(ctx context.Context, userID uint64, name string, abilities []string, ttl time.Duration) (string, *models.PersonalAccessToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, ErrAccessTokenNameRequired
+	}
+	for _, ability := range abilities {
+		if !isKnownAbility(ability) {
+			return "", nil, fmt.Errorf("%w: %s", ErrUnknownAbility, ability)
+		}
+	}
+
+	secret, _, err := auth.NewToken()
+	if err != nil {
+		return "", nil, err
+	}
+	token := ACCESS_TOKEN_PREFIX + secret
+
+	record := &models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: auth.HashToken(token),
+		Abilities: strings.Join(abilities, " "),
+	}
+	if ttl > 0 {
+		record.ExpiresAt = sql.NullTime{Time: time.Now().Add(ttl), Valid: true}
+	}
+
+	id, err := models.NewPersonalAccessTokenModel().Create(record)
+	if err != nil {
+		return "", nil, err
+	}
+	record.ID = uint64(id)
+
+	audit.Record(ctx, audit.Event{
+		Type:   audit.TOKEN_CREATED,
+		UserID: auditUserID(userID),
+		Metadata: map[string]any{
+			"token_id":  record.ID,
+			"name":      record.Name,
+			"abilities": abilities,
+		},
+	})
+	return token, record, nil
+}
+
+// AccessTokens lists the user's tokens, newest first
+func AccessTokens(userID uint64) ([]models.PersonalAccessToken, error) {
+	return models.NewPersonalAccessTokenModel().ForUser(userID)
+}
+
+// RevokeAccessToken deletes one of the user's tokens
+func RevokeAccessToken(userID uint64, tokenID uint64) error {
+	deleted, err := models.NewPersonalAccessTokenModel().DeleteForUser(userID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+// isKnownAbility reports whether tokens may be granted ability
+func isKnownAbility(ability string) bool {
+	if ability == auth.ABILITY_ALL {
+		return true
+	}
+	return slices.ContainsFunc(AccessTokenAbilities, func(a AccessTokenAbility) bool {
+		return a.Name == ability
+	})
+}
+
+// authenticateAccessToken resolves a bearer token for middleware.BearerAuth.
+// Tokens of users who may no longer log in are refused.
+func authenticateAccessToken(ctx context.Context, token string) (*auth.TokenAuth, error) {
+	if !strings.HasPrefix(token, ACCESS_TOKEN_PREFIX) {
+		return nil, auth.ErrInvalidAccessToken
+	}
+
+	tokenModel := models.NewPersonalAccessTokenModel()
+	record, err := tokenModel.FindByHash(auth.HashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.ErrInvalidAccessToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if record.IsExpired(now) {
+		return nil, auth.ErrInvalidAccessToken
+	}
+
+	user, err := models.NewUserModel().FindByID(int64(record.UserID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.ErrInvalidAccessToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active || (!user.IsEmailVerified() && requireEmailVerification()) {
+		return nil, auth.ErrInvalidAccessToken
+	}
+
+	twoFactorEnabled, err := models.NewTwoFactorModel().IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	authData, err := buildAuthData(user, twoFactorEnabled)
+	if err != nil {
+		return nil, err
+	}
+	// Tokens would otherwise get around AUTH_TWO_FACTOR_REQUIRE_ADMIN
+	if authData.NeedsTwoFactorSetup {
+		return nil, auth.ErrInvalidAccessToken
+	}
+
+	if !record.LastUsedAt.Valid || now.Sub(record.LastUsedAt.Time) >= ACCESS_TOKEN_TOUCH_INTERVAL {
+		if err := tokenModel.Touch(record.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return &auth.TokenAuth{Data: authData, Abilities: record.AbilityList()}, nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"gohst/app/models"
+	"gohst/internal/audit"
+)
+
+// Reasons recorded with failed logins
+const (
+	LOGIN_FAILURE_UNKNOWN_EMAIL    = "unknown_email"
+	LOGIN_FAILURE_INVALID_PASSWORD = "invalid_password"
+	LOGIN_FAILURE_LOCKED           = "locked"
+	LOGIN_FAILURE_TWO_FACTOR       = "invalid_two_factor_code"
+)
+
+// auditUserID formats a user ID the way audit events and sessions store it
+func auditUserID(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
+
+// recordLoginFailure adds a failed login to the audit log. userID is zero
+// when the email matched no account; email is empty for second factors.
+func recordLoginFailure(ctx context.Context, userID uint64, email string, reason string) {
+	event := audit.Event{
+		Type:     audit.LOGIN_FAILED,
+		Metadata: map[string]any{"reason": reason},
+	}
+	if email != "" {
+		event.Metadata["email"] = email
+	}
+	if userID != 0 {
+		event.UserID = auditUserID(userID)
+	}
+	audit.Record(ctx, event)
+}
+
+// AuditUserID turns a user filter, an email or a user ID, into the user ID
+// audit events are stored under
+func AuditUserID(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "@") {
+		return value, nil
+	}
+	user, err := models.NewUserModel().FindByEmail(value)
+	if err != nil {
+		return "", err
+	}
+	return auditUserID(user.ID), nil
+}
+
+// AuditEvents searches the security audit log, newest first
+func AuditEvents(ctx context.Context, filter audit.Filter) ([]audit.Event, error) {
+	return audit.NewDBStore().Search(ctx, filter)
+}
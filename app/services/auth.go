@@ -1,21 +1,36 @@
 package services
 
 import (
+	"context"
 	"encoding/gob"
 	"errors"
 	"gohst/app/models"
+	"gohst/internal/audit"
 	"gohst/internal/auth"
+	"gohst/internal/config"
+	"gohst/internal/logger"
 	"gohst/internal/session"
 	"gohst/internal/utils"
+	"strconv"
 	"time"
 )
 
+var log = logger.Component("services")
+
 type AuthData struct {
 	UserID     uint64
 	Email      string
 	Name       string
 	IsAdmin    bool
 	LoggedInAt time.Time
+
+	// Role is the user's role and Roles it plus every role it inherits from
+	Role  string
+	Roles []string
+
+	EmailVerified       bool
+	TwoFactorEnabled    bool
+	NeedsTwoFactorSetup bool
 }
 
 func init() {
@@ -34,23 +49,137 @@ func (ad *AuthData) GetEmail() string { return ad.Email }
 // GetName implements render.AuthUser.
 func (ad *AuthData) GetName() string { return ad.Name }
 
+// IsEmailVerified implements auth.VerifiedProvider.
+func (ad *AuthData) IsEmailVerified() bool { return ad.EmailVerified }
+
+// RequiresTwoFactorSetup implements auth.TwoFactorProvider.
+func (ad *AuthData) RequiresTwoFactorSetup() bool { return ad.NeedsTwoFactorSetup }
+
+// GetUserID implements auth.UserIDProvider.
+func (ad *AuthData) GetUserID() string { return strconv.FormatUint(ad.UserID, 10) }
+
+// RoleName implements auth.RoleProvider.
+func (ad *AuthData) RoleName() string { return ad.Role }
+
+// RoleNames implements auth.RolesProvider.
+func (ad *AuthData) RoleNames() []string {
+	if len(ad.Roles) == 0 && ad.Role != "" {
+		return []string{ad.Role}
+	}
+	return ad.Roles
+}
+
 // Login attempts to authenticate a user with email and password
 // Returns the authenticated user and any error that occurred
-func Login(sess *session.Session, email, password string) (*models.User, error) {
+// When remember is set the device stays logged in after the session ends.
+func Login(ctx context.Context, sess *session.Session, email, password string, remember bool) (*models.User, error) {
 
     // Find user in database
     userModel := models.NewUserModel()
     user, err := userModel.FindByEmail(email)
     if err != nil {
+		recordLoginFailure(ctx, 0, email, LOGIN_FAILURE_UNKNOWN_EMAIL)
         return nil, err
     }
 
+	// Locked accounts are refused before the password is checked, so a
+	// locked account tells an attacker nothing about their guesses
+	if err := checkLockout(user); err != nil {
+		recordLoginFailure(ctx, user.ID, email, LOGIN_FAILURE_LOCKED)
+		return nil, err
+	}
+
     // Verify password
-    passwordOk, _ := utils.CheckPassword(password, user.PasswordHash)
+    passwordOk, needsRehash, _ := utils.CheckPassword(password, user.PasswordHash)
     if !passwordOk {
+		recordLoginFailure(ctx, user.ID, email, LOGIN_FAILURE_INVALID_PASSWORD)
+		if err := recordFailedLogin(ctx, user); err != nil {
+			if errors.Is(err, ErrAccountLocked) {
+				return nil, err
+			}
+			log.ErrorContext(ctx, "recording failed login", "err", err)
+		}
         return nil, errors.New("invalid credentials")
     }
+	if needsRehash {
+		upgradePasswordHash(user, password)
+	}
+
+	if !user.IsEmailVerified() && requireEmailVerification() {
+		return user, ErrEmailNotVerified
+	}
+
+	twoFactorEnabled, err := models.NewTwoFactorModel().IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactorEnabled {
+		// The password is correct; hold the login until the code is checked.
+		// Failed logins are only forgotten once the code is right, so wrong
+		// codes keep counting toward the lockout across challenges.
+		beginTwoFactorChallenge(sess, user, remember)
+		return user, ErrTwoFactorRequired
+	}
+	clearFailedLogins(user)
+
+	if err := completeLogin(ctx, sess, user, false, "password"); err != nil {
+		return nil, err
+	}
+	if remember {
+		rememberDevice(ctx, sess)
+	}
+	return user, nil
+}
 
+// Logout ends the session's login and records it in the audit log
+func Logout(ctx context.Context, sess *session.Session) {
+	if auth.IsAuthenticated(sess) {
+		audit.Record(ctx, audit.Event{Type: audit.LOGOUT})
+	}
+	auth.Logout(sess)
+}
+
+// upgradePasswordHash rehashes a correct password whose stored hash is
+// weaker than the current Argon2 policy or was imported from another system
+func upgradePasswordHash(user *models.User, password string) {
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		log.Error("rehashing password", "err", err)
+		return
+	}
+	if err := models.NewUserModel().UpgradePasswordHash(user.ID, user.PasswordHash, passwordHash); err != nil {
+		log.Error("storing upgraded password hash", "err", err)
+		return
+	}
+	user.PasswordHash = passwordHash
+}
+
+// completeLogin stores the auth data for a user whose credentials, and
+// second factor when enabled, have been checked. method names how the user
+// logged in for the audit log.
+func completeLogin(ctx context.Context, sess *session.Session, user *models.User, twoFactorEnabled bool, method string) error {
+	authData, err := buildAuthData(user, twoFactorEnabled)
+	if err != nil {
+		return err
+	}
+
+	// New privileges, new session ID
+	sess.Regenerate()
+	auth.ForgetPermissions(sess)
+	if err := sess.Set(auth.AuthKey, authData); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, audit.Event{
+		Type:     audit.LOGIN_SUCCEEDED,
+		ActorID:  auditUserID(user.ID),
+		Metadata: map[string]any{"method": method},
+	})
+	return nil
+}
+
+// buildAuthData assembles the auth data stored for a logged-in user
+func buildAuthData(user *models.User, twoFactorEnabled bool) (*AuthData, error) {
 	roleModel := models.NewRoleModel()
 
 	role, err := roleModel.FindByID(user.RoleID)
@@ -59,23 +188,31 @@ func Login(sess *session.Session, email, password string) (*models.User, error)
 		return nil, errors.New("role not found")
 	}
 
+	roles, err := roleModel.Chain(role.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	isAdmin := role.Name == "admin"
 
-    // Store authentication data in session
-    authData := &AuthData{
-        UserID:     user.ID,
-        Email:      user.Email,
-        Name:       user.FirstName,
-        IsAdmin:    isAdmin,
-        LoggedInAt: time.Now(),
-    }
+	authData := &AuthData{
+		UserID:     user.ID,
+		Email:      user.Email,
+		Name:       user.FirstName,
+		IsAdmin:    isAdmin,
+		LoggedInAt: time.Now(),
+		Role:       role.Name,
+		Roles:      roles,
 
-    sess.Set(auth.AuthKey, authData)
-    return user, nil
+		EmailVerified:       user.IsEmailVerified(),
+		TwoFactorEnabled:    twoFactorEnabled,
+		NeedsTwoFactorSetup: isAdmin && !twoFactorEnabled && config.Auth.RequireAdminTwoFactor,
+	}
+	return authData, nil
 }
 
-// Register creates a new user account
-func Register(email, firstName, lastName, password string) error {
+// Register creates a new user account and emails a verification link
+func Register(ctx context.Context, email, firstName, lastName, password string) error {
     // Check if email already exists
     userModel := models.NewUserModel()
     existingUser, err := userModel.FindByEmail(email)
@@ -111,6 +248,12 @@ func Register(email, firstName, lastName, password string) error {
     if err != nil {
         return errors.New("failed to create user: " + err.Error())
     }
+    audit.Record(ctx, audit.Event{Type: audit.REGISTERED, ActorID: auditUserID(user.ID)})
+
+    // The account exists either way; the user can ask for another link
+    if err := SendVerificationEmail(ctx, user); err != nil {
+        log.ErrorContext(ctx, "sending verification email", "user", user.ID, "err", err)
+    }
 
     return nil
 }
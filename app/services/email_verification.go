@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	appConfig "gohst/app/config"
+	"gohst/app/models"
+	"gohst/internal/auth"
+	"gohst/internal/config"
+	"gohst/internal/mail"
+	"gohst/internal/routes"
+	"gohst/internal/session"
+	"gohst/internal/signing"
+	"gohst/internal/utils"
+)
+
+var (
+	// ErrEmailNotVerified is returned by Login when verification is required.
+	ErrEmailNotVerified = errors.New("please verify your email address before logging in")
+	// ErrInvalidVerificationLink is returned for tampered, expired or stale links.
+	ErrInvalidVerificationLink = errors.New("this verification link is invalid or has expired")
+)
+
+// requireEmailVerification reports whether unverified users are kept from
+// logging in (FEATURE_REQUIRE_EMAIL_VERIFICATION)
+func requireEmailVerification() bool {
+	return appConfig.App != nil && appConfig.App.Features.RequireEmailVerification
+}
+
+// EmailVerificationMailer delivers the verification link to the user.
+// Replace it to customise the email or send it through another channel.
+var EmailVerificationMailer = func(ctx context.Context, user *models.User, link string) error {
+	return mail.Send(ctx, mail.Message{
+		To:      []string{user.Email},
+		Subject: "Verify your email address",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm this is your email address by opening the link below.\n\n%s\n\n"+
+				"If you did not create an account, you can ignore this email.\n",
+			user.FirstName, link,
+		),
+	})
+}
+
+// SendVerificationEmail emails a signed verification link to the user
+func SendVerificationEmail(ctx context.Context, user *models.User) error {
+	return EmailVerificationMailer(ctx, user, EmailVerificationURL(user))
+}
+
+// EmailVerificationURL builds the signed, expiring link for a user. The link
+// embeds a hash of the current email, so it stops working if the email changes.
+func EmailVerificationURL(user *models.User) string {
+	params := url.Values{
+		"id":   {strconv.FormatUint(user.ID, 10)},
+		"hash": {emailHash(user.Email)},
+	}
+	ttl := time.Duration(config.Auth.EmailVerifyExpire) * time.Minute
+	builder := utils.NewURLBuilder(config.GetAppConfig().GetURL())
+	return builder.FullURL(signing.Sign(routes.URL("verification.confirm"), params, ttl))
+}
+
+// VerifyEmail checks a signed verification link and marks the user verified
+func VerifyEmail(link *url.URL) (*models.User, error) {
+	params, err := signing.Verify(link)
+	if err != nil {
+		return nil, ErrInvalidVerificationLink
+	}
+
+	id, err := strconv.ParseUint(params.Get("id"), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidVerificationLink
+	}
+	userModel := models.NewUserModel()
+	user, err := userModel.FindByID(int64(id))
+	if err != nil || params.Get("hash") != emailHash(user.Email) {
+		return nil, ErrInvalidVerificationLink
+	}
+
+	if !user.IsEmailVerified() {
+		if err := userModel.MarkEmailVerified(user.ID); err != nil {
+			return nil, err
+		}
+		user.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	return user, nil
+}
+
+// ResendVerificationEmail sends a new link to an unverified account. Like
+// password resets, it succeeds whether or not the account exists.
+func ResendVerificationEmail(ctx context.Context, email string) error {
+	user, err := models.NewUserModel().FindByEmail(email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.IsEmailVerified() {
+		return nil
+	}
+	return SendVerificationEmail(ctx, user)
+}
+
+// RefreshEmailVerified updates the session's auth data when the user has
+// verified their email since logging in, e.g. from another device. It
+// reports whether the user is now verified.
+func RefreshEmailVerified(sess *session.Session) bool {
+	authData, ok := auth.GetAuthData(sess).(*AuthData)
+	if !ok {
+		return false
+	}
+	if authData.EmailVerified {
+		return true
+	}
+
+	user, err := models.NewUserModel().FindByID(int64(authData.UserID))
+	if err != nil || !user.IsEmailVerified() {
+		return false
+	}
+	authData.EmailVerified = true
+	sess.Set(auth.AuthKey, authData)
+	return true
+}
+
+// emailHash ties a verification link to the address it was sent to
+func emailHash(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"gohst/app/models"
+	"gohst/internal/auth"
+	"gohst/internal/session"
+)
+
+// ErrCannotImpersonate is returned for users that may not be impersonated:
+// yourself, inactive users and admins.
+var ErrCannotImpersonate = errors.New("that user cannot be impersonated")
+
+// StartImpersonation lets the logged-in admin view the app as the target
+// user. The start is written to impersonation_logs first, so an
+// impersonation that cannot be logged does not happen.
+func StartImpersonation(sess *session.Session, targetID uint64, ip string, userAgent string) (*models.User, error) {
+	admin, ok := auth.GetAuthData(sess).(*AuthData)
+	if !ok {
+		return nil, errors.New("impersonating requires a logged-in user")
+	}
+	if auth.IsImpersonating(sess) {
+		return nil, auth.ErrAlreadyImpersonating
+	}
+
+	target, err := models.NewUserModel().FindByID(int64(targetID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: no user %d", ErrCannotImpersonate, targetID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == admin.UserID || !target.Active {
+		return nil, ErrCannotImpersonate
+	}
+
+	twoFactorEnabled, err := models.NewTwoFactorModel().IsEnabled(target.ID)
+	if err != nil {
+		return nil, err
+	}
+	authData, err := buildAuthData(target, twoFactorEnabled)
+	if err != nil {
+		return nil, err
+	}
+	// Impersonating an admin would hand out everything the admin can do
+	if slices.Contains(authData.RoleNames(), "admin") {
+		return nil, ErrCannotImpersonate
+	}
+	// Enrolling in two-factor is the target's own business
+	authData.NeedsTwoFactorSetup = false
+
+	if err := logImpersonation(models.IMPERSONATION_START, admin.UserID, target.ID, ip, userAgent); err != nil {
+		return nil, err
+	}
+	if err := auth.Impersonate(sess, authData); err != nil {
+		return nil, err
+	}
+	log.Info("impersonation started", "admin", admin.UserID, "user", target.ID)
+	return target, nil
+}
+
+// StopImpersonation returns the session to the admin who was impersonating.
+// Stopping always succeeds once the session is restored; a failure to log
+// it is only reported.
+func StopImpersonation(sess *session.Session, ip string, userAgent string) error {
+	var targetID uint64
+	if target, ok := auth.GetAuthData(sess).(*AuthData); ok {
+		targetID = target.UserID
+	}
+
+	impersonation, err := auth.StopImpersonating(sess)
+	if err != nil {
+		return err
+	}
+
+	var adminID uint64
+	if admin, ok := impersonation.Impersonator.(*AuthData); ok {
+		adminID = admin.UserID
+	}
+	if err := logImpersonation(models.IMPERSONATION_STOP, adminID, targetID, ip, userAgent); err != nil {
+		log.Error("recording the end of an impersonation", "admin", adminID, "user", targetID, "err", err)
+	}
+	log.Info("impersonation stopped", "admin", adminID, "user", targetID)
+	return nil
+}
+
+// ImpersonationLogs lists the newest impersonation log entries
+func ImpersonationLogs(limit int) ([]models.ImpersonationLog, error) {
+	return models.NewImpersonationLogModel().Recent(limit)
+}
+
+func logImpersonation(action string, adminID uint64, targetID uint64, ip string, userAgent string) error {
+	_, err := models.NewImpersonationLogModel().Create(&models.ImpersonationLog{
+		ImpersonatorID: nullUserID(adminID),
+		UserID:         nullUserID(targetID),
+		Action:         action,
+		IPAddress:      ip,
+		UserAgent:      userAgent,
+	})
+	return err
+}
+
+// nullUserID stores a zero user ID as NULL
+func nullUserID(id uint64) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"gohst/app/models"
+	"gohst/internal/auth"
+	"gohst/internal/config"
+	"gohst/internal/mail"
+	"gohst/internal/routes"
+	"gohst/internal/signing"
+	"gohst/internal/utils"
+)
+
+var (
+	// ErrAccountLocked is returned by Login while an account is locked after
+	// too many failed logins. Its message says nothing about the password.
+	ErrAccountLocked = errors.New("too many failed login attempts, check your email or try again later")
+	// ErrInvalidUnlockLink is returned for tampered, expired or stale links.
+	ErrInvalidUnlockLink = errors.New("this unlock link is invalid or has expired")
+)
+
+// AccountLockedMailer tells the user their account was locked and sends a
+// link to unlock it. Replace it to customise the email or send it through
+// another channel.
+var AccountLockedMailer = func(ctx context.Context, user *models.User, link string, until time.Time) error {
+	minutes := int(time.Until(until).Round(time.Minute).Minutes())
+	return mail.Send(ctx, mail.Message{
+		To:      []string{user.Email},
+		Subject: "Your account has been locked",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\nYour account was locked for %d minutes after too many failed login attempts.\n\n"+
+				"If that was you, open the link below to unlock it now.\n\n%s\n\n"+
+				"If it was not you, someone may be guessing your password. Unlock your account and "+
+				"choose a new password at %s.\n",
+			user.FirstName, minutes, link,
+			utils.NewURLBuilder(config.GetAppConfig().GetURL()).FullURL(routes.URL("password.forgot")),
+		),
+	})
+}
+
+// checkLockout returns ErrAccountLocked while the user is locked out
+func checkLockout(user *models.User) error {
+	lockout, err := models.NewAccountLockoutModel().FindByUserID(user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if lockout.IsLocked(time.Now()) {
+		return ErrAccountLocked
+	}
+	return nil
+}
+
+// recordFailedLogin counts a wrong password for the user and locks the
+// account once the lockout policy's threshold is reached. It returns
+// ErrAccountLocked when this failure locked the account.
+func recordFailedLogin(ctx context.Context, user *models.User) error {
+	policy := auth.DefaultLockoutPolicy()
+	if !policy.Enabled() {
+		return nil
+	}
+
+	lockoutModel := models.NewAccountLockoutModel()
+	forgetBefore := time.Now().Add(-policy.Ceiling())
+	failed, lockouts, err := lockoutModel.RecordFailure(user.ID, forgetBefore)
+	if err != nil {
+		return err
+	}
+	if !policy.Exceeded(failed) {
+		return nil
+	}
+
+	lockouts++
+	until := time.Now().Add(policy.Duration(lockouts)).Truncate(time.Second)
+	locked, err := lockoutModel.Lock(user.ID, lockouts, until)
+	if err != nil {
+		return err
+	}
+	if locked {
+		log.InfoContext(ctx, "locked account", "user", user.ID, "until", until, "failed", failed)
+		if err := AccountLockedMailer(ctx, user, AccountUnlockURL(user.ID, until), until); err != nil {
+			log.ErrorContext(ctx, "sending account locked email", "err", err)
+		}
+	}
+	return ErrAccountLocked
+}
+
+// clearFailedLogins forgets the user's failed logins after a correct password
+func clearFailedLogins(user *models.User) {
+	if err := models.NewAccountLockoutModel().Clear(user.ID); err != nil {
+		log.Error("clearing failed logins", "err", err)
+	}
+}
+
+// UnlockAccount lifts a lockout and forgets the user's failed logins, for
+// admins
+func UnlockAccount(userID uint64) error {
+	return models.NewAccountLockoutModel().Clear(userID)
+}
+
+// AccountUnlockURL builds the signed link that lifts the lockout ending at
+// until. It stops working when that lockout ends or is replaced.
+func AccountUnlockURL(userID uint64, until time.Time) string {
+	params := url.Values{
+		"id":    {strconv.FormatUint(userID, 10)},
+		"until": {strconv.FormatInt(until.Unix(), 10)},
+	}
+	builder := utils.NewURLBuilder(config.GetAppConfig().GetURL())
+	return builder.FullURL(signing.Sign(routes.URL("auth.unlock"), params, time.Until(until)))
+}
+
+// UnlockAccountFromLink checks a signed unlock link and lifts the lockout
+func UnlockAccountFromLink(link *url.URL) (*models.User, error) {
+	params, err := signing.Verify(link)
+	if err != nil {
+		return nil, ErrInvalidUnlockLink
+	}
+
+	id, err := strconv.ParseUint(params.Get("id"), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidUnlockLink
+	}
+	lockout, err := models.NewAccountLockoutModel().FindByUserID(id)
+	if err != nil || !lockout.LockedUntil.Valid ||
+		strconv.FormatInt(lockout.LockedUntil.Time.Unix(), 10) != params.Get("until") {
+		return nil, ErrInvalidUnlockLink
+	}
+
+	user, err := models.NewUserModel().FindByID(int64(id))
+	if err != nil {
+		return nil, ErrInvalidUnlockLink
+	}
+	if err := UnlockAccount(user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
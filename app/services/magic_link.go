@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"gohst/app/models"
+	"gohst/internal/auth"
+	"gohst/internal/config"
+	"gohst/internal/mail"
+	"gohst/internal/routes"
+	"gohst/internal/session"
+	"gohst/internal/signing"
+	"gohst/internal/utils"
+)
+
+const (
+	// MAGIC_LINK_THROTTLE is how long a user waits between login links
+	MAGIC_LINK_THROTTLE = time.Minute
+)
+
+var (
+	// ErrInvalidMagicLink is returned for tampered, expired or already used links.
+	ErrInvalidMagicLink = errors.New("this login link is invalid or has expired")
+	// ErrMagicLinkOtherBrowser is returned when a link is opened in a browser
+	// other than the one it was requested from.
+	ErrMagicLinkOtherBrowser = errors.New("open the login link in the browser you requested it from")
+)
+
+// MagicLinkRequest is stored in the session that asked for a login link.
+// Only that session can use the link, so a forwarded or intercepted email
+// cannot log anyone else in. Remember records whether the form asked to
+// remember the device.
+type MagicLinkRequest struct {
+	TokenHash string
+	Remember  bool
+}
+
+func init() {
+	gob.Register(&MagicLinkRequest{})
+}
+
+var magicLinkKey = session.NewKey[*MagicLinkRequest]("_gohst_magic_link_")
+
+// MagicLinkMailer delivers the login link to the user. Replace it to
+// customise the email or send it through another channel.
+var MagicLinkMailer = func(ctx context.Context, user *models.User, link string, expires time.Time) error {
+	minutes := int(time.Until(expires).Round(time.Minute).Minutes())
+	return mail.Send(ctx, mail.Message{
+		To:      []string{user.Email},
+		Subject: "Your login link",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to log in. It expires in %d minutes, can only be used once "+
+				"and only works in the browser you asked for it from.\n\n%s\n\n"+
+				"If you did not ask for this, you can ignore this email.\n",
+			user.FirstName, minutes, link,
+		),
+	})
+}
+
+// RequestMagicLink emails a login link to the account with this email and
+// binds it to the session. It succeeds whether or not the account exists so
+// the response cannot be used to discover registered addresses. Users get
+// one link per MAGIC_LINK_THROTTLE; further requests are dropped.
+func RequestMagicLink(ctx context.Context, sess *session.Session, email string, remember bool) error {
+	user, err := models.NewUserModel().FindByEmail(email)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !user.Active) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	linkModel := models.NewMagicLinkModel()
+	recent, err := linkModel.CountSince(user.ID, time.Now().Add(-MAGIC_LINK_THROTTLE))
+	if err != nil {
+		return err
+	}
+	if recent > 0 {
+		log.InfoContext(ctx, "dropped login link request, one was sent recently", "user", user.ID, "throttle", MAGIC_LINK_THROTTLE)
+		return nil
+	}
+
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+
+	// Only the newest link works
+	if err := linkModel.DeleteForUser(user.ID); err != nil {
+		return err
+	}
+
+	ttl := time.Duration(config.Auth.MagicLinkExpire) * time.Minute
+	expires := time.Now().Add(ttl)
+	if _, err := linkModel.Create(&models.MagicLink{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: expires,
+	}); err != nil {
+		return err
+	}
+	if err := magicLinkKey.Set(sess, &MagicLinkRequest{TokenHash: hash, Remember: remember}); err != nil {
+		return err
+	}
+
+	return MagicLinkMailer(ctx, user, MagicLinkURL(token, ttl), expires)
+}
+
+// MagicLinkURL builds the absolute signed link for a login token
+func MagicLinkURL(token string, ttl time.Duration) string {
+	builder := utils.NewURLBuilder(config.GetAppConfig().GetURL())
+	return builder.FullURL(signing.Sign(routes.URL("magic.verify"), url.Values{"token": {token}}, ttl))
+}
+
+// LoginWithMagicLink logs the user in from a signed login link opened in the
+// session that requested it. The link is consumed. Like Login it returns
+// ErrTwoFactorRequired when the user has to enter a code before the login
+// completes.
+func LoginWithMagicLink(ctx context.Context, sess *session.Session, link *url.URL) (*models.User, error) {
+	params, err := signing.Verify(link)
+	if err != nil {
+		return nil, ErrInvalidMagicLink
+	}
+	token := params.Get("token")
+
+	pending, ok := magicLinkKey.Get(sess)
+	if !ok || pending == nil || !auth.TokenMatches(token, pending.TokenHash) {
+		return nil, ErrMagicLinkOtherBrowser
+	}
+
+	linkModel := models.NewMagicLinkModel()
+	record, err := linkModel.FindValid(auth.HashToken(token))
+	if err != nil {
+		return nil, ErrInvalidMagicLink
+	}
+	consumed, err := linkModel.Consume(record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidMagicLink
+	}
+	magicLinkKey.Remove(sess)
+
+	userModel := models.NewUserModel()
+	user, err := userModel.FindByID(int64(record.UserID))
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active {
+		return nil, ErrAccountInactive
+	}
+
+	// The link arrived in the user's inbox, which proves they own the address
+	if !user.IsEmailVerified() {
+		if err := userModel.MarkEmailVerified(user.ID); err != nil {
+			return nil, err
+		}
+		user.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	twoFactorEnabled, err := models.NewTwoFactorModel().IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactorEnabled {
+		beginTwoFactorChallenge(sess, user, pending.Remember)
+		return user, ErrTwoFactorRequired
+	}
+
+	if err := completeLogin(ctx, sess, user, false, "magic_link"); err != nil {
+		return nil, err
+	}
+	if pending.Remember {
+		rememberDevice(ctx, sess)
+	}
+	return user, nil
+}
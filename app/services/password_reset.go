@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"gohst/app/models"
+	"gohst/internal/audit"
+	"gohst/internal/auth"
+	"gohst/internal/config"
+	"gohst/internal/mail"
+	"gohst/internal/routes"
+	"gohst/internal/session"
+	"gohst/internal/utils"
+)
+
+// ErrInvalidResetToken is returned for unknown, expired or already used tokens.
+var ErrInvalidResetToken = errors.New("this password reset link is invalid or has expired")
+
+// PasswordResetMailer delivers the reset link to the user. Replace it to
+// customise the email or send it through another channel.
+var PasswordResetMailer = func(ctx context.Context, user *models.User, link string, expires time.Time) error {
+	minutes := int(time.Until(expires).Round(time.Minute).Minutes())
+	return mail.Send(ctx, mail.Message{
+		To:      []string{user.Email},
+		Subject: "Reset your password",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\nSomeone asked to reset the password for your account. "+
+				"Use the link below to choose a new one. It expires in %d minutes and can only be used once.\n\n%s\n\n"+
+				"If you did not ask for this, you can ignore this email.\n",
+			user.FirstName, minutes, link,
+		),
+	})
+}
+
+// RequestPasswordReset emails a reset link to the account with this email.
+// It succeeds whether or not the account exists so the response cannot be
+// used to discover registered addresses.
+func RequestPasswordReset(ctx context.Context, email string) error {
+	userModel := models.NewUserModel()
+	user, err := userModel.FindByEmail(email)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !user.Active) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+
+	// Only the newest link works
+	resetModel := models.NewPasswordResetModel()
+	if err := resetModel.DeleteForUser(user.ID); err != nil {
+		return err
+	}
+
+	expires := time.Now().Add(time.Duration(config.Auth.PasswordResetExpire) * time.Minute)
+	if _, err := resetModel.Create(&models.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: expires,
+	}); err != nil {
+		return err
+	}
+
+	return PasswordResetMailer(ctx, user, PasswordResetURL(token), expires)
+}
+
+// PasswordResetURL builds the absolute link for a reset token
+func PasswordResetURL(token string) string {
+	builder := utils.NewURLBuilder(config.GetAppConfig().GetURL())
+	return builder.FullURL(routes.URL("password.reset") + "?token=" + url.QueryEscape(token))
+}
+
+// ValidatePasswordResetToken reports whether the token can still be used
+func ValidatePasswordResetToken(token string) error {
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+	if _, err := models.NewPasswordResetModel().FindValid(auth.HashToken(token)); err != nil {
+		return ErrInvalidResetToken
+	}
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token. The token is
+// consumed, the user's other outstanding tokens are deleted and every
+// session of the user is logged out.
+func ResetPassword(ctx context.Context, token string, password string) error {
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+
+	resetModel := models.NewPasswordResetModel()
+	reset, err := resetModel.FindValid(auth.HashToken(token))
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return errors.New("error processing password")
+	}
+
+	consumed, err := resetModel.Consume(reset.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidResetToken
+	}
+
+	if err := models.NewUserModel().UpdatePassword(reset.UserID, passwordHash); err != nil {
+		return err
+	}
+	audit.Record(ctx, audit.Event{
+		Type:     audit.PASSWORD_CHANGED,
+		UserID:   auditUserID(reset.UserID),
+		Metadata: map[string]any{"via": "reset"},
+	})
+	if err := resetModel.DeleteForUser(reset.UserID); err != nil {
+		log.ErrorContext(ctx, "deleting outstanding password resets", "user", reset.UserID, "err", err)
+	}
+
+	revoked, err := auth.LogoutEverywhere(ctx, strconv.FormatUint(reset.UserID, 10))
+	if errors.Is(err, session.ErrStatelessStore) {
+		log.WarnContext(ctx, "cookie store sessions stay valid after password reset until they expire", "user", reset.UserID)
+	} else if err != nil {
+		log.ErrorContext(ctx, "logging out sessions after password reset", "user", reset.UserID, "err", err)
+	} else if revoked > 0 {
+		log.InfoContext(ctx, "logged out sessions after password reset", "user", reset.UserID, "count", revoked)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"strconv"
+
+	"gohst/app/models"
+	"gohst/internal/auth"
+)
+
+func init() {
+	auth.SetPermissionLoader(loadPermissions)
+}
+
+// loadPermissions returns the permissions of the user's role, including
+// those inherited from parent roles
+func loadPermissions(ctx context.Context, userID string) ([]string, error) {
+	id, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := models.NewUserModel().FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewPermissionModel().NamesForRole(user.RoleID)
+}
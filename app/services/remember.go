@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"gohst/app/models"
+	"gohst/internal/auth"
+	"gohst/internal/session"
+)
+
+// ErrAccountInactive is returned when a deactivated user is logged back in.
+var ErrAccountInactive = errors.New("account is inactive")
+
+func init() {
+	auth.SetRememberer(rememberTokenStore{}, loginRemembered)
+}
+
+// rememberTokenStore keeps remember-me tokens in the remember_tokens table
+type rememberTokenStore struct{}
+
+func (rememberTokenStore) SaveRememberToken(ctx context.Context, token auth.RememberToken) error {
+	userID, err := strconv.ParseUint(token.UserID, 10, 64)
+	if err != nil {
+		return err
+	}
+	_, err = models.NewRememberTokenModel().Create(&models.RememberToken{
+		UserID:        userID,
+		Selector:      token.Selector,
+		ValidatorHash: token.ValidatorHash,
+		ExpiresAt:     token.ExpiresAt,
+	})
+	return err
+}
+
+func (rememberTokenStore) FindRememberToken(ctx context.Context, selector string) (*auth.RememberToken, error) {
+	token, err := models.NewRememberTokenModel().FindBySelector(selector)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &auth.RememberToken{
+		UserID:        strconv.FormatUint(token.UserID, 10),
+		Selector:      token.Selector,
+		ValidatorHash: token.ValidatorHash,
+		ExpiresAt:     token.ExpiresAt,
+	}, nil
+}
+
+func (rememberTokenStore) RotateRememberToken(ctx context.Context, selector string, oldHash string, newHash string) (bool, error) {
+	return models.NewRememberTokenModel().RotateValidator(selector, oldHash, newHash)
+}
+
+func (rememberTokenStore) DeleteRememberToken(ctx context.Context, selector string) error {
+	return models.NewRememberTokenModel().DeleteBySelector(selector)
+}
+
+func (rememberTokenStore) DeleteRememberTokens(ctx context.Context, userID string, exceptSelector string) error {
+	id, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return err
+	}
+	return models.NewRememberTokenModel().DeleteForUser(id, exceptSelector)
+}
+
+// loginRemembered logs a user in from a remember-me token. The token stands
+// in for both factors, since issuing it required them, but the account must
+// still be allowed to log in.
+func loginRemembered(ctx context.Context, sess *session.Session, userID string) error {
+	id, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		return err
+	}
+	user, err := models.NewUserModel().FindByID(id)
+	if err != nil {
+		return err
+	}
+	if !user.Active {
+		return ErrAccountInactive
+	}
+	// A locked account cannot log in with its password, so not from a
+	// remembered device either
+	if err := checkLockout(user); err != nil {
+		return err
+	}
+	if !user.IsEmailVerified() && requireEmailVerification() {
+		return ErrEmailNotVerified
+	}
+
+	twoFactorEnabled, err := models.NewTwoFactorModel().IsEnabled(user.ID)
+	if err != nil {
+		return err
+	}
+	return completeLogin(ctx, sess, user, twoFactorEnabled, "remember")
+}
+
+// rememberDevice issues a remember-me token after a login that asked for one.
+// The login itself has succeeded, so failures are only logged.
+func rememberDevice(ctx context.Context, sess *session.Session) {
+	if err := auth.Remember(ctx, sess); err != nil {
+		log.ErrorContext(ctx, "issuing remember-me token", "err", err)
+	}
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	appConfig "gohst/app/config"
+	"gohst/app/models"
+	"gohst/internal/config"
+	"gohst/internal/oidc"
+	"gohst/internal/session"
+	"gohst/internal/utils"
+)
+
+var (
+	// ErrSocialAccountExists is returned when a provider account has the
+	// email of a local account it cannot be linked to automatically.
+	ErrSocialAccountExists = errors.New("an account with this email already exists; log in with your password to link it")
+	// ErrSocialEmailRequired is returned when a provider shares no email
+	// and no account can be created.
+	ErrSocialEmailRequired = errors.New("the provider did not share an email address")
+	// ErrSocialRegistrationClosed is returned when a new user logs in with
+	// a provider while registration is turned off.
+	ErrSocialRegistrationClosed = errors.New("registration is closed")
+	// ErrIdentityLinkedElsewhere is returned when linking a provider account
+	// that already belongs to another user.
+	ErrIdentityLinkedElsewhere = errors.New("that account is linked to another user")
+	// ErrIdentityNotFound is returned when unlinking an identity the user does not have.
+	ErrIdentityNotFound = errors.New("linked account not found")
+)
+
+// SocialLogin logs a user in with the validated ID token claims of a
+// provider. The provider account is matched to a user by these rules:
+//
+//  1. An identity already linked to the account logs its user in.
+//  2. Otherwise a local user with the same email is linked when the provider
+//     and the user have both verified it and OIDC_LINK_VERIFIED_EMAILS is on.
+//     Any other email match is refused with ErrSocialAccountExists, so a
+//     provider account cannot take over a local one.
+//  3. Otherwise a new user is created, if registration is open.
+//
+// Like Login it returns ErrTwoFactorRequired when the user has to enter a
+// code before the login completes.
+func SocialLogin(ctx context.Context, sess *session.Session, provider string, claims *oidc.Claims) (*models.User, error) {
+	identities := models.NewUserIdentityModel()
+	userModel := models.NewUserModel()
+
+	var user *models.User
+	identity, err := identities.FindByProviderSubject(provider, claims.Subject)
+	switch {
+	case err == nil:
+		if user, err = userModel.FindByID(int64(identity.UserID)); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		if user, err = userForNewIdentity(ctx, claims); err != nil {
+			return nil, err
+		}
+		identity = &models.UserIdentity{UserID: user.ID, Provider: provider, Subject: claims.Subject}
+		if _, err := identities.Create(identity); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if err := identities.Touch(identity.ID, claims.Email, time.Now()); err != nil {
+		log.ErrorContext(ctx, "recording identity login", "err", err)
+	}
+
+	if !user.Active {
+		return nil, ErrAccountInactive
+	}
+	if !user.IsEmailVerified() && requireEmailVerification() {
+		return user, ErrEmailNotVerified
+	}
+
+	twoFactorEnabled, err := models.NewTwoFactorModel().IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactorEnabled {
+		beginTwoFactorChallenge(sess, user, false)
+		return user, ErrTwoFactorRequired
+	}
+
+	if err := completeLogin(ctx, sess, user, false, "oidc:"+provider); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// userForNewIdentity finds the local user a provider account that is not
+// linked yet belongs to, or creates one
+func userForNewIdentity(ctx context.Context, claims *oidc.Claims) (*models.User, error) {
+	email := strings.TrimSpace(claims.Email)
+	if email == "" {
+		return nil, ErrSocialEmailRequired
+	}
+
+	userModel := models.NewUserModel()
+	user, err := userModel.FindByEmail(email)
+	if err == nil {
+		if bool(claims.EmailVerified) && user.IsEmailVerified() && config.OIDC.LinkVerifiedEmails {
+			return user, nil
+		}
+		return nil, ErrSocialAccountExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if appConfig.App != nil && !appConfig.App.Features.EnableRegistration {
+		return nil, ErrSocialRegistrationClosed
+	}
+	return createSocialUser(ctx, email, claims)
+}
+
+// createSocialUser registers a user from a provider's claims. The password
+// is random and never shown, so the user logs in through the provider until
+// they set one with a password reset.
+func createSocialUser(ctx context.Context, email string, claims *oidc.Claims) (*models.User, error) {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	passwordHash, err := utils.HashPassword(base64.RawURLEncoding.EncodeToString(secret))
+	if err != nil {
+		return nil, errors.New("error processing password")
+	}
+
+	role, err := models.NewRoleModel().FindByName("user")
+	if err != nil {
+		return nil, errors.New("default role not found")
+	}
+
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(strings.TrimSpace(claims.Name), " ")
+	}
+
+	user := &models.User{
+		FirstName:    firstName,
+		LastName:     lastName,
+		Email:        email,
+		PasswordHash: passwordHash,
+		RoleID:       role.ID,
+		Active:       true,
+	}
+	if claims.EmailVerified {
+		user.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	if _, err := models.NewUserModel().Create(user); err != nil {
+		return nil, errors.New("failed to create user: " + err.Error())
+	}
+
+	if !user.IsEmailVerified() {
+		if err := SendVerificationEmail(ctx, user); err != nil {
+			log.ErrorContext(ctx, "sending verification email", "user", user.ID, "err", err)
+		}
+	}
+	return user, nil
+}
+
+// LinkIdentity links a provider account to a logged-in user. Linking an
+// account the user already has is not an error.
+func LinkIdentity(userID uint64, provider string, claims *oidc.Claims) error {
+	identities := models.NewUserIdentityModel()
+
+	identity, err := identities.FindByProviderSubject(provider, claims.Subject)
+	if err == nil {
+		if identity.UserID != userID {
+			return ErrIdentityLinkedElsewhere
+		}
+		return identities.Touch(identity.ID, claims.Email, time.Now())
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	_, err = identities.Create(&models.UserIdentity{
+		UserID:      userID,
+		Provider:    provider,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		LastLoginAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	return err
+}
+
+// Identities lists the provider accounts linked to the user
+func Identities(userID uint64) ([]models.UserIdentity, error) {
+	return models.NewUserIdentityModel().ForUser(userID)
+}
+
+// UnlinkIdentity removes one of the user's linked provider accounts
+func UnlinkIdentity(userID uint64, id uint64) error {
+	deleted, err := models.NewUserIdentityModel().DeleteForUser(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: %d", ErrIdentityNotFound, id)
+	}
+	return nil
+}
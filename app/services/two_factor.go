@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gohst/app/models"
+	"gohst/internal/auth"
+	"gohst/internal/config"
+	"gohst/internal/qr"
+	"gohst/internal/ratelimit"
+	"gohst/internal/session"
+	"gohst/internal/totp"
+	"gohst/internal/utils"
+)
+
+const (
+	// TWO_FACTOR_CHALLENGE_TTL is how long a password-checked login waits for its code
+	TWO_FACTOR_CHALLENGE_TTL = 5 * time.Minute
+	// TWO_FACTOR_MAX_ATTEMPTS is how many wrong codes end the challenge
+	TWO_FACTOR_MAX_ATTEMPTS = 5
+	// RECOVERY_CODE_COUNT is how many recovery codes are issued at a time
+	RECOVERY_CODE_COUNT = 8
+)
+
+var (
+	// ErrTwoFactorRequired is returned by Login when a code is still needed.
+	ErrTwoFactorRequired = errors.New("two-factor authentication required")
+	// ErrInvalidTwoFactorCode is returned for wrong, reused or expired codes.
+	ErrInvalidTwoFactorCode = errors.New("that code is not valid")
+	// ErrTwoFactorChallengeExpired is returned when the login must start over.
+	ErrTwoFactorChallengeExpired = errors.New("your login has expired, please log in again")
+	// ErrTwoFactorNotPending is returned when confirming without a setup in progress.
+	ErrTwoFactorNotPending = errors.New("two-factor setup has not been started")
+)
+
+// TwoFactorChallenge is stored in the session between the password check
+// and the code check. It carries no privileges. Remember records whether the
+// login form asked to remember the device.
+type TwoFactorChallenge struct {
+	UserID    uint64
+	Email     string
+	Attempts  int
+	ExpiresAt time.Time
+	Remember  bool
+}
+
+func init() {
+	gob.Register(&TwoFactorChallenge{})
+}
+
+var twoFactorChallengeKey = session.NewKey[*TwoFactorChallenge]("_gohst_2fa_challenge_")
+
+// TwoFactorSetup describes a user's enrollment for the account page.
+// Secret, URI and QRCode are only set while enrollment is unconfirmed.
+type TwoFactorSetup struct {
+	Enabled                bool
+	Secret                 string
+	URI                    string
+	QRCode                 string
+	RecoveryCodesRemaining int
+}
+
+func beginTwoFactorChallenge(sess *session.Session, user *models.User, remember bool) {
+	twoFactorChallengeKey.Set(sess, &TwoFactorChallenge{
+		UserID:    user.ID,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(TWO_FACTOR_CHALLENGE_TTL),
+		Remember:  remember,
+	})
+}
+
+// KeyByTwoFactorChallenge keys a rate limit on the user the session's pending
+// challenge is for, so switching IPs or logging in again with the password
+// does not reset it. Requests without a challenge are keyed by IP.
+func KeyByTwoFactorChallenge() ratelimit.KeyFunc {
+	return func(r *http.Request) (string, string) {
+		if sess := session.FromContext(r.Context()); sess != nil {
+			if challenge, ok := twoFactorChallengeKey.Get(sess); ok && challenge != nil {
+				return fmt.Sprintf("2fa:user:%d", challenge.UserID), ratelimit.KeyTypeUser
+			}
+		}
+		return "ip:" + ratelimit.ClientIP(r), ratelimit.KeyTypeIP
+	}
+}
+
+// PendingTwoFactorChallenge returns the login waiting for a code, if any
+func PendingTwoFactorChallenge(sess *session.Session) (*TwoFactorChallenge, bool) {
+	challenge, ok := twoFactorChallengeKey.Get(sess)
+	if !ok || challenge == nil {
+		return nil, false
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		twoFactorChallengeKey.Remove(sess)
+		return nil, false
+	}
+	return challenge, true
+}
+
+// CompleteTwoFactorChallenge checks a TOTP or recovery code for the pending
+// login and, when it is valid, logs the user in
+func CompleteTwoFactorChallenge(ctx context.Context, sess *session.Session, code string) error {
+	challenge, ok := PendingTwoFactorChallenge(sess)
+	if !ok {
+		return ErrTwoFactorChallengeExpired
+	}
+
+	user, err := models.NewUserModel().FindByID(int64(challenge.UserID))
+	if err != nil {
+		return err
+	}
+	// A lockout reached from another session ends this challenge too
+	if err := checkLockout(user); err != nil {
+		twoFactorChallengeKey.Remove(sess)
+		recordLoginFailure(ctx, user.ID, user.Email, LOGIN_FAILURE_LOCKED)
+		return err
+	}
+
+	valid, err := checkSecondFactor(challenge.UserID, code)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		recordLoginFailure(ctx, challenge.UserID, "", LOGIN_FAILURE_TWO_FACTOR)
+		// Wrong codes count toward the account lockout like wrong passwords,
+		// so starting a new challenge does not buy more guesses
+		if err := recordFailedLogin(ctx, user); err != nil {
+			if errors.Is(err, ErrAccountLocked) {
+				twoFactorChallengeKey.Remove(sess)
+				return err
+			}
+			log.ErrorContext(ctx, "recording failed login", "err", err)
+		}
+		challenge.Attempts++
+		if challenge.Attempts >= TWO_FACTOR_MAX_ATTEMPTS {
+			twoFactorChallengeKey.Remove(sess)
+			return ErrTwoFactorChallengeExpired
+		}
+		twoFactorChallengeKey.Set(sess, challenge)
+		return ErrInvalidTwoFactorCode
+	}
+
+	twoFactorChallengeKey.Remove(sess)
+	clearFailedLogins(user)
+	if err := completeLogin(ctx, sess, user, true, "two_factor"); err != nil {
+		return err
+	}
+	if challenge.Remember {
+		rememberDevice(ctx, sess)
+	}
+	return nil
+}
+
+// checkSecondFactor accepts a current TOTP code that has not been used yet,
+// or an unused recovery code
+func checkSecondFactor(userID uint64, code string) (bool, error) {
+	twoFactorModel := models.NewTwoFactorModel()
+	cred, err := twoFactorModel.FindByUserID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !cred.IsConfirmed() {
+		return false, nil
+	}
+
+	if step, ok := totp.Validate(cred.Secret, code, time.Now()); ok {
+		return twoFactorModel.UseStep(cred.ID, step)
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return false, nil
+	}
+	return models.NewRecoveryCodeModel().Consume(userID, auth.HashToken(normalized))
+}
+
+// GetTwoFactorSetup returns the user's enrollment state, including the QR
+// code while enrollment is waiting for confirmation
+func GetTwoFactorSetup(userID uint64, email string) (*TwoFactorSetup, error) {
+	cred, err := models.NewTwoFactorModel().FindByUserID(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &TwoFactorSetup{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cred.IsConfirmed() {
+		remaining, err := models.NewRecoveryCodeModel().CountUnused(userID)
+		if err != nil {
+			return nil, err
+		}
+		return &TwoFactorSetup{Enabled: true, RecoveryCodesRemaining: remaining}, nil
+	}
+
+	uri := totp.URI(config.Auth.TwoFactorIssuer, email, cred.Secret)
+	svg, err := qr.SVG(uri)
+	if err != nil {
+		return nil, err
+	}
+	return &TwoFactorSetup{Secret: cred.Secret, URI: uri, QRCode: svg}, nil
+}
+
+// BeginTwoFactorSetup generates a new secret for the user. It stays
+// inactive until ConfirmTwoFactor is called with a code from the app.
+func BeginTwoFactorSetup(userID uint64) error {
+	enabled, err := models.NewTwoFactorModel().IsEnabled(userID)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		return errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return err
+	}
+	_, err = models.NewTwoFactorModel().Create(&models.TwoFactorCredential{
+		UserID: userID,
+		Secret: secret,
+	})
+	return err
+}
+
+// ConfirmTwoFactor finishes enrollment with a code from the app and returns
+// the recovery codes, which are shown to the user once
+func ConfirmTwoFactor(sess *session.Session, code string) ([]string, error) {
+	authData, ok := auth.GetAuthData(sess).(*AuthData)
+	if !ok {
+		return nil, auth.ErrNotAuthenticated
+	}
+
+	twoFactorModel := models.NewTwoFactorModel()
+	cred, err := twoFactorModel.FindByUserID(authData.UserID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && cred.IsConfirmed()) {
+		return nil, ErrTwoFactorNotPending
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := totp.Validate(cred.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	if err := twoFactorModel.Confirm(cred.ID, step); err != nil {
+		return nil, err
+	}
+
+	codes, err := RegenerateRecoveryCodes(authData.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	authData.TwoFactorEnabled = true
+	authData.NeedsTwoFactorSetup = false
+	sess.Regenerate()
+	return codes, sess.Set(auth.AuthKey, authData)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes. Only their
+// hashes are stored.
+func RegenerateRecoveryCodes(userID uint64) ([]string, error) {
+	codes := make([]string, RECOVERY_CODE_COUNT)
+	hashes := make([]string, RECOVERY_CODE_COUNT)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := hex.EncodeToString(b)
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = auth.HashToken(raw)
+	}
+
+	if err := models.NewRecoveryCodeModel().Replace(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor removes two-factor authentication after re-checking the
+// user's password
+func DisableTwoFactor(sess *session.Session, password string) error {
+	authData, ok := auth.GetAuthData(sess).(*AuthData)
+	if !ok {
+		return auth.ErrNotAuthenticated
+	}
+
+	user, err := models.NewUserModel().FindByID(int64(authData.UserID))
+	if err != nil {
+		return err
+	}
+	if ok, _, _ := utils.CheckPassword(password, user.PasswordHash); !ok {
+		return errors.New("incorrect password")
+	}
+
+	if err := models.NewTwoFactorModel().DeleteForUser(user.ID); err != nil {
+		return err
+	}
+	if err := models.NewRecoveryCodeModel().DeleteForUser(user.ID); err != nil {
+		return err
+	}
+
+	authData.TwoFactorEnabled = false
+	authData.NeedsTwoFactorSetup = authData.IsAdmin && config.Auth.RequireAdminTwoFactor
+	return sess.Set(auth.AuthKey, authData)
+}
+
+// normalizeRecoveryCode accepts codes with or without the dash and spaces
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != 10 {
+		return ""
+	}
+	if _, err := hex.DecodeString(code); err != nil {
+		return ""
+	}
+	return code
+}
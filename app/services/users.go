@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gohst/app/models"
+	"gohst/internal/audit"
+	"gohst/internal/auth"
+	"gohst/internal/session"
+)
+
+// ErrUnknownRole is returned when changing a user to a role that does not exist
+var ErrUnknownRole = errors.New("unknown role")
+
+// ListUsers returns users by email, limit at a time starting at offset
+func ListUsers(limit int, offset int) ([]models.User, error) {
+	return models.NewUserModel().Page(limit, offset)
+}
+
+// ChangeUserRole moves the user to the named role and records the change.
+// Sessions keep the role they logged in with, so the user's sessions are
+// logged out for the new role to take effect.
+func ChangeUserRole(ctx context.Context, user *models.User, roleName string) error {
+	roleModel := models.NewRoleModel()
+	role, err := roleModel.FindByName(roleName)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnknownRole, roleName)
+	}
+	if role.ID == user.RoleID {
+		return nil
+	}
+
+	previous := ""
+	if old, err := roleModel.FindByID(user.RoleID); err == nil {
+		previous = old.Name
+	}
+
+	if err := models.NewUserModel().UpdateRole(user.ID, role.ID); err != nil {
+		return err
+	}
+	user.RoleID = role.ID
+
+	audit.Record(ctx, audit.Event{
+		Type:     audit.ROLE_CHANGED,
+		UserID:   auditUserID(user.ID),
+		Metadata: map[string]any{"from": previous, "to": role.Name},
+	})
+
+	if _, err := auth.LogoutEverywhere(ctx, auditUserID(user.ID)); errors.Is(err, session.ErrStatelessStore) {
+		log.WarnContext(ctx, "cookie store sessions keep the old role until they expire", "user", user.ID)
+	} else if err != nil {
+		log.ErrorContext(ctx, "logging out sessions after role change", "user", user.ID, "err", err)
+	}
+	return nil
+}
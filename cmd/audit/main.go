@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	appConfig "gohst/app/config"
+	"gohst/app/services"
+	"gohst/internal/audit"
+	"gohst/internal/config"
+	"gohst/internal/db"
+)
+
+func main() {
+	// Initialize configuration
+	config.RegisterAppConfig(appConfig.InitAppConfig())
+	config.InitConfig()
+	dbConfigs := appConfig.CreateDBConfigs() // Initialize database configurations
+	db.InitDBPool(dbConfigs)                 // Initialize database connections
+
+	defer db.CloseDBPool()
+
+	if len(os.Args) < 2 {
+		showHelp()
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "list":
+		listEvents(os.Args[2:])
+	default:
+		showHelp()
+		os.Exit(1)
+	}
+}
+
+func showHelp() {
+	fmt.Print(`
+Audit Log Commands:
+  list  - Show security audit events, newest first
+
+Usage:
+  audit list [-type auth.login_failed] [-user <email|id>] [-ip <address>] [-since 24h] [-limit 100]
+
+Event types: auth.login, auth.login_failed, auth.logout, auth.register,
+auth.password_changed, user.role_changed, token.created, ratelimit.denied
+`)
+}
+
+func listEvents(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	eventType := fs.String("type", "", "only events of this type")
+	user := fs.String("user", "", "only events by or about this user (email or ID)")
+	ip := fs.String("ip", "", "only events from this IP address")
+	since := fs.Duration("since", 0, "only events this recent, e.g. 24h")
+	limit := fs.Int("limit", audit.DEFAULT_SEARCH_LIMIT, "most events to show")
+	fs.Parse(args)
+
+	filter := audit.Filter{
+		Type:   *eventType,
+		UserID: userID(*user),
+		IP:     *ip,
+		Limit:  *limit,
+	}
+	if *since > 0 {
+		filter.Since = time.Now().Add(-*since)
+	}
+
+	events, err := services.AuditEvents(context.Background(), filter)
+	if err != nil {
+		log.Fatal("Failed to search the audit log:", err)
+	}
+	if len(events) == 0 {
+		fmt.Println("No matching events")
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tTYPE\tACTOR\tUSER\tIP\tREQUEST\tDETAILS")
+	for _, e := range events {
+		actor := e.ActorID
+		if e.ImpersonatorID != "" {
+			actor += " (by " + e.ImpersonatorID + ")"
+		}
+		details, _ := json.Marshal(e.Metadata)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.CreatedAt.Local().Format("2006-01-02 15:04:05"), e.Type, actor, e.UserID, e.IP, e.RequestID, details)
+	}
+	tw.Flush()
+}
+
+// userID accepts a user ID or the email of an account
+func userID(value string) string {
+	id, err := services.AuditUserID(value)
+	if err != nil {
+		log.Fatal("User not found:", value)
+	}
+	return id
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	appConfig "gohst/app/config"
+	appRoutes "gohst/app/routes"
+	"gohst/internal/config"
+	"gohst/internal/routes"
+	"gohst/internal/session"
+)
+
+func main() {
+	// Initialize configuration
+	config.RegisterAppConfig(appConfig.InitAppConfig())
+	config.InitConfig()
+	session.Init() // Controllers chain the session middleware
+
+	if len(os.Args) > 1 && os.Args[1] != "list" {
+		showHelp()
+		os.Exit(1)
+	}
+
+	// Building the router registers the routes without serving them
+	appRoutes.NewAppRouter().SetupRoutes()
+	listRoutes()
+}
+
+func showHelp() {
+	fmt.Print(`
+Route Commands:
+  list  - List every route with its method, pattern, name and middleware
+
+Usage:
+  routes list
+`)
+}
+
+func listRoutes() {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tNAME\tMIDDLEWARE")
+	for _, route := range routes.Routes() {
+		method := route.Method
+		if method == "" {
+			method = "ANY"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", method, route.Pattern, route.Name, strings.Join(route.Middleware, " > "))
+	}
+	tw.Flush()
+
+	fmt.Printf("\nEvery request first runs: %s\n", strings.Join(routes.GlobalMiddleware(), " > "))
+}
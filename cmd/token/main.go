@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	appConfig "gohst/app/config"
+	"gohst/app/models"
+	"gohst/app/services"
+	"gohst/internal/audit"
+	"gohst/internal/config"
+	"gohst/internal/db"
+)
+
+func main() {
+	// Initialize configuration
+	config.RegisterAppConfig(appConfig.InitAppConfig())
+	config.InitConfig()
+	dbConfigs := appConfig.CreateDBConfigs() // Initialize database configurations
+	db.InitDBPool(dbConfigs)                 // Initialize database connections
+
+	defer db.CloseDBPool()
+	audit.Init() // Token creation is recorded in the audit log
+	defer audit.Close(context.Background())
+
+	if len(os.Args) < 2 {
+		showHelp()
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "create":
+		createToken(os.Args[2:])
+	case "list":
+		if len(os.Args) < 3 {
+			log.Fatal("Usage: token list <email>")
+		}
+		listTokens(findUser(os.Args[2]))
+	case "revoke":
+		if len(os.Args) < 4 {
+			log.Fatal("Usage: token revoke <email> <token_id>")
+		}
+		id, err := strconv.ParseUint(os.Args[3], 10, 64)
+		if err != nil {
+			log.Fatal("Invalid token ID:", os.Args[3])
+		}
+		if err := services.RevokeAccessToken(findUser(os.Args[2]).ID, id); err != nil {
+			log.Fatal("Failed to revoke token:", err)
+		}
+		fmt.Printf("Revoked token %d\n", id)
+	default:
+		showHelp()
+		os.Exit(1)
+	}
+}
+
+func showHelp() {
+	fmt.Print(`
+Access Token Commands:
+  create  - Mint a personal access token for a user
+  list    - List a user's tokens
+  revoke  - Revoke one of a user's tokens
+
+Usage:
+  token create [-abilities user:read,...] [-expires days] <email> <name>
+  token list <email>
+  token revoke <email> <token_id>
+
+Omit -expires or pass 0 for a token that never expires. Use -abilities '*'
+to grant every ability.
+`)
+}
+
+func createToken(args []string) {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	abilities := fs.String("abilities", "", "comma-separated abilities to grant")
+	expires := fs.Int("expires", 0, "days until the token expires, 0 for never")
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		log.Fatal("Usage: token create [-abilities user:read,...] [-expires days] <email> <name>")
+	}
+	user := findUser(fs.Arg(0))
+
+	var granted []string
+	for _, ability := range strings.Split(*abilities, ",") {
+		if ability = strings.TrimSpace(ability); ability != "" {
+			granted = append(granted, ability)
+		}
+	}
+
+	token, record, err := services.CreateAccessToken(context.Background(), user.ID, fs.Arg(1), granted, time.Duration(*expires)*24*time.Hour)
+	if err != nil {
+		log.Fatal("Failed to create token:", err)
+	}
+
+	fmt.Printf("Created token %d (%s) for %s\n", record.ID, record.Name, user.Email)
+	fmt.Println("Copy it now, it will not be shown again:")
+	fmt.Println(token)
+}
+
+func listTokens(user *models.User) {
+	tokens, err := services.AccessTokens(user.ID)
+	if err != nil {
+		log.Fatal("Failed to list tokens:", err)
+	}
+	if len(tokens) == 0 {
+		fmt.Printf("%s has no tokens\n", user.Email)
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tABILITIES\tLAST USED\tEXPIRES")
+	for _, t := range tokens {
+		lastUsed, expires := "never", "never"
+		if t.LastUsedAt.Valid {
+			lastUsed = t.LastUsedAt.Time.Format("2006-01-02 15:04")
+		}
+		if t.ExpiresAt.Valid {
+			expires = t.ExpiresAt.Time.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", t.ID, t.Name, t.Abilities, lastUsed, expires)
+	}
+	tw.Flush()
+}
+
+func findUser(email string) *models.User {
+	user, err := models.NewUserModel().FindByEmail(email)
+	if err != nil {
+		log.Fatal("User not found:", email)
+	}
+	return user
+}
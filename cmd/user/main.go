@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	appConfig "gohst/app/config"
+	"gohst/app/models"
+	"gohst/app/services"
+	"gohst/internal/audit"
+	"gohst/internal/config"
+	"gohst/internal/db"
+	"gohst/internal/redisconn"
+	"gohst/internal/session"
+)
+
+func main() {
+	// Initialize configuration
+	config.RegisterAppConfig(appConfig.InitAppConfig())
+	config.InitConfig()
+	dbConfigs := appConfig.CreateDBConfigs() // Initialize database configurations
+	db.InitDBPool(dbConfigs)                 // Initialize database connections
+
+	defer db.CloseDBPool()
+	if err := redisconn.Init(); err != nil {
+		log.Fatal("Invalid Redis configuration:", err)
+	}
+	session.Init() // Role changes log the user's sessions out
+	defer redisconn.Close()
+	audit.Init()
+	defer audit.Close(context.Background())
+
+	if len(os.Args) < 2 {
+		showHelp()
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "unlock":
+		if len(os.Args) < 3 {
+			log.Fatal("Usage: user unlock <email>")
+		}
+		user := findUser(os.Args[2])
+		if err := services.UnlockAccount(user.ID); err != nil {
+			log.Fatal("Failed to unlock account:", err)
+		}
+		fmt.Printf("Unlocked %s\n", user.Email)
+	case "lockout":
+		if len(os.Args) < 3 {
+			log.Fatal("Usage: user lockout <email>")
+		}
+		showLockout(findUser(os.Args[2]))
+	case "role":
+		if len(os.Args) < 4 {
+			log.Fatal("Usage: user role <email> <role>")
+		}
+		user := findUser(os.Args[2])
+		if err := services.ChangeUserRole(context.Background(), user, os.Args[3]); err != nil {
+			log.Fatal("Failed to change role:", err)
+		}
+		fmt.Printf("%s now has the %s role\n", user.Email, os.Args[3])
+	default:
+		showHelp()
+		os.Exit(1)
+	}
+}
+
+func showHelp() {
+	fmt.Print(`
+User Commands:
+  lockout - Show a user's failed logins and lockout
+  unlock  - Lift a lockout and forget the user's failed logins
+  role    - Move a user to another role and log out their sessions
+
+Usage:
+  user lockout <email>
+  user unlock <email>
+  user role <email> <role>
+`)
+}
+
+func showLockout(user *models.User) {
+	lockout, err := models.NewAccountLockoutModel().FindByUserID(user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("%s has no failed logins\n", user.Email)
+		return
+	}
+	if err != nil {
+		log.Fatal("Failed to load lockout:", err)
+	}
+
+	fmt.Printf("Failed logins since last lockout: %d\n", lockout.FailedAttempts)
+	fmt.Printf("Lockouts in a row: %d\n", lockout.Lockouts)
+	if lockout.IsLocked(time.Now()) {
+		fmt.Printf("Locked until: %s\n", lockout.LockedUntil.Time.Format("2006-01-02 15:04"))
+	} else {
+		fmt.Println("Not locked")
+	}
+}
+
+func findUser(email string) *models.User {
+	user, err := models.NewUserModel().FindByEmail(email)
+	if err != nil {
+		log.Fatal("User not found:", email)
+	}
+	return user
+}
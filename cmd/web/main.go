@@ -1,8 +1,9 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"strconv"
 
 	"gohst/app/config"
@@ -12,45 +13,77 @@ import (
 	"gohst/views/layouts"
 
 	coreConfig "gohst/internal/config"
+	"gohst/internal/audit"
 	"gohst/internal/db"
+	"gohst/internal/lifecycle"
+	"gohst/internal/logger"
+	"gohst/internal/ratelimit"
+	"gohst/internal/redisconn"
 	"gohst/internal/session"
 )
 
 func main() {
 	defer func() {
         if r := recover(); r != nil {
-            log.Println("Recovered from panic:", r)
+            slog.Error("recovered from panic", "panic", r)
         }
     }()
 
 	coreConfig.RegisterAppConfig(config.InitAppConfig())
 	coreConfig.InitConfig()    // Initialize app-specific config
+	logger.Init()              // Structured logging per LOG_FORMAT and LOG_LEVEL
 
 	dbConfigs := config.CreateDBConfigs()   // Initialize database configurations
+	if err := redisconn.Init(); err != nil { // Fail fast on bad REDIS_* settings
+		slog.Error("invalid Redis configuration", "err", err)
+		os.Exit(1)
+	}
 	session.Init()
+	session.SetClientIPResolver(ratelimit.ClientIP) // Record the real client IP behind trusted proxies
+
+	// Opt-in session fingerprinting (SESSION_FINGERPRINT)
+	if policy := session.NewFingerprintPolicy(); policy != nil {
+		policy.ClientIP = ratelimit.ClientIP
+		policy.CoarsenIP = ratelimit.CoarsenIPv6
+		session.SM.SetFingerprintPolicy(policy)
+		session.SMAdmin.SetFingerprintPolicy(policy)
+	}
 	db.InitDBPool(dbConfigs) // Initialize database connections
-	defer db.CloseDBPool()
+
+	// Security audit log, written in the background and flushed on shutdown
+	audit.Init()
+	ratelimit.SetDenyHook(audit.RecordRateLimit)
 
 	// Register layouts
 	render.RegisterLayout("layouts/default", layouts.Default)
 	render.RegisterLayout("layouts/auth", layouts.Auth)
 
 	if config.App.IsDevelopment() {
-
-		log.Println("appConfig.App:", config.App)
-		log.Println("config.Vite:", coreConfig.Vite)
+		slog.Debug("app config", "app", config.App)
+		slog.Debug("vite config", "vite", coreConfig.Vite)
 	}
 
 	appRouter := appRoutes.NewAppRouter()
 	mux := routes.RegisterRouter(appRouter)
 	port := strconv.Itoa(config.App.Port)
-	server := http.Server{
-		Addr: ":" + port,
-		Handler: mux,
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           mux,
+		ReadTimeout:       coreConfig.Server.ReadTimeout,
+		ReadHeaderTimeout: coreConfig.Server.ReadHeaderTimeout,
+		WriteTimeout:      coreConfig.Server.WriteTimeout,
+		IdleTimeout:       coreConfig.Server.IdleTimeout,
 	}
 
-	log.Println("Starting server on port:" , port)
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatal(err)
+	// Serve until SIGINT/SIGTERM, then drain requests and run the shutdown
+	// hooks the subsystems registered (sessions, rate limits, audit, Redis, DB)
+	slog.Info("starting server", "port", port)
+	shutdown := lifecycle.Options{
+		Delay:        coreConfig.Server.ShutdownDelay,
+		DrainTimeout: coreConfig.Server.ShutdownTimeout,
+	}
+	if err := lifecycle.Serve(server, shutdown); err != nil {
+		slog.Error("server stopped", "err", err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,26 @@
+CREATE TABLE password_resets (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL,
+    token_hash      VARCHAR(64) NOT NULL UNIQUE,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    used_at         TIMESTAMPTZ NULL,
+    created_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Index for invalidating a user's outstanding tokens
+CREATE INDEX idx_password_resets_user_id ON password_resets (user_id);
+
+CREATE OR REPLACE FUNCTION update_updated_at_password_resets()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = (NOW() AT TIME ZONE 'UTC');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_password_resets_updated_at
+BEFORE UPDATE ON password_resets
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_password_resets();
@@ -0,0 +1,35 @@
+CREATE TABLE two_factor_credentials (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL UNIQUE,
+    secret          VARCHAR(64) NOT NULL,
+    confirmed_at    TIMESTAMPTZ NULL,
+    last_used_step  BIGINT NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE two_factor_recovery_codes (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL,
+    code_hash       VARCHAR(64) NOT NULL,
+    used_at         TIMESTAMPTZ NULL,
+    created_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_two_factor_recovery_codes_user_id ON two_factor_recovery_codes (user_id);
+
+CREATE OR REPLACE FUNCTION update_updated_at_two_factor_credentials()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = (NOW() AT TIME ZONE 'UTC');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_two_factor_credentials_updated_at
+BEFORE UPDATE ON two_factor_credentials
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_two_factor_credentials();
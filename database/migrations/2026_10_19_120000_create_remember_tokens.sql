@@ -0,0 +1,26 @@
+CREATE TABLE remember_tokens (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL,
+    selector        VARCHAR(32) NOT NULL UNIQUE,
+    validator_hash  VARCHAR(64) NOT NULL,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Index for revoking every token of a user
+CREATE INDEX idx_remember_tokens_user_id ON remember_tokens (user_id);
+
+CREATE OR REPLACE FUNCTION update_updated_at_remember_tokens()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = (NOW() AT TIME ZONE 'UTC');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_remember_tokens_updated_at
+BEFORE UPDATE ON remember_tokens
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_remember_tokens();
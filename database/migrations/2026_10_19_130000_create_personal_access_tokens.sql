@@ -0,0 +1,28 @@
+CREATE TABLE personal_access_tokens (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL,
+    name            VARCHAR(255) NOT NULL,
+    token_hash      VARCHAR(64) NOT NULL UNIQUE,
+    abilities       TEXT NOT NULL DEFAULT '',
+    last_used_at    TIMESTAMPTZ NULL,
+    expires_at      TIMESTAMPTZ NULL,
+    created_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Index for listing a user's tokens
+CREATE INDEX idx_personal_access_tokens_user_id ON personal_access_tokens (user_id);
+
+CREATE OR REPLACE FUNCTION update_updated_at_personal_access_tokens()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = (NOW() AT TIME ZONE 'UTC');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_personal_access_tokens_updated_at
+BEFORE UPDATE ON personal_access_tokens
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_personal_access_tokens();
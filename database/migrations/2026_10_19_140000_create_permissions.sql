@@ -0,0 +1,34 @@
+-- A role has every permission of its parent role, e.g. admin -> manager -> user
+ALTER TABLE roles ADD COLUMN parent_id BIGINT NULL REFERENCES roles(id) ON DELETE SET NULL;
+
+CREATE TABLE permissions (
+    id              BIGSERIAL PRIMARY KEY,
+    name            VARCHAR(100) NOT NULL UNIQUE,
+    description     VARCHAR(255) NULL,
+    created_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE TABLE role_permissions (
+    role_id         BIGINT NOT NULL,
+    permission_id   BIGINT NOT NULL,
+    PRIMARY KEY (role_id, permission_id),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+);
+
+-- Index for finding the roles that grant a permission
+CREATE INDEX idx_role_permissions_permission_id ON role_permissions (permission_id);
+
+CREATE OR REPLACE FUNCTION update_updated_at_permissions()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = (NOW() AT TIME ZONE 'UTC');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_permissions_updated_at
+BEFORE UPDATE ON permissions
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_permissions();
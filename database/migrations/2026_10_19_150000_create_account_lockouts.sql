@@ -0,0 +1,24 @@
+CREATE TABLE account_lockouts (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL UNIQUE,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    lockouts        INT NOT NULL DEFAULT 0,
+    locked_until    TIMESTAMPTZ NULL,
+    last_failed_at  TIMESTAMPTZ NULL,
+    created_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE OR REPLACE FUNCTION update_updated_at_account_lockouts()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = (NOW() AT TIME ZONE 'UTC');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_account_lockouts_updated_at
+BEFORE UPDATE ON account_lockouts
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_account_lockouts();
@@ -0,0 +1,29 @@
+CREATE TABLE user_identities (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL,
+    provider        VARCHAR(64) NOT NULL,
+    subject         VARCHAR(255) NOT NULL,
+    email           VARCHAR(255) NOT NULL DEFAULT '',
+    last_login_at   TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at      TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    -- A provider account belongs to one user
+    UNIQUE (provider, subject)
+);
+
+-- Index for listing a user's linked accounts
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
+
+CREATE OR REPLACE FUNCTION update_updated_at_user_identities()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = (NOW() AT TIME ZONE 'UTC');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_user_identities_updated_at
+BEFORE UPDATE ON user_identities
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_user_identities();
@@ -0,0 +1,18 @@
+CREATE TABLE impersonation_logs (
+    id                  BIGSERIAL PRIMARY KEY,
+    impersonator_id     BIGINT NULL,
+    user_id             BIGINT NULL,
+    action              VARCHAR(16) NOT NULL,
+    ip_address          VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent          TEXT NOT NULL DEFAULT '',
+    created_at          TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at          TIMESTAMPTZ DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    -- The trail outlives the accounts it mentions
+    FOREIGN KEY (impersonator_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    CHECK (action IN ('start', 'stop'))
+);
+
+-- Indexes for reviewing what an admin did and who was impersonated
+CREATE INDEX idx_impersonation_logs_impersonator_id ON impersonation_logs (impersonator_id);
+CREATE INDEX idx_impersonation_logs_user_id ON impersonation_logs (user_id);
@@ -0,0 +1,20 @@
+CREATE TABLE audit_events (
+    id                  BIGSERIAL PRIMARY KEY,
+    event_type          VARCHAR(64) NOT NULL,
+    -- User IDs are kept as written, without foreign keys: the log outlives
+    -- the accounts it mentions and must not change when they are deleted
+    actor_id            VARCHAR(64) NOT NULL DEFAULT '',
+    user_id             VARCHAR(64) NOT NULL DEFAULT '',
+    impersonator_id     VARCHAR(64) NOT NULL DEFAULT '',
+    ip_address          VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent          TEXT NOT NULL DEFAULT '',
+    request_id          VARCHAR(128) NOT NULL DEFAULT '',
+    metadata            JSONB NOT NULL DEFAULT '{}',
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+-- Indexes for reviewing an event type, a user's activity or an address
+CREATE INDEX idx_audit_events_type_created ON audit_events (event_type, created_at DESC);
+CREATE INDEX idx_audit_events_actor_created ON audit_events (actor_id, created_at DESC);
+CREATE INDEX idx_audit_events_user_created ON audit_events (user_id, created_at DESC);
+CREATE INDEX idx_audit_events_ip_created ON audit_events (ip_address, created_at DESC);
@@ -0,0 +1,37 @@
+-- Permission catalog. Add new permissions here and grant them below.
+INSERT INTO permissions (name, description) VALUES
+    ('posts.view', 'View posts'),
+    ('posts.create', 'Create posts'),
+    ('posts.edit', 'Edit any post'),
+    ('posts.delete', 'Delete any post'),
+    ('users.view', 'View user accounts'),
+    ('users.edit', 'Edit user accounts'),
+    ('users.delete', 'Delete user accounts'),
+    ('roles.manage', 'Manage roles and their permissions')
+ON CONFLICT (name) DO NOTHING;
+
+-- Inheritance: admin has everything manager has, manager everything user has
+UPDATE roles SET parent_id = (SELECT id FROM roles WHERE name = 'manager') WHERE name = 'admin';
+UPDATE roles SET parent_id = (SELECT id FROM roles WHERE name = 'user') WHERE name = 'manager';
+
+-- Each role only lists what it adds to its parent
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON p.name IN ('posts.view', 'posts.create')
+WHERE r.name = 'user'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON p.name IN ('posts.edit', 'posts.delete', 'users.view')
+WHERE r.name = 'manager'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON p.name IN ('users.edit', 'users.delete', 'roles.manage')
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
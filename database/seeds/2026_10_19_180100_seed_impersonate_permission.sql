@@ -0,0 +1,11 @@
+-- Admins can view the app as another user
+INSERT INTO permissions (name, description) VALUES
+    ('users.impersonate', 'View the app as another user')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON p.name = 'users.impersonate'
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
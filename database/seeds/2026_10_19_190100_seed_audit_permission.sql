@@ -0,0 +1,11 @@
+-- Admins can review the security audit log
+INSERT INTO permissions (name, description) VALUES
+    ('audit.view', 'Review the security audit log')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON p.name = 'audit.view'
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,229 @@
+// Package audit keeps a durable record of security-relevant events: logins
+// and failed logins, logouts, registrations, password and role changes,
+// access token creation and rate-limit denials.
+//
+// Record fills in who did it and from where (the logged-in user, the client
+// IP, user agent and request ID) from the request context and hands the
+// event to a background writer, so recording never slows a request down:
+//
+//	audit.Record(r.Context(), audit.Event{
+//	    Type:     audit.PASSWORD_CHANGED,
+//	    UserID:   "42",
+//	    Metadata: map[string]any{"via": "reset"},
+//	})
+//
+// Call Init at startup and Close on shutdown; until Init runs, or with
+// AUDIT_ENABLED=false, Record does nothing.
+package audit
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"gohst/internal/auth"
+	"gohst/internal/config"
+	"gohst/internal/lifecycle"
+	"gohst/internal/logger"
+	"gohst/internal/ratelimit"
+	"gohst/internal/session"
+)
+
+var log = logger.Component("audit")
+
+// Event types recorded by the framework and the starter app
+const (
+	LOGIN_SUCCEEDED  = "auth.login"
+	LOGIN_FAILED     = "auth.login_failed"
+	LOGOUT           = "auth.logout"
+	REGISTERED       = "auth.register"
+	PASSWORD_CHANGED = "auth.password_changed"
+	ROLE_CHANGED     = "user.role_changed"
+	TOKEN_CREATED    = "token.created"
+	RATE_LIMITED     = "ratelimit.denied"
+)
+
+// EventTypes lists the event types above, e.g. for filter menus
+var EventTypes = []string{
+	LOGIN_SUCCEEDED,
+	LOGIN_FAILED,
+	LOGOUT,
+	REGISTERED,
+	PASSWORD_CHANGED,
+	ROLE_CHANGED,
+	TOKEN_CREATED,
+	RATE_LIMITED,
+}
+
+const (
+	// Client-supplied values are cut to these lengths before they are stored
+	MAX_REQUEST_ID_LENGTH = 128
+	MAX_USER_AGENT_LENGTH = 512
+)
+
+// Event is one entry in the audit log. ActorID is the user who acted and
+// UserID the user the event is about; for most events they are the same.
+// ImpersonatorID is set when an admin acted while impersonating the actor.
+type Event struct {
+	ID             int64
+	Type           string
+	ActorID        string
+	UserID         string
+	ImpersonatorID string
+	IP             string
+	UserAgent      string
+	RequestID      string
+	Metadata       map[string]any
+	CreatedAt      time.Time
+}
+
+// Store persists audit events
+type Store interface {
+	Write(ctx context.Context, events []Event) error
+}
+
+type requestInfo struct {
+	ip        string
+	userAgent string
+	requestID string
+}
+
+type contextKey struct{}
+
+// Middleware keeps the client IP, user agent and request ID in the request
+// context for Record. RegisterRouter applies it to every request.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithRequest(r.Context(), r)))
+	})
+}
+
+// WithRequest returns a context carrying the request details Record fills in.
+// The request ID is the one middleware.RequestID put in ctx.
+func WithRequest(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestInfo{
+		ip:        ratelimit.ClientIP(r),
+		userAgent: truncate(r.UserAgent(), MAX_USER_AGENT_LENGTH),
+		requestID: truncate(logger.RequestID(ctx), MAX_REQUEST_ID_LENGTH),
+	})
+}
+
+var (
+	mu       sync.RWMutex
+	recorder *Recorder
+)
+
+// Init starts recording to the audit_events table, unless AUDIT_ENABLED is off
+func Init() {
+	if config.Audit != nil && !config.Audit.Enabled {
+		log.Info("disabled, events will not be recorded")
+		return
+	}
+	size := config.AUDIT_BUFFER_SIZE_DEFAULT
+	if config.Audit != nil && config.Audit.BufferSize > 0 {
+		size = config.Audit.BufferSize
+	}
+	Start(NewRecorder(NewDBStore(), size))
+
+	// Flush buffered events on shutdown, before the database pool closes
+	lifecycle.OnShutdown("audit", Close)
+}
+
+// Start records events through rec, replacing any recorder already running
+// without closing it
+func Start(rec *Recorder) {
+	mu.Lock()
+	defer mu.Unlock()
+	recorder = rec
+}
+
+// Close stops recording and waits for buffered events to be written, or for
+// ctx to end
+func Close(ctx context.Context) error {
+	mu.Lock()
+	rec := recorder
+	recorder = nil
+	mu.Unlock()
+
+	if rec == nil {
+		return nil
+	}
+	return rec.Close(ctx)
+}
+
+// Record adds an event to the audit log. Actor, impersonator, IP, user agent
+// and request ID are taken from ctx unless the event already has them, and
+// the event is written in the background.
+func Record(ctx context.Context, event Event) {
+	mu.RLock()
+	rec := recorder
+	mu.RUnlock()
+	if rec == nil {
+		return
+	}
+
+	fill(ctx, &event)
+	rec.Record(event)
+}
+
+// RecordRateLimit records a rate-limit denial. Pass it to
+// ratelimit.SetDenyHook.
+func RecordRateLimit(r *http.Request, entry ratelimit.LogEntry) {
+	Record(WithRequest(r.Context(), r), Event{
+		Type: RATE_LIMITED,
+		Metadata: map[string]any{
+			"method":      entry.Method,
+			"path":        entry.Path,
+			"scope":       entry.Scope,
+			"key_type":    entry.KeyType,
+			"retry_after": entry.RetryAfter,
+		},
+	})
+}
+
+// fill completes the event from the request and session in ctx
+func fill(ctx context.Context, event *Event) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	if info, ok := ctx.Value(contextKey{}).(*requestInfo); ok {
+		if event.IP == "" {
+			event.IP = info.ip
+		}
+		if event.UserAgent == "" {
+			event.UserAgent = info.userAgent
+		}
+		if event.RequestID == "" {
+			event.RequestID = info.requestID
+		}
+	}
+
+	if sess := session.FromContext(ctx); sess != nil {
+		if event.ActorID == "" {
+			if user, ok := auth.GetAuthData(sess).(auth.UserIDProvider); ok {
+				event.ActorID = user.GetUserID()
+			}
+		}
+		if event.ImpersonatorID == "" {
+			if impersonation, ok := auth.CurrentImpersonation(sess); ok {
+				if admin, ok := impersonation.Impersonator.Data().(auth.UserIDProvider); ok {
+					event.ImpersonatorID = admin.GetUserID()
+				}
+			}
+		}
+	}
+
+	// Most events are about the user who caused them
+	if event.UserID == "" {
+		event.UserID = event.ActorID
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
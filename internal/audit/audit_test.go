@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gohst/internal/auth"
+	"gohst/internal/config"
+	"gohst/internal/logger"
+	"gohst/internal/session"
+)
+
+type testAppConfig struct{}
+
+func (testAppConfig) GetURL() string          { return "http://localhost:3030" }
+func (testAppConfig) GetDistPath() string     { return "static/dist" }
+func (testAppConfig) IsProduction() bool      { return false }
+func (testAppConfig) IsDevelopment() bool     { return false }
+func (testAppConfig) IsMaintenanceMode() bool { return false }
+
+type testAuthData struct {
+	UserID string
+}
+
+func (d *testAuthData) Data() any         { return d }
+func (d *testAuthData) GetUserID() string { return d.UserID }
+
+func init() {
+	gob.Register(&testAuthData{})
+}
+
+// memoryStore keeps written events; block, when set, holds writes until closed
+type memoryStore struct {
+	mu     sync.Mutex
+	events []Event
+	block  chan struct{}
+	err    error
+}
+
+func (s *memoryStore) Write(ctx context.Context, events []Event) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	return s.err
+}
+
+func (s *memoryStore) written() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+// withRequest runs fn inside a request with a session, logged in as data
+// when it is not nil, and the audit middleware applied
+func withRequest(t *testing.T, r *http.Request, data auth.AuthDataProvider, fn func(ctx context.Context)) {
+	t.Helper()
+	config.RegisterAppConfig(testAppConfig{})
+	config.Session = &config.SessionConfig{Length: config.SESSION_LENGTH_DEFAULT, Name: "_test_session"}
+
+	memory, storeType := session.NewMemorySessionManager("_test_session")
+	sm := session.NewSessionManagerWithStore(memory, storeType, "_test_session")
+	handler := Middleware(sm.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if data != nil {
+			if err := session.FromContext(r.Context()).Set(auth.AuthKey, data); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+		}
+		fn(r.Context())
+	})))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestRecorderWritesEvents(t *testing.T) {
+	store := &memoryStore{}
+	rec := NewRecorder(store, 10)
+
+	for _, eventType := range []string{LOGIN_SUCCEEDED, LOGOUT} {
+		if !rec.Record(Event{Type: eventType}) {
+			t.Fatalf("Record(%s) dropped the event", eventType)
+		}
+	}
+	if err := rec.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	written := store.written()
+	if len(written) != 2 || written[0].Type != LOGIN_SUCCEEDED || written[1].Type != LOGOUT {
+		t.Fatalf("written = %+v, want login then logout", written)
+	}
+}
+
+func TestRecorderDropsWhenFull(t *testing.T) {
+	store := &memoryStore{block: make(chan struct{})}
+	rec := NewRecorder(store, 1)
+
+	// The first event is taken by the writer and blocks it, the second
+	// fills the buffer
+	rec.Record(Event{Type: LOGIN_FAILED})
+	deadline := time.Now().Add(time.Second)
+	for len(rec.events) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !rec.Record(Event{Type: LOGIN_FAILED}) {
+		t.Fatal("second event dropped with room in the buffer")
+	}
+	if rec.Record(Event{Type: LOGIN_FAILED}) {
+		t.Fatal("event accepted with the buffer full")
+	}
+	if got := rec.Dropped(); got != 1 {
+		t.Fatalf("Dropped() = %d, want 1", got)
+	}
+
+	close(store.block)
+	if err := rec.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := len(store.written()); got != 2 {
+		t.Fatalf("wrote %d events, want 2", got)
+	}
+}
+
+func TestRecorderClose(t *testing.T) {
+	store := &memoryStore{block: make(chan struct{})}
+	rec := NewRecorder(store, 10)
+	rec.Record(Event{Type: LOGOUT})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := rec.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close with a stuck store = %v, want deadline exceeded", err)
+	}
+	if rec.Record(Event{Type: LOGOUT}) {
+		t.Fatal("event accepted after Close")
+	}
+
+	close(store.block)
+	if err := rec.Close(context.Background()); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+}
+
+func TestRecordFillsFromRequest(t *testing.T) {
+	store := &memoryStore{}
+	Start(NewRecorder(store, 10))
+
+	r := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+	r.RemoteAddr = "203.0.113.9:4321"
+	r.Header.Set("User-Agent", "test-agent")
+	r = r.WithContext(logger.WithRequest(r.Context(), strings.Repeat("r", MAX_REQUEST_ID_LENGTH+10)))
+
+	withRequest(t, r, &testAuthData{UserID: "7"}, func(ctx context.Context) {
+		Record(ctx, Event{Type: PASSWORD_CHANGED})
+		Record(ctx, Event{Type: ROLE_CHANGED, UserID: "9"})
+	})
+	if err := Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	written := store.written()
+	if len(written) != 2 {
+		t.Fatalf("wrote %d events, want 2", len(written))
+	}
+	event := written[0]
+	if event.ActorID != "7" || event.UserID != "7" {
+		t.Errorf("actor/user = %q/%q, want 7/7", event.ActorID, event.UserID)
+	}
+	if event.IP != "203.0.113.9" || event.UserAgent != "test-agent" {
+		t.Errorf("IP/UA = %q/%q", event.IP, event.UserAgent)
+	}
+	if len(event.RequestID) != MAX_REQUEST_ID_LENGTH {
+		t.Errorf("request ID length = %d, want it cut to %d", len(event.RequestID), MAX_REQUEST_ID_LENGTH)
+	}
+	if event.CreatedAt.IsZero() {
+		t.Error("CreatedAt not set")
+	}
+	if written[1].ActorID != "7" || written[1].UserID != "9" {
+		t.Errorf("role change actor/user = %q/%q, want 7/9", written[1].ActorID, written[1].UserID)
+	}
+}
+
+func TestRecordWithImpersonation(t *testing.T) {
+	store := &memoryStore{}
+	Start(NewRecorder(store, 10))
+
+	withRequest(t, httptest.NewRequest(http.MethodGet, "/", nil), &testAuthData{UserID: "1"}, func(ctx context.Context) {
+		if err := auth.Impersonate(session.FromContext(ctx), &testAuthData{UserID: "2"}); err != nil {
+			t.Fatalf("Impersonate: %v", err)
+		}
+		Record(ctx, Event{Type: TOKEN_CREATED})
+	})
+	Close(context.Background())
+
+	written := store.written()
+	if len(written) != 1 || written[0].ActorID != "2" || written[0].ImpersonatorID != "1" {
+		t.Fatalf("written = %+v, want actor 2 impersonated by 1", written)
+	}
+}
+
+func TestRecordWithoutRecorder(t *testing.T) {
+	Close(context.Background())
+	// Nothing to assert beyond not panicking: recording is off until Start
+	Record(context.Background(), Event{Type: LOGOUT})
+}
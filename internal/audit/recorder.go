@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// MAX_BATCH_SIZE caps how many buffered events are written at once
+	MAX_BATCH_SIZE = 100
+	// WRITE_TIMEOUT bounds a single write to the store
+	WRITE_TIMEOUT = 5 * time.Second
+)
+
+// Recorder writes events to a store from a background goroutine. Events
+// wait in a bounded buffer; when it is full new events are dropped and
+// counted, so a slow database cannot hold requests up.
+type Recorder struct {
+	store   Store
+	events  chan Event
+	done    chan struct{}
+	dropped atomic.Int64
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewRecorder starts a recorder buffering up to bufferSize events
+func NewRecorder(store Store, bufferSize int) *Recorder {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	rec := &Recorder{
+		store:  store,
+		events: make(chan Event, bufferSize),
+		done:   make(chan struct{}),
+	}
+	go rec.run()
+	return rec
+}
+
+// Record queues an event, reporting false when it was dropped because the
+// buffer is full or the recorder is closed
+func (rec *Recorder) Record(event Event) bool {
+	rec.mu.RLock()
+	defer rec.mu.RUnlock()
+
+	if !rec.closed {
+		select {
+		case rec.events <- event:
+			return true
+		default:
+		}
+	}
+
+	// Log the first drop and then every thousandth, not every one of a flood
+	if n := rec.dropped.Add(1); n == 1 || n%1000 == 0 {
+		log.Warn("buffer full, dropped event", "type", event.Type, "dropped", n)
+	}
+	return false
+}
+
+// Dropped returns how many events were dropped since the recorder started
+func (rec *Recorder) Dropped() int64 {
+	return rec.dropped.Load()
+}
+
+// Close stops accepting events and waits until the buffered ones are
+// written or ctx ends
+func (rec *Recorder) Close(ctx context.Context) error {
+	rec.mu.Lock()
+	if !rec.closed {
+		rec.closed = true
+		close(rec.events)
+	}
+	rec.mu.Unlock()
+
+	select {
+	case <-rec.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run writes events in batches of whatever is buffered until Close
+func (rec *Recorder) run() {
+	defer close(rec.done)
+
+	batch := make([]Event, 0, MAX_BATCH_SIZE)
+	for event := range rec.events {
+		batch = append(batch[:0], event)
+	fill:
+		for len(batch) < MAX_BATCH_SIZE {
+			select {
+			case next, ok := <-rec.events:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, next)
+			default:
+				break fill
+			}
+		}
+		rec.write(batch)
+	}
+}
+
+func (rec *Recorder) write(batch []Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), WRITE_TIMEOUT)
+	defer cancel()
+
+	if err := rec.store.Write(ctx, batch); err != nil {
+		log.Error("failed to write events", "count", len(batch), "err", err)
+	}
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gohst/internal/db"
+)
+
+const (
+	// DEFAULT_SEARCH_LIMIT is how many events Search returns without a limit
+	DEFAULT_SEARCH_LIMIT = 100
+	// MAX_SEARCH_LIMIT caps how many events one search returns
+	MAX_SEARCH_LIMIT = 1000
+)
+
+// Filter narrows an audit log search. Empty fields match everything.
+type Filter struct {
+	Type string
+	// UserID matches events where the user acted or was acted on
+	UserID string
+	IP     string
+	Since  time.Time
+	Limit  int
+}
+
+// DBStore keeps events in the audit_events table
+type DBStore struct {
+	db *sql.DB
+}
+
+// NewDBStore creates a database-backed store using the primary DB
+func NewDBStore() *DBStore {
+	primary := db.GetPrimaryDB()
+	if primary == nil {
+		log.Warn("no primary DB available for audit store")
+		return &DBStore{}
+	}
+	return &DBStore{db: primary.DB}
+}
+
+// Write inserts events in one statement
+func (s *DBStore) Write(ctx context.Context, events []Event) error {
+	if s.db == nil {
+		return fmt.Errorf("database not available")
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	const columns = 9
+	placeholders := make([]string, 0, len(events))
+	args := make([]any, 0, len(events)*columns)
+	for i, event := range events {
+		metadata, err := json.Marshal(event.Metadata)
+		if err != nil || event.Metadata == nil {
+			metadata = []byte("{}")
+		}
+
+		n := i * columns
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9))
+		args = append(args,
+			event.Type,
+			event.ActorID,
+			event.UserID,
+			event.ImpersonatorID,
+			event.IP,
+			event.UserAgent,
+			event.RequestID,
+			metadata,
+			event.CreatedAt,
+		)
+	}
+
+	query := `
+		INSERT INTO audit_events (event_type, actor_id, user_id, impersonator_id, ip_address, user_agent, request_id, metadata, created_at)
+		VALUES ` + strings.Join(placeholders, ", ")
+	_, err := s.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// Search returns matching events, newest first
+func (s *DBStore) Search(ctx context.Context, filter Filter) ([]Event, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var where []string
+	var args []any
+	add := func(clause string, value any) {
+		args = append(args, value)
+		where = append(where, strings.ReplaceAll(clause, "?", fmt.Sprintf("$%d", len(args))))
+	}
+	if filter.Type != "" {
+		add("event_type = ?", filter.Type)
+	}
+	if filter.UserID != "" {
+		add("(actor_id = ? OR user_id = ?)", filter.UserID)
+	}
+	if filter.IP != "" {
+		add("ip_address = ?", filter.IP)
+	}
+	if !filter.Since.IsZero() {
+		add("created_at >= ?", filter.Since)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DEFAULT_SEARCH_LIMIT
+	}
+	limit = min(limit, MAX_SEARCH_LIMIT)
+
+	query := `
+		SELECT id, event_type, actor_id, user_id, impersonator_id, ip_address, user_agent, request_id, metadata, created_at
+		FROM audit_events`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT %d", limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var event Event
+		var metadata []byte
+		if err := rows.Scan(
+			&event.ID,
+			&event.Type,
+			&event.ActorID,
+			&event.UserID,
+			&event.ImpersonatorID,
+			&event.IP,
+			&event.UserAgent,
+			&event.RequestID,
+			&metadata,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &event.Metadata); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -1,21 +1,51 @@
 package auth
 
 import (
+	"gohst/internal/logger"
 	"gohst/internal/session"
 )
 
 const AuthKey = "_gohst_auth_"
 
+var log = logger.Component("auth")
+
 // AuthDataProvider is an interface for providing authentication data
 type AuthDataProvider interface {
 	Data() any
 }
 
+// UserIDProvider exposes the user ID that sessions are indexed under, which
+// lets a user's sessions be listed and revoked across devices.
+type UserIDProvider interface {
+	GetUserID() string
+}
+
+// VerifiedProvider is implemented by auth data that tracks whether the
+// user's email address has been verified.
+type VerifiedProvider interface {
+	IsEmailVerified() bool
+}
+
+// TwoFactorProvider is implemented by auth data for users who must enroll in
+// two-factor authentication before using the app.
+type TwoFactorProvider interface {
+	RequiresTwoFactorSetup() bool
+}
+
 // RoleProvider exposes a role name for authorization checks.
 type RoleProvider interface {
 	RoleName() string
 }
 
+func init() {
+	session.SetUserResolver(AuthKey, func(val any) string {
+		if provider, ok := val.(UserIDProvider); ok {
+			return provider.GetUserID()
+		}
+		return ""
+	})
+}
+
 // GetAuthData retrieves auth data from the session
 // Auth data must implement AuthDataProvider interface
 func GetAuthData(sess *session.Session) any {
@@ -38,7 +68,29 @@ func IsAuthenticated(sess *session.Session) bool {
 	return GetAuthData(sess) != nil
 }
 
-// Logout completely clears the session for security
+// IsVerified reports whether the authenticated user has verified their email.
+// Auth data that does not implement VerifiedProvider counts as verified.
+func IsVerified(sess *session.Session) bool {
+	data := GetAuthData(sess)
+	if data == nil {
+		return false
+	}
+	if provider, ok := data.(VerifiedProvider); ok {
+		return provider.IsEmailVerified()
+	}
+	return true
+}
+
+// NeedsTwoFactorSetup reports whether the authenticated user must enroll in
+// two-factor authentication first
+func NeedsTwoFactorSetup(sess *session.Session) bool {
+	provider, ok := GetAuthData(sess).(TwoFactorProvider)
+	return ok && provider.RequiresTwoFactorSetup()
+}
+
+// Logout completely clears the session for security, along with the
+// remember-me token and cookie of this device
 func Logout(sess *session.Session) {
+	forgetRemembered(sess)
 	sess.RegenerateNew()
 }
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"gohst/internal/session"
+)
+
+// abilitiesKey holds the abilities of the access token that authenticated an
+// ephemeral session. Browser sessions never have it.
+const abilitiesKey = "_gohst_token_abilities_"
+
+// ABILITY_ALL grants every ability.
+const ABILITY_ALL = "*"
+
+var (
+	// ErrInvalidAccessToken is returned for unknown, revoked or expired tokens.
+	ErrInvalidAccessToken = errors.New("auth: invalid or expired access token")
+	// ErrTokenAuthDisabled is returned when no TokenAuthenticator is registered.
+	ErrTokenAuthDisabled = errors.New("auth: access tokens are not configured")
+)
+
+// TokenAuth is the result of authenticating an access token: the same auth
+// data a browser login stores, plus the abilities the token was granted.
+type TokenAuth struct {
+	Data      AuthDataProvider
+	Abilities []string
+}
+
+// TokenAuthenticator resolves a presented access token. It returns
+// ErrInvalidAccessToken when the token must be refused.
+type TokenAuthenticator func(ctx context.Context, token string) (*TokenAuth, error)
+
+var tokenAuthenticator TokenAuthenticator
+
+// SetTokenAuthenticator enables bearer-token authentication. The app
+// provides it, since access tokens live in its database.
+func SetTokenAuthenticator(fn TokenAuthenticator) {
+	tokenAuthenticator = fn
+}
+
+// BearerToken returns the token of an "Authorization: Bearer" header, if any
+func BearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if len(h) > len(prefix) && strings.EqualFold(h[:len(prefix)], prefix) {
+		return strings.TrimSpace(h[len(prefix):])
+	}
+	return ""
+}
+
+// AuthenticateToken resolves an access token into an ephemeral session
+// holding its auth data, so GetAuthData, IsAuthenticated and the auth
+// middleware work for API requests exactly as they do for browsers.
+func AuthenticateToken(ctx context.Context, token string) (*session.Session, error) {
+	if tokenAuthenticator == nil {
+		return nil, ErrTokenAuthDisabled
+	}
+	if token == "" {
+		return nil, ErrInvalidAccessToken
+	}
+
+	result, err := tokenAuthenticator(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || result.Data == nil {
+		return nil, ErrInvalidAccessToken
+	}
+
+	sess := session.NewEphemeral()
+	if err := sess.Set(AuthKey, result.Data); err != nil {
+		return nil, err
+	}
+	abilities := result.Abilities
+	if abilities == nil {
+		abilities = []string{}
+	}
+	if err := sess.Set(abilitiesKey, abilities); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// IsTokenAuthenticated reports whether sess was authenticated by an access
+// token rather than a browser login
+func IsTokenAuthenticated(sess *session.Session) bool {
+	_, ok := sess.Get(abilitiesKey)
+	return ok
+}
+
+// TokenCan reports whether the request may use ability. Access tokens need
+// the ability granted; browser sessions of authenticated users have every
+// ability, since abilities only narrow what a token can do.
+func TokenCan(sess *session.Session, ability string) bool {
+	if !IsAuthenticated(sess) {
+		return false
+	}
+	val, ok := sess.Get(abilitiesKey)
+	if !ok {
+		return true
+	}
+	granted, _ := val.([]string)
+	return AbilityAllows(granted, ability)
+}
+
+// AbilityAllows reports whether the granted abilities cover ability.
+// "*" grants everything and "posts:*" grants every "posts:" ability.
+func AbilityAllows(granted []string, ability string) bool {
+	for _, g := range granted {
+		if g == ABILITY_ALL || g == ability {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(g, "*"); ok && strings.HasPrefix(ability, prefix) {
+			return true
+		}
+	}
+	return false
+}
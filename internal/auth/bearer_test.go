@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gohst/internal/session"
+)
+
+func TestAbilityAllows(t *testing.T) {
+	tests := []struct {
+		granted []string
+		ability string
+		want    bool
+	}{
+		{[]string{"posts:write"}, "posts:write", true},
+		{[]string{"posts:read"}, "posts:write", false},
+		{[]string{"posts:*"}, "posts:write", true},
+		{[]string{"posts:*"}, "users:read", false},
+		{[]string{"*"}, "anything", true},
+		{nil, "posts:read", false},
+	}
+	for _, tt := range tests {
+		if got := AbilityAllows(tt.granted, tt.ability); got != tt.want {
+			t.Errorf("AbilityAllows(%v, %q) = %v, want %v", tt.granted, tt.ability, got, tt.want)
+		}
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	if got := BearerToken(req); got != "" {
+		t.Fatalf("expected no token, got %q", got)
+	}
+	req.Header.Set("Authorization", "bearer  abc123 ")
+	if got := BearerToken(req); got != "abc123" {
+		t.Fatalf("expected abc123, got %q", got)
+	}
+	req.Header.Set("Authorization", "Basic abc123")
+	if got := BearerToken(req); got != "" {
+		t.Fatalf("expected Basic auth to be ignored, got %q", got)
+	}
+}
+
+func TestAuthenticateToken(t *testing.T) {
+	SetTokenAuthenticator(func(ctx context.Context, token string) (*TokenAuth, error) {
+		if token != "good" {
+			return nil, ErrInvalidAccessToken
+		}
+		return &TokenAuth{Data: &testAuthData{UserID: "7"}, Abilities: []string{"posts:read"}}, nil
+	})
+	t.Cleanup(func() { SetTokenAuthenticator(nil) })
+
+	if _, err := AuthenticateToken(context.Background(), "bad"); !errors.Is(err, ErrInvalidAccessToken) {
+		t.Fatalf("expected ErrInvalidAccessToken, got %v", err)
+	}
+
+	sess, err := AuthenticateToken(context.Background(), "good")
+	if err != nil {
+		t.Fatalf("AuthenticateToken: %v", err)
+	}
+	if !sess.Ephemeral() {
+		t.Fatal("token sessions must be ephemeral")
+	}
+	if !IsAuthenticated(sess) || sess.UserID() != "7" {
+		t.Fatal("expected the token's auth data in the session")
+	}
+	if !IsTokenAuthenticated(sess) {
+		t.Fatal("expected the session to be marked as token-authenticated")
+	}
+	if !TokenCan(sess, "posts:read") || TokenCan(sess, "posts:write") {
+		t.Fatal("abilities must be limited to those granted")
+	}
+	if err := sess.Flush(); err != nil {
+		t.Fatalf("flushing an ephemeral session should be a no-op, got %v", err)
+	}
+}
+
+func TestTokenCan_BrowserSession(t *testing.T) {
+	_, sm := setupRemember(t)
+	cookie, err := sm.Seed(func(sess *session.Session) {
+		sess.Set(AuthKey, &testAuthData{UserID: "7"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var can bool
+	handler := sm.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		can = TokenCan(session.FromContext(r.Context()), "posts:write")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !can {
+		t.Fatal("browser sessions are not limited by abilities")
+	}
+}
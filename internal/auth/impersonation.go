@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"encoding/gob"
+	"errors"
+	"time"
+
+	"gohst/internal/session"
+)
+
+var (
+	// ErrAlreadyImpersonating is returned when impersonating from inside an
+	// impersonation; stop the current one first.
+	ErrAlreadyImpersonating = errors.New("already impersonating a user")
+	// ErrNotImpersonating is returned when stopping without an impersonation.
+	ErrNotImpersonating = errors.New("not impersonating a user")
+)
+
+// Impersonation is stored in the session while a user views the app as
+// someone else. Impersonator is the impersonating user's own auth data,
+// restored when the impersonation stops.
+type Impersonation struct {
+	Impersonator AuthDataProvider
+	StartedAt    time.Time
+}
+
+func init() {
+	gob.Register(&Impersonation{})
+}
+
+var impersonationKey = session.NewKey[*Impersonation]("_gohst_impersonation_")
+
+// Impersonate logs the session in as target, keeping the current auth data
+// to return to. Who may impersonate whom is up to the caller.
+func Impersonate(sess *session.Session, target AuthDataProvider) error {
+	if IsImpersonating(sess) {
+		return ErrAlreadyImpersonating
+	}
+	current, ok := sess.Get(AuthKey)
+	impersonator, isProvider := current.(AuthDataProvider)
+	if !ok || !isProvider {
+		return errors.New("impersonating requires a logged-in user")
+	}
+
+	sess.Regenerate()
+	ForgetPermissions(sess)
+	if err := impersonationKey.Set(sess, &Impersonation{Impersonator: impersonator, StartedAt: time.Now()}); err != nil {
+		return err
+	}
+	return sess.Set(AuthKey, target)
+}
+
+// StopImpersonating restores the impersonating user's auth data and returns
+// the impersonation that ended
+func StopImpersonating(sess *session.Session) (*Impersonation, error) {
+	impersonation, ok := CurrentImpersonation(sess)
+	if !ok {
+		return nil, ErrNotImpersonating
+	}
+
+	sess.Regenerate()
+	ForgetPermissions(sess)
+	impersonationKey.Remove(sess)
+	if err := sess.Set(AuthKey, impersonation.Impersonator); err != nil {
+		return nil, err
+	}
+	return impersonation, nil
+}
+
+// CurrentImpersonation returns the impersonation in progress, if any
+func CurrentImpersonation(sess *session.Session) (*Impersonation, bool) {
+	impersonation, ok := impersonationKey.Get(sess)
+	if !ok || impersonation == nil || impersonation.Impersonator == nil {
+		return nil, false
+	}
+	return impersonation, true
+}
+
+// IsImpersonating reports whether the session is viewing the app as
+// another user
+func IsImpersonating(sess *session.Session) bool {
+	_, ok := CurrentImpersonation(sess)
+	return ok
+}
+
+// Impersonator returns the auth data of the user doing the impersonating,
+// or nil when the session is not impersonating
+func Impersonator(sess *session.Session) any {
+	impersonation, ok := CurrentImpersonation(sess)
+	if !ok {
+		return nil
+	}
+	return impersonation.Impersonator.Data()
+}
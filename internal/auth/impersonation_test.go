@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gohst/internal/session"
+)
+
+func TestImpersonate(t *testing.T) {
+	countingLoader(t, []string{"posts.view"})
+	admin := &testRoleData{UserID: "1", Role: "admin"}
+	target := &testRoleData{UserID: "2", Role: "user"}
+
+	withSession(t, admin, func(sess *session.Session) {
+		Permissions(context.Background(), sess)
+
+		if err := Impersonate(sess, target); err != nil {
+			t.Fatalf("Impersonate: %v", err)
+		}
+		if got := GetAuthData(sess); got != target {
+			t.Fatalf("auth data = %v, want the target", got)
+		}
+		if !IsImpersonating(sess) || Impersonator(sess) != admin {
+			t.Fatal("expected the admin to be recorded as impersonator")
+		}
+		if _, ok := permissionsKey.Get(sess); ok {
+			t.Fatal("expected the admin's cached permissions to be forgotten")
+		}
+		if err := Impersonate(sess, &testRoleData{UserID: "3"}); !errors.Is(err, ErrAlreadyImpersonating) {
+			t.Fatalf("expected ErrAlreadyImpersonating, got %v", err)
+		}
+
+		impersonation, err := StopImpersonating(sess)
+		if err != nil {
+			t.Fatalf("StopImpersonating: %v", err)
+		}
+		if impersonation.Impersonator != admin || GetAuthData(sess) != admin {
+			t.Fatal("expected the admin's auth data to be restored")
+		}
+		if IsImpersonating(sess) || Impersonator(sess) != nil {
+			t.Fatal("expected the impersonation to have ended")
+		}
+		if _, err := StopImpersonating(sess); !errors.Is(err, ErrNotImpersonating) {
+			t.Fatalf("expected ErrNotImpersonating, got %v", err)
+		}
+	})
+}
+
+func TestImpersonate_RequiresLogin(t *testing.T) {
+	withSession(t, nil, func(sess *session.Session) {
+		if err := Impersonate(sess, &testRoleData{UserID: "2"}); err == nil {
+			t.Fatal("expected guests to be refused")
+		}
+		if IsAuthenticated(sess) {
+			t.Fatal("expected the guest to stay logged out")
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gohst/internal/config"
+	"gohst/internal/session"
+)
+
+// MAX_INTENDED_URL_LENGTH caps the URLs remembered for after login
+const MAX_INTENDED_URL_LENGTH = 2048
+
+var intendedURLKey = session.NewKey[string]("_gohst_intended_url_")
+
+// LoginPath is where guests are sent to log in (AUTH_LOGIN_PATH)
+func LoginPath() string {
+	if config.Auth != nil && config.Auth.LoginPath != "" {
+		return config.Auth.LoginPath
+	}
+	return config.AUTH_LOGIN_PATH_DEFAULT
+}
+
+// HomePath is where users land after logging in when no page was asked for
+// (AUTH_HOME_PATH)
+func HomePath() string {
+	if config.Auth != nil && config.Auth.HomePath != "" {
+		return config.Auth.HomePath
+	}
+	return config.AUTH_HOME_PATH_DEFAULT
+}
+
+// GuestRedirectPath is where logged-in users visiting guest-only pages are
+// sent (AUTH_GUEST_REDIRECT_PATH, defaulting to the home path)
+func GuestRedirectPath() string {
+	if config.Auth != nil && config.Auth.GuestRedirectPath != "" {
+		return config.Auth.GuestRedirectPath
+	}
+	return HomePath()
+}
+
+// RememberIntendedURL keeps the page a guest asked for, so the login can
+// return there. Only GET requests are remembered: replaying a form post
+// after login would be surprising at best.
+func RememberIntendedURL(sess *session.Session, r *http.Request) {
+	if sess == nil || r.Method != http.MethodGet {
+		return
+	}
+	// RequestURI is the path before any StripPrefix, which is what the
+	// browser has to be sent back to
+	target := r.RequestURI
+	if target == "" {
+		target = r.URL.RequestURI()
+	}
+	if IsSafeRedirect(target) {
+		intendedURLKey.Set(sess, target)
+	}
+}
+
+// PullIntendedURL returns the page remembered before login and forgets it,
+// or the home path when there is none
+func PullIntendedURL(sess *session.Session) string {
+	target, ok := intendedURLKey.Get(sess)
+	intendedURLKey.Remove(sess)
+	if !ok || !IsSafeRedirect(target) {
+		return HomePath()
+	}
+	return target
+}
+
+// IsSafeRedirect reports whether target is a relative path on this site.
+// Absolute URLs, scheme-relative URLs ("//evil.example") and paths a browser
+// would read as one ("/\evil.example") are refused, which keeps redirects
+// from being used to send users elsewhere.
+func IsSafeRedirect(target string) bool {
+	if target == "" || len(target) > MAX_INTENDED_URL_LENGTH || target[0] != '/' {
+		return false
+	}
+	if strings.HasPrefix(target, "//") || strings.ContainsRune(target, '\\') {
+		return false
+	}
+	for _, c := range target {
+		if c < 0x20 || c == 0x7f {
+			return false
+		}
+	}
+
+	u, err := url.Parse(target)
+	return err == nil && u.Scheme == "" && u.Host == "" && u.User == nil && u.Opaque == ""
+}
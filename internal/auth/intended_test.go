@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gohst/internal/config"
+	"gohst/internal/session"
+)
+
+func TestIsSafeRedirect(t *testing.T) {
+	for target, want := range map[string]bool{
+		"/":                       true,
+		"/account/tokens":         true,
+		"/posts?page=2#comments":  true,
+		"/search?q=http://x.test": true,
+		"":                        false,
+		"account":                 false,
+		"https://evil.example":    false,
+		"//evil.example/path":     false,
+		"/\\evil.example":         false,
+		"/\t/evil.example":        false,
+		"/ok\r\nSet-Cookie: x=1":  false,
+		"javascript:alert(1)":     false,
+		"http:/evil.example":      false,
+		"\\\\evil.example":        false,
+	} {
+		if got := IsSafeRedirect(target); got != want {
+			t.Errorf("IsSafeRedirect(%q) = %v, want %v", target, got, want)
+		}
+	}
+}
+
+func TestIntendedURL(t *testing.T) {
+	previous := config.Auth
+	config.Auth = &config.AuthConfig{HomePath: "/home"}
+	t.Cleanup(func() { config.Auth = previous })
+
+	withSession(t, nil, func(sess *session.Session) {
+		if got := PullIntendedURL(sess); got != "/home" {
+			t.Fatalf("expected the home path without an intended URL, got %q", got)
+		}
+
+		// The original URI is kept, not the path left after StripPrefix
+		r := httptest.NewRequest(http.MethodGet, "/account/tokens?tab=new", nil)
+		r.URL.Path = "/tokens"
+		RememberIntendedURL(sess, r)
+		if got := PullIntendedURL(sess); got != "/account/tokens?tab=new" {
+			t.Fatalf("PullIntendedURL = %q", got)
+		}
+		if got := PullIntendedURL(sess); got != "/home" {
+			t.Fatalf("expected the intended URL to be used once, got %q", got)
+		}
+
+		RememberIntendedURL(sess, httptest.NewRequest(http.MethodPost, "/account/tokens", nil))
+		if got := PullIntendedURL(sess); got != "/home" {
+			t.Fatalf("expected POST requests not to be remembered, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"time"
+
+	"gohst/internal/config"
+)
+
+// LockoutPolicy decides when repeated failed logins lock an account and for
+// how long. Each lockout in a row lasts twice as long as the one before.
+type LockoutPolicy struct {
+	// Threshold is the number of failed logins that locks the account
+	Threshold int
+	// Base is the length of the first lockout
+	Base time.Duration
+	// Max caps the length of any lockout. When it is not positive the
+	// default of AUTH_LOCKOUT_MAX_MINUTES_DEFAULT applies, so doubling can
+	// never overflow.
+	Max time.Duration
+}
+
+// DefaultLockoutPolicy returns the policy configured through the
+// AUTH_LOCKOUT_* settings
+func DefaultLockoutPolicy() LockoutPolicy {
+	threshold := config.AUTH_LOCKOUT_THRESHOLD_DEFAULT
+	base := config.AUTH_LOCKOUT_MINUTES_DEFAULT
+	max := config.AUTH_LOCKOUT_MAX_MINUTES_DEFAULT
+	if config.Auth != nil {
+		threshold = config.Auth.LockoutThreshold
+		base = config.Auth.LockoutMinutes
+		max = config.Auth.LockoutMaxMinutes
+	}
+	return LockoutPolicy{
+		Threshold: threshold,
+		Base:      time.Duration(base) * time.Minute,
+		Max:       time.Duration(max) * time.Minute,
+	}
+}
+
+// Enabled reports whether accounts can be locked at all
+func (p LockoutPolicy) Enabled() bool {
+	return p.Threshold > 0 && p.Base > 0
+}
+
+// Exceeded reports whether failures failed logins in a row lock the account
+func (p LockoutPolicy) Exceeded(failures int) bool {
+	return p.Enabled() && failures >= p.Threshold
+}
+
+// Ceiling returns the longest a lockout can last, which is also how long
+// failed logins are remembered
+func (p LockoutPolicy) Ceiling() time.Duration {
+	if p.Max > 0 {
+		return p.Max
+	}
+	return time.Duration(config.AUTH_LOCKOUT_MAX_MINUTES_DEFAULT) * time.Minute
+}
+
+// Duration returns how long the given lockout in a row lasts, counting the
+// first lockout as 1
+func (p LockoutPolicy) Duration(lockout int) time.Duration {
+	if lockout < 1 {
+		lockout = 1
+	}
+	ceiling := p.Ceiling()
+	d := p.Base
+	for i := 1; i < lockout && d < ceiling; i++ {
+		d *= 2
+	}
+	return min(d, ceiling)
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"gohst/internal/config"
+)
+
+func TestLockoutPolicy_Duration(t *testing.T) {
+	p := LockoutPolicy{Threshold: 5, Base: 15 * time.Minute, Max: 2 * time.Hour}
+
+	for lockout, want := range map[int]time.Duration{
+		0:  15 * time.Minute,
+		1:  15 * time.Minute,
+		2:  30 * time.Minute,
+		3:  time.Hour,
+		4:  2 * time.Hour,
+		5:  2 * time.Hour,
+		60: 2 * time.Hour,
+	} {
+		if got := p.Duration(lockout); got != want {
+			t.Errorf("Duration(%d) = %v, want %v", lockout, got, want)
+		}
+	}
+}
+
+func TestLockoutPolicy_DurationWithoutMax(t *testing.T) {
+	p := LockoutPolicy{Threshold: 5, Base: 15 * time.Minute}
+	ceiling := time.Duration(config.AUTH_LOCKOUT_MAX_MINUTES_DEFAULT) * time.Minute
+
+	for _, lockout := range []int{10, 40, 64, 1000} {
+		if got := p.Duration(lockout); got != ceiling {
+			t.Errorf("Duration(%d) = %v, want the default ceiling %v", lockout, got, ceiling)
+		}
+	}
+}
+
+func TestLockoutPolicy_Exceeded(t *testing.T) {
+	p := LockoutPolicy{Threshold: 3, Base: time.Minute}
+	if p.Exceeded(2) || !p.Exceeded(3) || !p.Exceeded(4) {
+		t.Fatal("expected the account to lock at the threshold")
+	}
+
+	disabled := LockoutPolicy{Threshold: 0, Base: time.Minute}
+	if disabled.Exceeded(100) {
+		t.Fatal("a zero threshold disables lockouts")
+	}
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"encoding/gob"
+	"time"
+
+	"gohst/internal/config"
+	"gohst/internal/session"
+)
+
+// PermissionProvider is implemented by auth data that carries the user's
+// permissions itself. It takes precedence over the PermissionLoader.
+type PermissionProvider interface {
+	Permissions() []string
+}
+
+// RolesProvider exposes every role a user has, including the roles their
+// role inherits from, so role checks respect inheritance.
+type RolesProvider interface {
+	RoleNames() []string
+}
+
+// PermissionLoader returns the permissions of a user. The app provides it,
+// since roles and permissions live in its database.
+type PermissionLoader func(ctx context.Context, userID string) ([]string, error)
+
+// permissionCache holds a user's permissions in their session so they are
+// not loaded on every request
+type permissionCache struct {
+	UserID      string
+	Permissions []string
+	LoadedAt    time.Time
+}
+
+var permissionsKey = session.NewKey[*permissionCache]("_gohst_permissions_")
+
+var permissionLoader PermissionLoader
+
+func init() {
+	gob.Register(&permissionCache{})
+}
+
+// SetPermissionLoader enables permission checks
+func SetPermissionLoader(fn PermissionLoader) {
+	permissionLoader = fn
+}
+
+// PermissionCacheTTL returns how long loaded permissions are reused. Zero
+// loads them on every request.
+func PermissionCacheTTL() time.Duration {
+	minutes := config.AUTH_PERMISSION_CACHE_TTL_DEFAULT
+	if config.Auth != nil {
+		minutes = config.Auth.PermissionCacheTTL
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// Permissions returns the permissions of the authenticated user, or nil for
+// guests. Loaded permissions are cached in the session for
+// PermissionCacheTTL and reloaded when another user logs in.
+func Permissions(ctx context.Context, sess *session.Session) ([]string, error) {
+	if sess == nil {
+		return nil, nil
+	}
+	data := GetAuthData(sess)
+	if data == nil {
+		return nil, nil
+	}
+	if provider, ok := data.(PermissionProvider); ok {
+		return provider.Permissions(), nil
+	}
+
+	provider, ok := data.(UserIDProvider)
+	if !ok || provider.GetUserID() == "" || permissionLoader == nil {
+		return nil, nil
+	}
+	userID := provider.GetUserID()
+
+	ttl := PermissionCacheTTL()
+	if cached, ok := permissionsKey.Get(sess); ok && cached.UserID == userID && time.Since(cached.LoadedAt) < ttl {
+		return cached.Permissions, nil
+	}
+
+	perms, err := permissionLoader(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if perms == nil {
+		perms = []string{}
+	}
+	if ttl > 0 {
+		if err := permissionsKey.Set(sess, &permissionCache{UserID: userID, Permissions: perms, LoadedAt: time.Now()}); err != nil {
+			log.ErrorContext(ctx, "caching permissions", "err", err)
+		}
+	}
+	return perms, nil
+}
+
+// Can reports whether the authenticated user has the permission. "*" grants
+// every permission and "users.*" every "users." permission. Permissions that
+// cannot be loaded are denied.
+func Can(ctx context.Context, sess *session.Session, permission string) bool {
+	perms, err := Permissions(ctx, sess)
+	if err != nil {
+		log.ErrorContext(ctx, "loading permissions", "err", err)
+		return false
+	}
+	return AbilityAllows(perms, permission)
+}
+
+// ForgetPermissions drops the cached permissions, e.g. after the user's
+// role changes, so the next check loads them again
+func ForgetPermissions(sess *session.Session) {
+	permissionsKey.Remove(sess)
+}
+
+// HasRole reports whether the authenticated user has one of the roles,
+// directly or through inheritance when the auth data is a RolesProvider
+func HasRole(sess *session.Session, roles ...string) bool {
+	data := GetAuthData(sess)
+	if data == nil {
+		return false
+	}
+
+	var held []string
+	if provider, ok := data.(RolesProvider); ok {
+		held = provider.RoleNames()
+	} else if provider, ok := data.(RoleProvider); ok && provider.RoleName() != "" {
+		held = []string{provider.RoleName()}
+	}
+
+	for _, h := range held {
+		for _, role := range roles {
+			if h == role {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gohst/internal/config"
+	"gohst/internal/session"
+)
+
+type testRoleData struct {
+	UserID string
+	Role   string
+	Roles  []string
+}
+
+func (d *testRoleData) Data() any           { return d }
+func (d *testRoleData) GetUserID() string   { return d.UserID }
+func (d *testRoleData) RoleName() string    { return d.Role }
+func (d *testRoleData) RoleNames() []string { return d.Roles }
+
+func init() {
+	gob.Register(&testRoleData{})
+}
+
+// withSession runs fn with a fresh session holding the auth data
+func withSession(t *testing.T, data AuthDataProvider, fn func(sess *session.Session)) {
+	t.Helper()
+	config.RegisterAppConfig(testAppConfig{})
+	config.Session = &config.SessionConfig{Length: config.SESSION_LENGTH_DEFAULT, Name: "_test_session"}
+
+	memory, storeType := session.NewMemorySessionManager("_test_session")
+	sm := session.NewSessionManagerWithStore(memory, storeType, "_test_session")
+	handler := sm.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess := session.FromContext(r.Context())
+		if data != nil {
+			if err := sess.Set(AuthKey, data); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+		}
+		fn(sess)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+// countingLoader grants perms and counts how often it is called
+func countingLoader(t *testing.T, perms []string) *int {
+	t.Helper()
+	calls := 0
+	SetPermissionLoader(func(ctx context.Context, userID string) ([]string, error) {
+		calls++
+		return perms, nil
+	})
+	t.Cleanup(func() { SetPermissionLoader(nil) })
+	return &calls
+}
+
+func TestCan(t *testing.T) {
+	countingLoader(t, []string{"posts.create", "users.*"})
+	ctx := context.Background()
+
+	withSession(t, &testAuthData{UserID: "7"}, func(sess *session.Session) {
+		for perm, want := range map[string]bool{
+			"posts.create": true,
+			"posts.delete": false,
+			"users.edit":   true,
+			"roles.manage": false,
+		} {
+			if got := Can(ctx, sess, perm); got != want {
+				t.Errorf("Can(%q) = %v, want %v", perm, got, want)
+			}
+		}
+	})
+
+	withSession(t, nil, func(sess *session.Session) {
+		if Can(ctx, sess, "posts.create") {
+			t.Error("guests must not have permissions")
+		}
+	})
+}
+
+func TestPermissions_CachedPerUser(t *testing.T) {
+	calls := countingLoader(t, []string{"posts.create"})
+	ctx := context.Background()
+
+	withSession(t, &testAuthData{UserID: "7"}, func(sess *session.Session) {
+		Can(ctx, sess, "posts.create")
+		Can(ctx, sess, "posts.edit")
+		if *calls != 1 {
+			t.Fatalf("expected permissions to be loaded once, got %d", *calls)
+		}
+
+		// Another user in the same session must not reuse the cache
+		sess.Set(AuthKey, &testAuthData{UserID: "8"})
+		Can(ctx, sess, "posts.create")
+		if *calls != 2 {
+			t.Fatalf("expected a reload for another user, got %d loads", *calls)
+		}
+
+		ForgetPermissions(sess)
+		Can(ctx, sess, "posts.create")
+		if *calls != 3 {
+			t.Fatalf("expected a reload after ForgetPermissions, got %d loads", *calls)
+		}
+	})
+}
+
+func TestCan_LoaderErrorDenies(t *testing.T) {
+	SetPermissionLoader(func(ctx context.Context, userID string) ([]string, error) {
+		return nil, errors.New("database down")
+	})
+	t.Cleanup(func() { SetPermissionLoader(nil) })
+
+	withSession(t, &testAuthData{UserID: "7"}, func(sess *session.Session) {
+		if Can(context.Background(), sess, "posts.create") {
+			t.Fatal("expected a failed load to deny")
+		}
+	})
+}
+
+func TestHasRole_Inheritance(t *testing.T) {
+	data := &testRoleData{UserID: "7", Role: "admin", Roles: []string{"admin", "manager", "user"}}
+	withSession(t, data, func(sess *session.Session) {
+		if !HasRole(sess, "manager") {
+			t.Error("admin inherits manager")
+		}
+		if !HasRole(sess, "editor", "user") {
+			t.Error("expected any listed role to match")
+		}
+		if HasRole(sess, "editor") {
+			t.Error("admin does not inherit editor")
+		}
+	})
+
+	withSession(t, nil, func(sess *session.Session) {
+		if HasRole(sess, "user") {
+			t.Error("guests have no roles")
+		}
+	})
+}
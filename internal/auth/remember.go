@@ -0,0 +1,274 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"gohst/internal/config"
+	"gohst/internal/session"
+)
+
+// REMEMBER_SELECTOR_BYTES is the size of the public half of a remember-me
+// token, used to look the token up.
+const REMEMBER_SELECTOR_BYTES = 12
+
+// rememberSelectorKey holds the selector of the token that logged the session
+// in, so Logout and session revocation can delete it
+const rememberSelectorKey = "_gohst_remember_"
+
+// ErrRememberTokenTheft is returned by Restore when a known selector arrives
+// with the wrong validator, a sign the cookie was copied and one copy already
+// used, rotating the validator the other copy still carries.
+var ErrRememberTokenTheft = errors.New("auth: remember token validator mismatch")
+
+// RememberToken is a persistent login token. The selector finds the stored
+// token; only a hash of the validator is kept, so a leaked table cannot be
+// turned into working cookies.
+type RememberToken struct {
+	UserID        string
+	Selector      string
+	ValidatorHash string
+	ExpiresAt     time.Time
+}
+
+// RememberStore persists remember-me tokens. The app provides it, since the
+// framework does not own a database schema.
+type RememberStore interface {
+	// SaveRememberToken stores a new token
+	SaveRememberToken(ctx context.Context, token RememberToken) error
+	// FindRememberToken returns the token with the selector, or nil when
+	// there is none
+	FindRememberToken(ctx context.Context, selector string) (*RememberToken, error)
+	// RotateRememberToken replaces the validator hash of the token with the
+	// selector, but only while it is still oldHash, and reports whether it
+	// did
+	RotateRememberToken(ctx context.Context, selector string, oldHash string, newHash string) (bool, error)
+	// DeleteRememberToken removes the token with the selector
+	DeleteRememberToken(ctx context.Context, selector string) error
+	// DeleteRememberTokens removes every token of the user except the one
+	// with exceptSelector, which may be empty
+	DeleteRememberTokens(ctx context.Context, userID string, exceptSelector string) error
+}
+
+// RememberLoginFunc logs the user into sess when a remember-me token is
+// presented. It should refuse users who may no longer log in.
+type RememberLoginFunc func(ctx context.Context, sess *session.Session, userID string) error
+
+var (
+	rememberStore RememberStore
+	rememberLogin RememberLoginFunc
+	rememberNow   = time.Now
+)
+
+// SetRememberer enables remember-me logins with the given token store and
+// login function.
+func SetRememberer(store RememberStore, login RememberLoginFunc) {
+	rememberStore = store
+	rememberLogin = login
+}
+
+func init() {
+	session.SetRevokeHook(func(ctx context.Context, data *session.SessionData) {
+		if selector, ok := data.Values[rememberSelectorKey].(string); ok && rememberStore != nil {
+			if err := rememberStore.DeleteRememberToken(ctx, selector); err != nil {
+				log.ErrorContext(ctx, "deleting remember token of revoked session", "err", err)
+			}
+		}
+	})
+}
+
+// RememberCookieName returns the name of the remember-me cookie
+func RememberCookieName() string {
+	if config.Auth != nil && config.Auth.RememberCookie != "" {
+		return config.Auth.RememberCookie
+	}
+	return "_gohst_remember"
+}
+
+// RememberDuration returns how long a remember-me login lasts
+func RememberDuration() time.Duration {
+	days := config.AUTH_REMEMBER_DAYS_DEFAULT
+	if config.Auth != nil && config.Auth.RememberDays > 0 {
+		days = config.Auth.RememberDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// newRememberToken returns the cookie value for a new token and the token to store
+func newRememberToken(userID string, expiresAt time.Time) (string, RememberToken, error) {
+	b := make([]byte, REMEMBER_SELECTOR_BYTES)
+	if _, err := rand.Read(b); err != nil {
+		return "", RememberToken{}, err
+	}
+	selector := base64.RawURLEncoding.EncodeToString(b)
+
+	validator, hash, err := NewToken()
+	if err != nil {
+		return "", RememberToken{}, err
+	}
+
+	token := RememberToken{
+		UserID:        userID,
+		Selector:      selector,
+		ValidatorHash: hash,
+		ExpiresAt:     expiresAt,
+	}
+	return selector + ":" + validator, token, nil
+}
+
+// parseRememberCookie splits a cookie value into selector and validator
+func parseRememberCookie(value string) (selector string, validator string, ok bool) {
+	selector, validator, ok = strings.Cut(value, ":")
+	return selector, validator, ok && selector != "" && validator != ""
+}
+
+// Remember issues a remember-me token for the logged-in user of sess and
+// sets its cookie. Call it right after a successful login.
+func Remember(ctx context.Context, sess *session.Session) error {
+	if rememberStore == nil {
+		return errors.New("auth: remember me is not configured")
+	}
+	userID := sess.UserID()
+	if userID == "" {
+		return ErrNotAuthenticated
+	}
+	return issueRememberToken(ctx, sess, userID, rememberNow().Add(RememberDuration()))
+}
+
+func issueRememberToken(ctx context.Context, sess *session.Session, userID string, expiresAt time.Time) error {
+	value, token, err := newRememberToken(userID, expiresAt)
+	if err != nil {
+		return err
+	}
+	if err := rememberStore.SaveRememberToken(ctx, token); err != nil {
+		return err
+	}
+	return setRememberCookie(sess, token.Selector, value, expiresAt)
+}
+
+// setRememberCookie ties the token to sess, so logging out deletes it, and
+// sets the cookie carrying it
+func setRememberCookie(sess *session.Session, selector string, value string, expiresAt time.Time) error {
+	if err := sess.Set(rememberSelectorKey, selector); err != nil {
+		return err
+	}
+	sess.SetCookie(rememberCookie(value, expiresAt))
+	return nil
+}
+
+// Restore logs sess in from the remember-me cookie on r when the session is
+// not authenticated. Each validator works once: the token keeps its selector
+// and expiry but gets a new validator on every use. A known selector with the
+// wrong validator therefore means the cookie was copied and the other copy
+// already used, so every remember-me token of that user is revoked, their
+// sessions ended and ErrRememberTokenTheft returned.
+func Restore(sess *session.Session, r *http.Request) (bool, error) {
+	if rememberStore == nil || rememberLogin == nil || IsAuthenticated(sess) {
+		return false, nil
+	}
+	cookie, err := r.Cookie(RememberCookieName())
+	if err != nil {
+		return false, nil
+	}
+
+	ctx := r.Context()
+	selector, validator, ok := parseRememberCookie(cookie.Value)
+	if !ok {
+		forgetCookie(sess)
+		return false, nil
+	}
+
+	token, err := rememberStore.FindRememberToken(ctx, selector)
+	if err != nil {
+		return false, err
+	}
+	if token == nil {
+		forgetCookie(sess)
+		return false, nil
+	}
+
+	if !TokenMatches(validator, token.ValidatorHash) {
+		// Whoever used the other copy may be logged in already, so end
+		// their sessions along with every remember-me token
+		forgetCookie(sess)
+		if _, err := LogoutEverywhere(ctx, token.UserID); err != nil && !errors.Is(err, session.ErrStatelessStore) {
+			return false, err
+		}
+		return false, ErrRememberTokenTheft
+	}
+
+	if !rememberNow().Before(token.ExpiresAt) {
+		forgetCookie(sess)
+		return false, rememberStore.DeleteRememberToken(ctx, selector)
+	}
+
+	// Rotate before logging in, so this validator is spent even if the login
+	// fails. Losing the swap means a concurrent request with the same cookie
+	// rotated it first; its response carries the new cookie, so leave it be.
+	newValidator, newHash, err := NewToken()
+	if err != nil {
+		return false, err
+	}
+	rotated, err := rememberStore.RotateRememberToken(ctx, selector, token.ValidatorHash, newHash)
+	if err != nil || !rotated {
+		return false, err
+	}
+
+	if err := rememberLogin(ctx, sess, token.UserID); err != nil {
+		forgetCookie(sess)
+		if delErr := rememberStore.DeleteRememberToken(ctx, selector); delErr != nil {
+			log.ErrorContext(ctx, "deleting remember token", "err", delErr)
+		}
+		return false, err
+	}
+	if err := setRememberCookie(sess, selector, selector+":"+newValidator, token.ExpiresAt); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// forgetRemembered deletes the token that logged sess in and expires the cookie
+func forgetRemembered(sess *session.Session) {
+	if rememberStore == nil {
+		return
+	}
+	if val, ok := sess.Get(rememberSelectorKey); ok {
+		if selector, ok := val.(string); ok {
+			if err := rememberStore.DeleteRememberToken(context.Background(), selector); err != nil {
+				log.Error("deleting remember token", "err", err)
+			}
+		}
+	}
+	forgetCookie(sess)
+}
+
+// forgetCookie expires the remember-me cookie
+func forgetCookie(sess *session.Session) {
+	cookie := rememberCookie("", time.Unix(0, 0))
+	cookie.MaxAge = -1
+	sess.SetCookie(cookie)
+}
+
+// rememberCookie builds the remember-me cookie with the session cookie's flags
+func rememberCookie(value string, expires time.Time) *http.Cookie {
+	isProduction := config.GetAppConfig().IsProduction()
+
+	cookie := &http.Cookie{
+		Name:     RememberCookieName(),
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   isProduction,
+		SameSite: http.SameSiteLaxMode,
+		Expires:  expires,
+	}
+	if isProduction {
+		cookie.SameSite = http.SameSiteStrictMode
+	}
+	return cookie
+}
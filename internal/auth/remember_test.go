@@ -0,0 +1,322 @@
+package auth
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"gohst/internal/config"
+	"gohst/internal/session"
+)
+
+type testAppConfig struct{}
+
+func (testAppConfig) GetURL() string          { return "http://localhost:3030" }
+func (testAppConfig) GetDistPath() string     { return "static/dist" }
+func (testAppConfig) IsProduction() bool      { return false }
+func (testAppConfig) IsDevelopment() bool     { return false }
+func (testAppConfig) IsMaintenanceMode() bool { return false }
+
+type testAuthData struct {
+	UserID string
+}
+
+func (d *testAuthData) Data() any         { return d }
+func (d *testAuthData) GetUserID() string { return d.UserID }
+
+func init() {
+	gob.Register(&testAuthData{})
+}
+
+// memoryRememberStore is an in-memory RememberStore
+type memoryRememberStore struct {
+	mu     sync.Mutex
+	tokens map[string]RememberToken
+}
+
+func (m *memoryRememberStore) SaveRememberToken(ctx context.Context, token RememberToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens[token.Selector] = token
+	return nil
+}
+
+func (m *memoryRememberStore) FindRememberToken(ctx context.Context, selector string) (*RememberToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token, ok := m.tokens[selector]
+	if !ok {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+func (m *memoryRememberStore) RotateRememberToken(ctx context.Context, selector string, oldHash string, newHash string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token, ok := m.tokens[selector]
+	if !ok || token.ValidatorHash != oldHash {
+		return false, nil
+	}
+	token.ValidatorHash = newHash
+	m.tokens[selector] = token
+	return true, nil
+}
+
+func (m *memoryRememberStore) DeleteRememberToken(ctx context.Context, selector string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tokens, selector)
+	return nil
+}
+
+func (m *memoryRememberStore) DeleteRememberTokens(ctx context.Context, userID string, exceptSelector string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for selector, token := range m.tokens {
+		if token.UserID == userID && selector != exceptSelector {
+			delete(m.tokens, selector)
+		}
+	}
+	return nil
+}
+
+func (m *memoryRememberStore) forUser(userID string) []RememberToken {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var tokens []RememberToken
+	for _, token := range m.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// setupRemember installs an in-memory store and a login function that marks
+// the session as the given user
+func setupRemember(t *testing.T) (*memoryRememberStore, *session.SessionManager) {
+	t.Helper()
+	config.RegisterAppConfig(testAppConfig{})
+	config.Session = &config.SessionConfig{Length: config.SESSION_LENGTH_DEFAULT, Name: "_test_session"}
+
+	store := &memoryRememberStore{tokens: make(map[string]RememberToken)}
+	SetRememberer(store, func(ctx context.Context, sess *session.Session, userID string) error {
+		sess.Regenerate()
+		return sess.Set(AuthKey, &testAuthData{UserID: userID})
+	})
+	t.Cleanup(func() { SetRememberer(nil, nil) })
+
+	memory, storeType := session.NewMemorySessionManager("_test_session")
+	return store, session.NewSessionManagerWithStore(memory, storeType, "_test_session")
+}
+
+// storeToken saves a token for the user and returns its cookie value
+func storeToken(t *testing.T, store *memoryRememberStore, userID string, expiresAt time.Time) (string, RememberToken) {
+	t.Helper()
+	value, token, err := newRememberToken(userID, expiresAt)
+	if err != nil {
+		t.Fatalf("newRememberToken: %v", err)
+	}
+	store.SaveRememberToken(context.Background(), token)
+	return value, token
+}
+
+// restore runs Restore for a request carrying the remember-me cookie
+func restore(sm *session.SessionManager, cookieValue string) (bool, string, *httptest.ResponseRecorder, error) {
+	var (
+		ok     bool
+		err    error
+		userID string
+	)
+	handler := sm.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess := session.FromContext(r.Context())
+		ok, err = Restore(sess, r)
+		userID = sess.UserID()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: RememberCookieName(), Value: cookieValue})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return ok, userID, rec, err
+}
+
+func rememberCookieFrom(rec *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == RememberCookieName() {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestParseRememberCookie(t *testing.T) {
+	value, token, err := newRememberToken("7", time.Now())
+	if err != nil {
+		t.Fatalf("newRememberToken: %v", err)
+	}
+	selector, validator, ok := parseRememberCookie(value)
+	if !ok || selector != token.Selector {
+		t.Fatalf("expected selector %q, got %q (%v)", token.Selector, selector, ok)
+	}
+	if !TokenMatches(validator, token.ValidatorHash) {
+		t.Fatal("expected the validator to match the stored hash")
+	}
+
+	for _, bad := range []string{"", "nocolon", ":validator", "selector:"} {
+		if _, _, ok := parseRememberCookie(bad); ok {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestRestore_LogsInAndRotates(t *testing.T) {
+	store, sm := setupRemember(t)
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	value, old := storeToken(t, store, "7", expires)
+
+	ok, userID, rec, err := restore(sm, value)
+	if err != nil || !ok {
+		t.Fatalf("expected restore, got %v (%v)", ok, err)
+	}
+	if userID != "7" {
+		t.Fatalf("expected user 7, got %q", userID)
+	}
+
+	tokens := store.forUser("7")
+	if len(tokens) != 1 || tokens[0].Selector != old.Selector || tokens[0].ValidatorHash == old.ValidatorHash {
+		t.Fatalf("expected the validator to be rotated under the same selector, got %+v", tokens)
+	}
+	if !tokens[0].ExpiresAt.Equal(expires) {
+		t.Fatalf("rotation must keep the expiry, got %v", tokens[0].ExpiresAt)
+	}
+
+	cookie := rememberCookieFrom(rec)
+	if cookie == nil || cookie.Value == value {
+		t.Fatalf("expected a new remember cookie, got %+v", cookie)
+	}
+	if selector, validator, _ := parseRememberCookie(cookie.Value); selector != old.Selector || !TokenMatches(validator, tokens[0].ValidatorHash) {
+		t.Fatal("cookie does not carry the rotated validator")
+	}
+
+	// The rotated cookie works in turn
+	if ok, userID, _, err := restore(sm, cookie.Value); !ok || err != nil || userID != "7" {
+		t.Fatalf("expected the rotated cookie to log in, got %v (%v) as %q", ok, err, userID)
+	}
+}
+
+func TestRestore_ReplayedCookieRevokesAll(t *testing.T) {
+	store, sm := setupRemember(t)
+	original, _ := storeToken(t, store, "7", time.Now().Add(time.Hour))
+	storeToken(t, store, "7", time.Now().Add(time.Hour)) // another device
+
+	// The victim uses the cookie first, which rotates it
+	_, _, rec, err := restore(sm, original)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	rotated := rememberCookieFrom(rec)
+	if rotated == nil || rotated.Value == original {
+		t.Fatalf("expected a rotated cookie, got %+v", rotated)
+	}
+
+	// The thief replays the copy taken before rotation
+	ok, userID, rec, err := restore(sm, original)
+	if ok || userID != "" {
+		t.Fatal("a replayed cookie must not log in")
+	}
+	if !errors.Is(err, ErrRememberTokenTheft) {
+		t.Fatalf("expected ErrRememberTokenTheft, got %v", err)
+	}
+	if tokens := store.forUser("7"); len(tokens) != 0 {
+		t.Fatalf("expected every token of the user to be revoked, got %d", len(tokens))
+	}
+	if cookie := rememberCookieFrom(rec); cookie == nil || cookie.MaxAge >= 0 {
+		t.Fatalf("expected the cookie to be cleared, got %+v", cookie)
+	}
+	if ok, _, _, _ := restore(sm, rotated.Value); ok {
+		t.Fatal("the victim's rotated cookie must be revoked too")
+	}
+}
+
+func TestRestore_ValidatorMismatchRevokesAll(t *testing.T) {
+	store, sm := setupRemember(t)
+	value, _ := storeToken(t, store, "7", time.Now().Add(time.Hour))
+	storeToken(t, store, "7", time.Now().Add(time.Hour))
+	storeToken(t, store, "8", time.Now().Add(time.Hour))
+
+	selector, _, _ := parseRememberCookie(value)
+	ok, userID, rec, err := restore(sm, selector+":forged")
+	if ok || userID != "" {
+		t.Fatal("a forged validator must not log in")
+	}
+	if !errors.Is(err, ErrRememberTokenTheft) {
+		t.Fatalf("expected ErrRememberTokenTheft, got %v", err)
+	}
+	if tokens := store.forUser("7"); len(tokens) != 0 {
+		t.Fatalf("expected every token of the user to be revoked, got %d", len(tokens))
+	}
+	if tokens := store.forUser("8"); len(tokens) != 1 {
+		t.Fatal("other users' tokens must survive")
+	}
+	if cookie := rememberCookieFrom(rec); cookie == nil || cookie.MaxAge >= 0 {
+		t.Fatalf("expected the cookie to be cleared, got %+v", cookie)
+	}
+}
+
+func TestRestore_ExpiredToken(t *testing.T) {
+	store, sm := setupRemember(t)
+	value, _ := storeToken(t, store, "7", time.Now().Add(-time.Minute))
+
+	ok, userID, _, err := restore(sm, value)
+	if ok || err != nil || userID != "" {
+		t.Fatalf("expected no login, got %v (%v) as %q", ok, err, userID)
+	}
+	if tokens := store.forUser("7"); len(tokens) != 0 {
+		t.Fatal("expected the expired token to be deleted")
+	}
+}
+
+func TestLogout_ForgetsRememberToken(t *testing.T) {
+	store, sm := setupRemember(t)
+	value, _ := storeToken(t, store, "7", time.Now().Add(time.Hour))
+	_, _, rec, _ := restore(sm, value)
+	current := rememberCookieFrom(rec)
+	storeToken(t, store, "7", time.Now().Add(time.Hour)) // another device
+
+	var sessionCookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "_test_session" {
+			sessionCookie = c
+		}
+	}
+	if sessionCookie == nil {
+		t.Fatal("expected a session cookie")
+	}
+
+	handler := sm.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Logout(session.FromContext(r.Context()))
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.AddCookie(sessionCookie)
+	req.AddCookie(current)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	tokens := store.forUser("7")
+	if len(tokens) != 1 {
+		t.Fatalf("expected only the other device's token to remain, got %d", len(tokens))
+	}
+	if selector, _, _ := parseRememberCookie(current.Value); tokens[0].Selector == selector {
+		t.Fatal("expected this device's token to be deleted")
+	}
+	if cookie := rememberCookieFrom(rec); cookie == nil || cookie.MaxAge >= 0 {
+		t.Fatalf("expected the cookie to be cleared, got %+v", cookie)
+	}
+}
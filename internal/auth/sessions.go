@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"gohst/internal/session"
+)
+
+// ErrNotAuthenticated is returned by session management helpers for guests.
+var ErrNotAuthenticated = errors.New("auth: session is not authenticated")
+
+// Sessions lists every active session of the logged-in user, including the
+// current one, most recently active first.
+func Sessions(sess *session.Session) ([]session.SessionInfo, error) {
+	userID := sess.UserID()
+	if userID == "" {
+		return nil, ErrNotAuthenticated
+	}
+	return sess.Manager().UserSessions(context.Background(), userID, sess.ID())
+}
+
+// RevokeSession logs out one of the user's sessions by its handle.
+// The current session cannot be revoked this way; use Logout instead.
+func RevokeSession(sess *session.Session, handle string) error {
+	userID := sess.UserID()
+	if userID == "" {
+		return ErrNotAuthenticated
+	}
+	if handle == session.SessionHandle(sess.ID()) {
+		return errors.New("auth: use Logout to end the current session")
+	}
+	return sess.Manager().RevokeSession(context.Background(), userID, handle)
+}
+
+// LogoutOtherSessions logs the user out everywhere except the current
+// session, e.g. after a password change. Remember-me tokens other than the
+// current session's are revoked too. It returns how many sessions ended.
+func LogoutOtherSessions(sess *session.Session) (int, error) {
+	userID := sess.UserID()
+	if userID == "" {
+		return 0, ErrNotAuthenticated
+	}
+	ctx := context.Background()
+	if rememberStore != nil {
+		// Remembered devices without a live session would log straight back in
+		current, _ := sess.Get(rememberSelectorKey)
+		selector, _ := current.(string)
+		if err := rememberStore.DeleteRememberTokens(ctx, userID, selector); err != nil {
+			return 0, err
+		}
+	}
+	return sess.Manager().RevokeOtherSessions(ctx, userID, sess.ID())
+}
+
+// LogoutEverywhere ends every session and remember-me token of the user in
+// both the app and admin session managers, e.g. after a password reset.
+// It returns how many sessions ended.
+//
+// A manager on the cookie store keeps no server-side state, so its sessions
+// stay valid until they expire. The rest is still revoked and
+// session.ErrStatelessStore is returned so the caller can warn about it.
+func LogoutEverywhere(ctx context.Context, userID string) (int, error) {
+	if userID == "" {
+		return 0, ErrNotAuthenticated
+	}
+
+	if rememberStore != nil {
+		if err := rememberStore.DeleteRememberTokens(ctx, userID, ""); err != nil {
+			return 0, err
+		}
+	}
+
+	revoked := 0
+	var stateless error
+	for _, sm := range []*session.SessionManager{session.SM, session.SMAdmin} {
+		if sm == nil {
+			continue
+		}
+		n, err := sm.RevokeOtherSessions(ctx, userID, "")
+		if errors.Is(err, session.ErrStatelessStore) {
+			stateless = err
+			continue
+		}
+		if err != nil {
+			return revoked, err
+		}
+		revoked += n
+	}
+	return revoked, stateless
+}
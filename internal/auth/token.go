@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// TOKEN_BYTES is the amount of randomness in tokens sent to users.
+const TOKEN_BYTES = 32
+
+// NewToken returns a random URL-safe token for links sent to users, along
+// with the hash to store. Only the hash is persisted, so a leaked table
+// cannot be used to forge links.
+func NewToken() (token string, hash string, err error) {
+	b := make([]byte, TOKEN_BYTES)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the stored form of a token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// TokenMatches compares a presented token against a stored hash in constant time
+func TokenMatches(token string, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(hash)) == 1
+}
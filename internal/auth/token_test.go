@@ -0,0 +1,24 @@
+package auth
+
+import "testing"
+
+func TestNewToken(t *testing.T) {
+	token, hash, err := NewToken()
+	if err != nil {
+		t.Fatalf("NewToken: %v", err)
+	}
+	if token == hash {
+		t.Fatal("the stored hash must differ from the token")
+	}
+	if !TokenMatches(token, hash) {
+		t.Fatal("expected token to match its hash")
+	}
+	if TokenMatches(token+"x", hash) {
+		t.Fatal("expected a different token not to match")
+	}
+
+	other, _, _ := NewToken()
+	if other == token {
+		t.Fatal("expected tokens to be unique")
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+// AuditConfig controls the security audit log
+type AuditConfig struct {
+	// Enabled toggles recording audit events
+	Enabled bool
+
+	// BufferSize is how many events can wait to be written. Events recorded
+	// while the buffer is full are dropped and counted rather than blocking
+	// the request.
+	BufferSize int
+}
+
+const AUDIT_BUFFER_SIZE_DEFAULT = 1024
+
+var Audit *AuditConfig
+
+func initAudit() {
+	Audit = &AuditConfig{
+		Enabled:    GetEnv("AUDIT_ENABLED", true).(bool),
+		BufferSize: GetEnv("AUDIT_BUFFER_SIZE", AUDIT_BUFFER_SIZE_DEFAULT).(int),
+	}
+}
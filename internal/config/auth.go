@@ -0,0 +1,75 @@
+package config
+
+// AuthConfig holds authentication settings. Expiries are in minutes.
+// PasswordResetExpire is how long a reset link stays valid and
+// EmailVerifyExpire how long an email verification link does.
+// RequireAdminTwoFactor sends admins without two-factor authentication to
+// enroll before they can use the app; TwoFactorIssuer names the app in
+// authenticator apps. RememberDays is how long a "remember me" login lasts
+// and RememberCookie the cookie that carries it. PermissionCacheTTL is how
+// long a user's permissions are cached in their session, in minutes.
+// LockoutThreshold failed logins lock an account for LockoutMinutes, doubling
+// with each further lockout up to LockoutMaxMinutes. MagicLinkExpire is how
+// long an emailed login link stays valid. LoginPath is where guests are sent
+// to log in, HomePath where users land after logging in when no page was
+// asked for, and GuestRedirectPath where logged-in users visiting guest-only
+// pages are sent.
+type AuthConfig struct {
+	EmailVerifyExpire     int
+	GuestRedirectPath     string
+	HomePath              string
+	LockoutMaxMinutes     int
+	LockoutMinutes        int
+	LockoutThreshold      int
+	LoginPath             string
+	MagicLinkExpire       int
+	PasswordResetExpire   int
+	PermissionCacheTTL    int
+	RememberCookie        string
+	RememberDays          int
+	RequireAdminTwoFactor bool
+	TwoFactorIssuer       string
+}
+
+const AUTH_PASSWORD_RESET_EXPIRE_DEFAULT = 60
+
+const AUTH_EMAIL_VERIFY_EXPIRE_DEFAULT = 1440
+
+const AUTH_REMEMBER_DAYS_DEFAULT = 30
+
+const AUTH_PERMISSION_CACHE_TTL_DEFAULT = 5
+
+const AUTH_LOCKOUT_THRESHOLD_DEFAULT = 5
+
+const AUTH_LOCKOUT_MINUTES_DEFAULT = 15
+
+const AUTH_LOCKOUT_MAX_MINUTES_DEFAULT = 1440
+
+const AUTH_MAGIC_LINK_EXPIRE_DEFAULT = 15
+
+const AUTH_LOGIN_PATH_DEFAULT = "/auth/login"
+
+const AUTH_HOME_PATH_DEFAULT = "/"
+
+var Auth *AuthConfig
+
+func initAuth() {
+	homePath := GetEnv("AUTH_HOME_PATH", AUTH_HOME_PATH_DEFAULT).(string)
+
+	Auth = &AuthConfig{
+		EmailVerifyExpire:     GetEnv("AUTH_EMAIL_VERIFY_EXPIRE", AUTH_EMAIL_VERIFY_EXPIRE_DEFAULT).(int),
+		GuestRedirectPath:     GetEnv("AUTH_GUEST_REDIRECT_PATH", homePath).(string),
+		HomePath:              homePath,
+		LockoutMaxMinutes:     GetEnv("AUTH_LOCKOUT_MAX_MINUTES", AUTH_LOCKOUT_MAX_MINUTES_DEFAULT).(int),
+		LockoutMinutes:        GetEnv("AUTH_LOCKOUT_MINUTES", AUTH_LOCKOUT_MINUTES_DEFAULT).(int),
+		LockoutThreshold:      GetEnv("AUTH_LOCKOUT_THRESHOLD", AUTH_LOCKOUT_THRESHOLD_DEFAULT).(int),
+		LoginPath:             GetEnv("AUTH_LOGIN_PATH", AUTH_LOGIN_PATH_DEFAULT).(string),
+		MagicLinkExpire:       GetEnv("AUTH_MAGIC_LINK_EXPIRE", AUTH_MAGIC_LINK_EXPIRE_DEFAULT).(int),
+		PasswordResetExpire:   GetEnv("AUTH_PASSWORD_RESET_EXPIRE", AUTH_PASSWORD_RESET_EXPIRE_DEFAULT).(int),
+		PermissionCacheTTL:    GetEnv("AUTH_PERMISSION_CACHE_TTL", AUTH_PERMISSION_CACHE_TTL_DEFAULT).(int),
+		RememberCookie:        GetEnv("AUTH_REMEMBER_COOKIE", "_gohst_remember").(string),
+		RememberDays:          GetEnv("AUTH_REMEMBER_DAYS", AUTH_REMEMBER_DAYS_DEFAULT).(int),
+		RequireAdminTwoFactor: GetEnv("AUTH_TWO_FACTOR_REQUIRE_ADMIN", false).(bool),
+		TwoFactorIssuer:       GetEnv("AUTH_TWO_FACTOR_ISSUER", "Gohst").(string),
+	}
+}
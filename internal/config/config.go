@@ -10,8 +10,16 @@ func InitConfig(envName ...string) {
 	}
 
 	initEnv(env)
+	initRedis()
 	initSession()
 	initVite()
 	initRateLimit()
+	initMail()
+	initAuth()
+	initSigning()
+	initOIDC()
+	initAudit()
+	initServer()
+	initLog()
 
 }
@@ -0,0 +1,26 @@
+package config
+
+import "strings"
+
+// LogConfig selects the framework logger's output. Format is text or json;
+// Level is debug, info, warn or error.
+type LogConfig struct {
+	Format string
+	Level  string
+}
+
+const (
+	LOG_FORMAT_TEXT = "text"
+	LOG_FORMAT_JSON = "json"
+
+	LOG_LEVEL_DEFAULT = "info"
+)
+
+var Log *LogConfig
+
+func initLog() {
+	Log = &LogConfig{
+		Format: strings.ToLower(GetEnv("LOG_FORMAT", LOG_FORMAT_TEXT).(string)),
+		Level:  strings.ToLower(GetEnv("LOG_LEVEL", LOG_LEVEL_DEFAULT).(string)),
+	}
+}
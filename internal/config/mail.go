@@ -0,0 +1,37 @@
+package config
+
+// SMTPConfig holds the connection details for the smtp mail driver
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	TLS      bool
+}
+
+// MailConfig selects the mail driver ("log" or "smtp") and the sender
+type MailConfig struct {
+	Driver   string
+	From     string
+	FromName string
+	SMTP     *SMTPConfig
+}
+
+const MAIL_SMTP_PORT_DEFAULT = 587
+
+var Mail *MailConfig
+
+func initMail() {
+	Mail = &MailConfig{
+		Driver:   GetEnv("MAIL_DRIVER", "log").(string),
+		From:     GetEnv("MAIL_FROM", "noreply@example.com").(string),
+		FromName: GetEnv("MAIL_FROM_NAME", "").(string),
+		SMTP: &SMTPConfig{
+			Host:     GetEnv("MAIL_SMTP_HOST", "").(string),
+			Port:     GetEnv("MAIL_SMTP_PORT", MAIL_SMTP_PORT_DEFAULT).(int),
+			User:     GetEnv("MAIL_SMTP_USER", "").(string),
+			Password: GetEnv("MAIL_SMTP_PASSWORD", "").(string),
+			TLS:      GetEnv("MAIL_SMTP_TLS", true).(bool),
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OIDCProviderConfig configures one OpenID Connect login provider. Name is
+// the key used in URLs and env vars; the callback URL is
+// APP_URL/auth/oidc/<name>/callback.
+type OIDCProviderConfig struct {
+	Name         string
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// OIDCConfig lists the enabled providers. LinkVerifiedEmails links a new
+// provider identity to the existing account with the same email when both
+// the provider and the account have verified it. Skipped explains each
+// listed provider left out for missing settings; the oidc package logs it.
+type OIDCConfig struct {
+	Providers          []OIDCProviderConfig
+	LinkVerifiedEmails bool
+	Skipped            []error
+}
+
+const OIDC_SCOPES_DEFAULT = "openid email profile"
+
+var OIDC *OIDCConfig
+
+// initOIDC reads OIDC_PROVIDERS, a comma-separated list of names, and the
+// OIDC_<NAME>_* settings of each
+func initOIDC() {
+	OIDC = &OIDCConfig{
+		LinkVerifiedEmails: GetEnv("OIDC_LINK_VERIFIED_EMAILS", true).(bool),
+	}
+
+	for _, name := range strings.Split(GetEnv("OIDC_PROVIDERS", "").(string), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+		provider := OIDCProviderConfig{
+			Name:         name,
+			DisplayName:  GetEnv(prefix+"DISPLAY_NAME", strings.ToUpper(name[:1])+name[1:]).(string),
+			Issuer:       strings.TrimRight(GetEnv(prefix+"ISSUER", "").(string), "/"),
+			ClientID:     GetEnv(prefix+"CLIENT_ID", "").(string),
+			ClientSecret: GetEnv(prefix+"CLIENT_SECRET", "").(string),
+			Scopes:       strings.Fields(GetEnv(prefix+"SCOPES", OIDC_SCOPES_DEFAULT).(string)),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			OIDC.Skipped = append(OIDC.Skipped,
+				fmt.Errorf("OIDC provider %q needs %sISSUER and %sCLIENT_ID", name, prefix, prefix))
+			continue
+		}
+		OIDC.Providers = append(OIDC.Providers, provider)
+	}
+}
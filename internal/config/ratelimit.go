@@ -8,12 +8,9 @@ type RateLimitConfig struct {
 	// Store is the backing store type: "memory" or "redis"
 	Store string
 
-	// RedisPrefix is the key prefix for all rate-limit keys in Redis
+	// RedisPrefix is the key prefix for all rate-limit keys in the shared Redis
 	RedisPrefix string
 
-	// Redis holds the Redis connection config (shared with session if desired)
-	Redis *RedisConfig
-
 	// TrustedProxies is a list of CIDR ranges or IPs that are trusted reverse proxies.
 	// X-Forwarded-For / X-Real-IP headers are only honoured from these peers.
 	TrustedProxies []string
@@ -51,12 +48,6 @@ func initRateLimit() {
 		DefaultWindow:         GetEnv("RATE_LIMIT_DEFAULT_WINDOW", 60).(int),
 		DefaultBurst:          GetEnv("RATE_LIMIT_DEFAULT_BURST", 60).(int),
 		TrustedProxies:        proxies,
-		Redis: &RedisConfig{
-			DB:       GetEnv("RATE_LIMIT_REDIS_DB", 0).(int),
-			Host:     GetEnv("RATE_LIMIT_REDIS_HOST", GetEnv("SESSION_REDIS_HOST", "localhost").(string)).(string),
-			Password: GetEnv("RATE_LIMIT_REDIS_PASSWORD", GetEnv("SESSION_REDIS_PASSWORD", "").(string)).(string),
-			Port:     GetEnv("RATE_LIMIT_REDIS_PORT", GetEnv("SESSION_REDIS_PORT", 6379).(int)).(int),
-		},
 	}
 }
 
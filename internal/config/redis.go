@@ -0,0 +1,98 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+const (
+	REDIS_MODE_STANDALONE = "standalone"
+	REDIS_MODE_SENTINEL   = "sentinel"
+	REDIS_MODE_CLUSTER    = "cluster"
+)
+
+const REDIS_HOST_DEFAULT = "localhost"
+
+const REDIS_PORT_DEFAULT = 6379
+
+// RedisTLSConfig enables TLS for Redis connections.
+type RedisTLSConfig struct {
+	Enabled            bool
+	ServerName         string
+	InsecureSkipVerify bool
+	CAFile             string
+}
+
+// RedisConnectionConfig describes the single Redis deployment shared by
+// sessions, rate limiting and any future cache. Subsystems keep their keys
+// apart with their own prefixes rather than separate connections.
+type RedisConnectionConfig struct {
+	// Mode is standalone, sentinel or cluster
+	Mode string
+
+	// Addrs are host:port pairs: the server, the sentinels, or the cluster seed nodes
+	Addrs []string
+
+	Username string
+	Password string
+
+	// DB is ignored in cluster mode
+	DB int
+
+	// MasterName and the sentinel credentials are used in sentinel mode
+	MasterName       string
+	SentinelUsername string
+	SentinelPassword string
+
+	TLS *RedisTLSConfig
+}
+
+var Redis *RedisConnectionConfig
+
+// legacyRedisPrefixes are the per-subsystem settings used before sessions
+// and rate limiting shared one connection, in the order they are tried
+var legacyRedisPrefixes = []string{"SESSION_REDIS_", "RATE_LIMIT_REDIS_"}
+
+// redisEnv reads REDIS_<suffix>, falling back to the legacy settings with a
+// deprecation warning
+func redisEnv(suffix string, defaultValue any) any {
+	if os.Getenv("REDIS_"+suffix) != "" {
+		return GetEnv("REDIS_"+suffix, defaultValue)
+	}
+	for _, prefix := range legacyRedisPrefixes {
+		key := prefix + suffix
+		if os.Getenv(key) != "" {
+			log.Printf("%s is deprecated and will be removed; set REDIS_%s instead", key, suffix)
+			return GetEnv(key, defaultValue)
+		}
+	}
+	return defaultValue
+}
+
+func initRedis() {
+	// REDIS_ADDRS wins; otherwise build one address from REDIS_HOST/REDIS_PORT
+	addrs := splitCSV(GetEnv("REDIS_ADDRS", "").(string))
+	if len(addrs) == 0 {
+		host := redisEnv("HOST", REDIS_HOST_DEFAULT).(string)
+		port := redisEnv("PORT", REDIS_PORT_DEFAULT).(int)
+		addrs = []string{host + ":" + strconv.Itoa(port)}
+	}
+
+	Redis = &RedisConnectionConfig{
+		Mode:             GetEnv("REDIS_MODE", REDIS_MODE_STANDALONE).(string),
+		Addrs:            addrs,
+		Username:         GetEnv("REDIS_USERNAME", "").(string),
+		Password:         redisEnv("PASSWORD", "").(string),
+		DB:               redisEnv("DB", 0).(int),
+		MasterName:       GetEnv("REDIS_MASTER_NAME", "").(string),
+		SentinelUsername: GetEnv("REDIS_SENTINEL_USERNAME", "").(string),
+		SentinelPassword: GetEnv("REDIS_SENTINEL_PASSWORD", "").(string),
+		TLS: &RedisTLSConfig{
+			Enabled:            GetEnv("REDIS_TLS", false).(bool),
+			ServerName:         GetEnv("REDIS_TLS_SERVER_NAME", "").(string),
+			InsecureSkipVerify: GetEnv("REDIS_TLS_INSECURE_SKIP_VERIFY", false).(bool),
+			CAFile:             GetEnv("REDIS_TLS_CA_FILE", "").(string),
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import "time"
+
+// ServerConfig holds the HTTP server timeouts. Each is set in seconds.
+type ServerConfig struct {
+	// ReadTimeout limits reading a whole request, body included
+	ReadTimeout time.Duration
+
+	// ReadHeaderTimeout limits reading the request headers, guarding
+	// against slow clients holding connections open
+	ReadHeaderTimeout time.Duration
+
+	// WriteTimeout limits writing the response, counted from the end of
+	// the request headers
+	WriteTimeout time.Duration
+
+	// IdleTimeout is how long a keep-alive connection may wait for its
+	// next request
+	IdleTimeout time.Duration
+
+	// ShutdownDelay is how long the server keeps serving after SIGINT or
+	// SIGTERM with readiness failing, so load balancers can drop it first
+	ShutdownDelay time.Duration
+
+	// ShutdownTimeout is how long in-flight requests get to finish after
+	// SIGINT or SIGTERM, and then how long the shutdown hooks get
+	ShutdownTimeout time.Duration
+}
+
+const (
+	SERVER_READ_TIMEOUT_DEFAULT        = 15
+	SERVER_READ_HEADER_TIMEOUT_DEFAULT = 5
+	SERVER_WRITE_TIMEOUT_DEFAULT       = 30
+	SERVER_IDLE_TIMEOUT_DEFAULT        = 120
+	SERVER_SHUTDOWN_TIMEOUT_DEFAULT    = 20
+)
+
+var Server *ServerConfig
+
+func initServer() {
+	Server = &ServerConfig{
+		ReadTimeout:       seconds("SERVER_READ_TIMEOUT", SERVER_READ_TIMEOUT_DEFAULT),
+		ReadHeaderTimeout: seconds("SERVER_READ_HEADER_TIMEOUT", SERVER_READ_HEADER_TIMEOUT_DEFAULT),
+		WriteTimeout:      seconds("SERVER_WRITE_TIMEOUT", SERVER_WRITE_TIMEOUT_DEFAULT),
+		IdleTimeout:       seconds("SERVER_IDLE_TIMEOUT", SERVER_IDLE_TIMEOUT_DEFAULT),
+		ShutdownDelay:     seconds("SERVER_SHUTDOWN_DELAY", 0),
+		ShutdownTimeout:   seconds("SERVER_SHUTDOWN_TIMEOUT", SERVER_SHUTDOWN_TIMEOUT_DEFAULT),
+	}
+	if Server.ShutdownTimeout <= 0 {
+		Server.ShutdownTimeout = SERVER_SHUTDOWN_TIMEOUT_DEFAULT * time.Second
+	}
+}
+
+// seconds reads a whole number of seconds. 0 disables the read, write and
+// idle timeouts; the shutdown timeout falls back to its default.
+func seconds(key string, defaultValue int) time.Duration {
+	return time.Duration(GetEnv(key, defaultValue).(int)) * time.Second
+}
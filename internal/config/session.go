@@ -3,25 +3,50 @@ package config
 type FileConfig struct {
 	Path string
 }
-type RedisConfig struct {
-	DB       int
-	Host     string
-	Password string
-	Port     int
+
+// CookieConfig holds the AEAD keys for the encrypted cookie session store.
+// Key is used to seal new cookies; OldKeys are still accepted when opening
+// cookies so keys can be rotated without logging everyone out.
+type CookieConfig struct {
+	Key     string
+	OldKeys []string
+}
+
+// FingerprintConfig binds sessions to a coarse client fingerprint: the
+// user-agent family plus an IP prefix. Action is log, regenerate or reauth.
+type FingerprintConfig struct {
+	Enabled    bool
+	Action     string
+	IPv4Prefix int
 }
 
+// SessionConfig holds session settings. Timeouts are in minutes.
+// IdleTimeout slides with activity; AbsoluteTimeout caps the total lifetime
+// of a session (0 disables it). TouchInterval throttles how often activity
+// alone extends the idle expiry.
 type SessionConfig struct {
-	ContextKey string
-	File       *FileConfig
-	Length     int
-	Name       string
-	AdminName  string
-	Redis      *RedisConfig
-	Store      string
+	AbsoluteTimeout int
+	ContextKey      string
+	Cookie          *CookieConfig
+	File            *FileConfig
+	Fingerprint     *FingerprintConfig
+	IdleTimeout     int
+	Length          int
+	Name            string
+	AdminName       string
+	RedisPrefix     string
+	Store           string
+	TouchInterval   int
 }
 
 const SESSION_LENGTH_DEFAULT = 60
 
+// SESSION_ABSOLUTE_TIMEOUT_DEFAULT leaves sessions uncapped unless
+// SESSION_ABSOLUTE_TIMEOUT is set, as they were before the cap existed
+const SESSION_ABSOLUTE_TIMEOUT_DEFAULT = 0
+
+const SESSION_TOUCH_INTERVAL_DEFAULT = 1
+
 var Session *SessionConfig
 
 func initSession() {
@@ -29,20 +54,28 @@ func initSession() {
 	if adminName == "" {
 		adminName = GetEnv("ADMIN_SESSION_NAME", "_gohst_admin_session").(string)
 	}
+	length := GetEnv("SESSION_LENGTH", SESSION_LENGTH_DEFAULT).(int)
 	Session = &SessionConfig{
-		ContextKey: GetEnv("SESSION_CONTEXT_KEY", "session").(string),
+		AbsoluteTimeout: GetEnv("SESSION_ABSOLUTE_TIMEOUT", SESSION_ABSOLUTE_TIMEOUT_DEFAULT).(int),
+		ContextKey:      GetEnv("SESSION_CONTEXT_KEY", "session").(string),
+		Cookie: &CookieConfig{
+			Key:     GetEnv("SESSION_COOKIE_KEY", "").(string),
+			OldKeys: splitCSV(GetEnv("SESSION_COOKIE_OLD_KEYS", "").(string)),
+		},
 		File: &FileConfig{
 			Path: GetEnv("SESSION_FILE_PATH", "tmp/sessions").(string),
 		},
-		Length:    GetEnv("SESSION_LENGTH", SESSION_LENGTH_DEFAULT).(int),
-		Name:      GetEnv("SESSION_NAME", "_gohst_session").(string),
-		AdminName: adminName,
-		Redis: &RedisConfig{
-			DB:       GetEnv("SESSION_REDIS_DB", 0).(int),
-			Host:     GetEnv("SESSION_REDIS_HOST", "localhost").(string),
-			Password: GetEnv("SESSION_REDIS_PASSWORD", "").(string),
-			Port:     GetEnv("SESSION_REDIS_PORT", 6379).(int),
+		Fingerprint: &FingerprintConfig{
+			Enabled:    GetEnv("SESSION_FINGERPRINT", false).(bool),
+			Action:     GetEnv("SESSION_FINGERPRINT_ACTION", "log").(string),
+			IPv4Prefix: GetEnv("SESSION_FINGERPRINT_IPV4_PREFIX", 24).(int),
 		},
-		Store: GetEnv("SESSION_STORE", "file").(string),
+		IdleTimeout:   GetEnv("SESSION_IDLE_TIMEOUT", length).(int),
+		Length:        length,
+		Name:          GetEnv("SESSION_NAME", "_gohst_session").(string),
+		AdminName:     adminName,
+		RedisPrefix:   GetEnv("SESSION_REDIS_PREFIX", "gohst:sess:").(string),
+		Store:         GetEnv("SESSION_STORE", "file").(string),
+		TouchInterval: GetEnv("SESSION_TOUCH_INTERVAL", SESSION_TOUCH_INTERVAL_DEFAULT).(int),
 	}
 }
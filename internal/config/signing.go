@@ -0,0 +1,18 @@
+package config
+
+// SigningConfig holds the keys used to sign URLs such as email verification
+// links. Key signs new URLs; OldKeys are still accepted so keys can rotate
+// without breaking links already sent.
+type SigningConfig struct {
+	Key     string
+	OldKeys []string
+}
+
+var Signing *SigningConfig
+
+func initSigning() {
+	Signing = &SigningConfig{
+		Key:     GetEnv("APP_KEY", "").(string),
+		OldKeys: splitCSV(GetEnv("APP_OLD_KEYS", "").(string)),
+	}
+}
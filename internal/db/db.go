@@ -1,16 +1,22 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"os"
 	"sync"
 
 	_ "github.com/lib/pq"
 
 	"gohst/internal/config"
+	"gohst/internal/health"
+	"gohst/internal/lifecycle"
+	"gohst/internal/logger"
 )
 
+var log = logger.Component("db")
+
 // DBManager manages the database connection
 const PRIMARY_DB_NAME = "primary"
 type DBManager struct {
@@ -46,16 +52,25 @@ func InitDBPool(pool *config.DatabaseConfigPool) {
 
 			db, err := sql.Open("postgres", dsn)
 			if err != nil {
-				log.Fatalf("Error connecting to database %s: %v", name, err)
+				log.Error("opening database failed", "name", name, "err", err)
+				os.Exit(1)
 			}
 
 			if err = db.Ping(); err != nil {
-				log.Fatalf("Database %s ping failed: %v", name, err)
+				log.Error("database ping failed", "name", name, "err", err)
+				os.Exit(1)
 			}
 
-			log.Printf("Connected to database: %s", name)
+			log.Info("connected to database", "name", name)
 			Databases[name] = &DBManager{DB: db}
+			health.Register("db:"+name, db.PingContext)
 		}
+
+		// Closed last, after anything that still writes on shutdown
+		lifecycle.OnShutdown("database", func(ctx context.Context) error {
+			CloseDBPool()
+			return nil
+		})
 	})
 }
 
@@ -81,14 +96,14 @@ func CloseDB() {
 	// Close primary database connection
 	if Database != nil && Database.DB != nil {
 		Database.DB.Close()
-		log.Println("Primary database connection closed.")
+		log.Info("database connection closed", "name", PRIMARY_DB_NAME)
 	}
 
 	// Close all multi-database connections
 	for name, dbManager := range Databases {
 		if dbManager != nil && dbManager.DB != nil {
 			dbManager.DB.Close()
-			log.Printf("Database connection '%s' closed.", name)
+			log.Info("database connection closed", "name", name)
 		}
 	}
 }
@@ -99,7 +114,7 @@ func CloseDBPool() {
 	for name, dbManager := range Databases {
 		if dbManager != nil && dbManager.DB != nil {
 			dbManager.DB.Close()
-			log.Printf("Database connection '%s' closed.", name)
+			log.Info("database connection closed", "name", name)
 		}
 	}
 
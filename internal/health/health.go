@@ -0,0 +1,218 @@
+// Package health serves liveness and readiness probes for load balancers and
+// orchestrators.
+//
+// Subsystems register readiness checks when they are set up, under a name
+// that is unique per dependency; registering a name again replaces the check:
+//
+//	health.Register("db:primary", func(ctx context.Context) error {
+//		return conn.PingContext(ctx)
+//	})
+//
+// Liveness only runs checks registered with RegisterLiveness, none by
+// default, so an outage of a dependency marks instances unready instead of
+// getting them restarted. Readiness runs every readiness check concurrently
+// and fails as soon as graceful shutdown begins.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"gohst/internal/lifecycle"
+	"gohst/internal/logger"
+)
+
+var log = logger.Component("health")
+
+// Check reports whether a dependency is usable. It should return once ctx is done.
+type Check func(ctx context.Context) error
+
+const (
+	STATUS_OK            = "ok"
+	STATUS_FAILING       = "failing"
+	STATUS_SHUTTING_DOWN = "shutting_down"
+)
+
+// CHECK_TIMEOUT bounds each check so one hung dependency cannot stall a probe
+const CHECK_TIMEOUT = 2 * time.Second
+
+// Result is the outcome of one check. Error details are logged, not served,
+// since the probes are public.
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// Report is the JSON body of a probe
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Registry holds liveness and readiness checks. Most code uses the
+// package-level functions, which share one registry.
+type Registry struct {
+	mu        sync.RWMutex
+	liveness  map[string]Check
+	readiness map[string]Check
+
+	// ShuttingDown fails readiness once it reports true
+	ShuttingDown func() bool
+
+	// Timeout bounds each check
+	Timeout time.Duration
+}
+
+// NewRegistry returns an empty registry that fails readiness during
+// lifecycle shutdown and gives each check CHECK_TIMEOUT
+func NewRegistry() *Registry {
+	return &Registry{
+		liveness:     map[string]Check{},
+		readiness:    map[string]Check{},
+		ShuttingDown: lifecycle.ShuttingDown,
+		Timeout:      CHECK_TIMEOUT,
+	}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Register adds or replaces a readiness check
+func (reg *Registry) Register(name string, check Check) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.readiness[name] = check
+}
+
+// RegisterLiveness adds or replaces a liveness check. Keep these to faults
+// only a restart fixes; dependencies belong in readiness.
+func (reg *Registry) RegisterLiveness(name string, check Check) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.liveness[name] = check
+}
+
+// Liveness runs the liveness checks
+func (reg *Registry) Liveness(ctx context.Context) Report {
+	return reg.run(ctx, reg.liveness)
+}
+
+// Readiness runs the readiness checks, or fails without running them once
+// shutdown has begun
+func (reg *Registry) Readiness(ctx context.Context) Report {
+	if reg.ShuttingDown != nil && reg.ShuttingDown() {
+		return Report{Status: STATUS_SHUTTING_DOWN, Checks: map[string]Result{}}
+	}
+	return reg.run(ctx, reg.readiness)
+}
+
+// run executes checks concurrently, each under the registry timeout
+func (reg *Registry) run(ctx context.Context, set map[string]Check) Report {
+	reg.mu.RLock()
+	checks := make(map[string]Check, len(set))
+	for name, check := range set {
+		checks[name] = check
+	}
+	reg.mu.RUnlock()
+
+	report := Report{Status: STATUS_OK, Checks: make(map[string]Result, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := runCheck(ctx, name, check, reg.Timeout)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != STATUS_OK {
+				report.Status = STATUS_FAILING
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func runCheck(ctx context.Context, name string, check Check, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := safeCheck(ctx, check)
+	result := Result{
+		Status:    STATUS_OK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		log.WarnContext(ctx, "check failing", "check", name, "err", err)
+		result.Status = STATUS_FAILING
+	}
+	return result
+}
+
+// safeCheck turns a panicking check into a failure
+func safeCheck(ctx context.Context, check Check) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("check panicked: %v", r)
+		}
+	}()
+	return check(ctx)
+}
+
+// LivenessHandler serves the liveness report, 200 when every check passes
+// and 503 otherwise
+func (reg *Registry) LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, r, reg.Liveness(r.Context()))
+	}
+}
+
+// ReadinessHandler serves the readiness report, 200 when every check passes
+// and 503 otherwise
+func (reg *Registry) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, r, reg.Readiness(r.Context()))
+	}
+}
+
+// writeReport serves report, leaving passing probes out of the access log as
+// they are polled every few seconds
+func writeReport(w http.ResponseWriter, r *http.Request, report Report) {
+	status := http.StatusOK
+	if report.Status != STATUS_OK {
+		status = http.StatusServiceUnavailable
+	} else {
+		logger.SkipAccessLog(r.Context())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report) //nolint:errcheck
+}
+
+// Register adds or replaces a readiness check
+func Register(name string, check Check) {
+	defaultRegistry.Register(name, check)
+}
+
+// RegisterLiveness adds or replaces a liveness check
+func RegisterLiveness(name string, check Check) {
+	defaultRegistry.RegisterLiveness(name, check)
+}
+
+// Liveness serves GET /healthz
+func Liveness(w http.ResponseWriter, r *http.Request) {
+	defaultRegistry.LivenessHandler()(w, r)
+}
+
+// Readiness serves GET /readyz
+func Readiness(w http.ResponseWriter, r *http.Request) {
+	defaultRegistry.ReadinessHandler()(w, r)
+}
@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestRegistry() *Registry {
+	reg := NewRegistry()
+	reg.ShuttingDown = func() bool { return false }
+	reg.Timeout = 50 * time.Millisecond
+	return reg
+}
+
+func probe(t *testing.T, handler http.HandlerFunc) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decoding %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, report
+}
+
+func TestReadiness_AllPassing(t *testing.T) {
+	reg := newTestRegistry()
+	reg.Register("db:primary", func(ctx context.Context) error { return nil })
+	reg.Register("redis:sessions", func(ctx context.Context) error { return nil })
+
+	code, report := probe(t, reg.ReadinessHandler())
+	if code != http.StatusOK || report.Status != STATUS_OK {
+		t.Fatalf("got %d %s, want 200 ok", code, report.Status)
+	}
+	if len(report.Checks) != 2 || report.Checks["db:primary"].Status != STATUS_OK {
+		t.Fatalf("checks = %+v", report.Checks)
+	}
+}
+
+func TestReadiness_OneFailingFailsTheProbe(t *testing.T) {
+	reg := newTestRegistry()
+	reg.Register("db:primary", func(ctx context.Context) error { return nil })
+	reg.Register("storage", func(ctx context.Context) error { return errors.New("disk full") })
+
+	code, report := probe(t, reg.ReadinessHandler())
+	if code != http.StatusServiceUnavailable || report.Status != STATUS_FAILING {
+		t.Fatalf("got %d %s, want 503 failing", code, report.Status)
+	}
+	if report.Checks["storage"].Status != STATUS_FAILING || report.Checks["db:primary"].Status != STATUS_OK {
+		t.Fatalf("checks = %+v", report.Checks)
+	}
+}
+
+func TestReadiness_PanicAndTimeoutFail(t *testing.T) {
+	reg := newTestRegistry()
+	reg.Register("panics", func(ctx context.Context) error { panic("boom") })
+	reg.Register("hangs", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := reg.Readiness(context.Background())
+	if report.Checks["panics"].Status != STATUS_FAILING || report.Checks["hangs"].Status != STATUS_FAILING {
+		t.Fatalf("checks = %+v", report.Checks)
+	}
+}
+
+func TestReadiness_FailsWhileShuttingDown(t *testing.T) {
+	reg := newTestRegistry()
+	ran := false
+	reg.Register("db:primary", func(ctx context.Context) error { ran = true; return nil })
+	reg.ShuttingDown = func() bool { return true }
+
+	code, report := probe(t, reg.ReadinessHandler())
+	if code != http.StatusServiceUnavailable || report.Status != STATUS_SHUTTING_DOWN {
+		t.Fatalf("got %d %s, want 503 shutting_down", code, report.Status)
+	}
+	if ran {
+		t.Fatal("checks should not run during shutdown")
+	}
+}
+
+func TestLiveness_IgnoresReadinessChecks(t *testing.T) {
+	reg := newTestRegistry()
+	reg.Register("db:primary", func(ctx context.Context) error { return errors.New("down") })
+	reg.ShuttingDown = func() bool { return true }
+
+	code, report := probe(t, reg.LivenessHandler())
+	if code != http.StatusOK || report.Status != STATUS_OK || len(report.Checks) != 0 {
+		t.Fatalf("got %d %+v, want 200 with no checks", code, report)
+	}
+
+	reg.RegisterLiveness("workers", func(ctx context.Context) error { return errors.New("stuck") })
+	if code, _ := probe(t, reg.LivenessHandler()); code != http.StatusServiceUnavailable {
+		t.Fatalf("failing liveness check got %d, want 503", code)
+	}
+}
+
+func TestRegister_ReplacesByName(t *testing.T) {
+	reg := newTestRegistry()
+	reg.Register("redis:ratelimit", func(ctx context.Context) error { return errors.New("old") })
+	reg.Register("redis:ratelimit", func(ctx context.Context) error { return nil })
+
+	report := reg.Readiness(context.Background())
+	if len(report.Checks) != 1 || report.Status != STATUS_OK {
+		t.Fatalf("report = %+v, want the replacement check only", report)
+	}
+}
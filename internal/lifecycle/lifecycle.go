@@ -0,0 +1,143 @@
+// Package lifecycle lets subsystems hook into the start and shutdown of the
+// web server.
+//
+// A subsystem registers its hooks when it is set up, typically from its Init
+// or constructor:
+//
+//	lifecycle.OnShutdown("database", func(ctx context.Context) error {
+//		CloseDBPool()
+//		return nil
+//	})
+//
+// Start hooks run in registration order before the server accepts requests.
+// Shutdown hooks run after in-flight requests have drained, in reverse
+// registration order like deferred calls, so a subsystem is closed before the
+// ones it was built on. Commands that never call Start or Shutdown, such as
+// the CLIs, can register hooks harmlessly.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"gohst/internal/logger"
+)
+
+var log = logger.Component("lifecycle")
+
+// Hook is run on start or shutdown. It should return once ctx is done.
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	fn   Hook
+}
+
+// Registry holds start and shutdown hooks. Most code uses the package-level
+// functions, which share one registry.
+type Registry struct {
+	mu           sync.Mutex
+	start        []namedHook
+	shutdown     []namedHook
+	shuttingDown atomic.Bool
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+var defaultRegistry = NewRegistry()
+
+// OnStart registers fn to run when the server starts
+func (reg *Registry) OnStart(name string, fn Hook) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.start = append(reg.start, namedHook{name: name, fn: fn})
+}
+
+// OnShutdown registers fn to run when the server shuts down
+func (reg *Registry) OnShutdown(name string, fn Hook) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.shutdown = append(reg.shutdown, namedHook{name: name, fn: fn})
+}
+
+// Start runs the start hooks in registration order, stopping at the first
+// that fails
+func (reg *Registry) Start(ctx context.Context) error {
+	reg.mu.Lock()
+	hooks := append([]namedHook(nil), reg.start...)
+	reg.mu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook.fn(ctx); err != nil {
+			return fmt.Errorf("lifecycle: starting %s: %w", hook.name, err)
+		}
+	}
+	return nil
+}
+
+// Shutdown runs every shutdown hook in reverse registration order, even if
+// some fail, and returns their errors joined. Only the first call runs the
+// hooks; later calls return the same result.
+func (reg *Registry) Shutdown(ctx context.Context) error {
+	reg.shutdownOnce.Do(func() {
+		reg.shuttingDown.Store(true)
+
+		reg.mu.Lock()
+		hooks := append([]namedHook(nil), reg.shutdown...)
+		reg.mu.Unlock()
+
+		var errs []error
+		for i := len(hooks) - 1; i >= 0; i-- {
+			if err := hooks[i].fn(ctx); err != nil {
+				log.Error("shutdown hook failed", "hook", hooks[i].name, "err", err)
+				errs = append(errs, fmt.Errorf("%s: %w", hooks[i].name, err))
+			}
+		}
+		reg.shutdownErr = errors.Join(errs...)
+	})
+	return reg.shutdownErr
+}
+
+// ShuttingDown reports whether shutdown has begun, from the moment the server
+// stops accepting requests
+func (reg *Registry) ShuttingDown() bool {
+	return reg.shuttingDown.Load()
+}
+
+// beginShutdown marks the registry as shutting down before the drain
+func (reg *Registry) beginShutdown() {
+	reg.shuttingDown.Store(true)
+}
+
+// OnStart registers fn to run when the server starts
+func OnStart(name string, fn Hook) {
+	defaultRegistry.OnStart(name, fn)
+}
+
+// OnShutdown registers fn to run when the server shuts down
+func OnShutdown(name string, fn Hook) {
+	defaultRegistry.OnShutdown(name, fn)
+}
+
+// Start runs the registered start hooks
+func Start(ctx context.Context) error {
+	return defaultRegistry.Start(ctx)
+}
+
+// Shutdown runs the registered shutdown hooks
+func Shutdown(ctx context.Context) error {
+	return defaultRegistry.Shutdown(ctx)
+}
+
+// ShuttingDown reports whether the server has begun shutting down
+func ShuttingDown() bool {
+	return defaultRegistry.ShuttingDown()
+}
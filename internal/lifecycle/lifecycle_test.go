@@ -0,0 +1,173 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStart_RunsInOrderAndStopsAtFirstError(t *testing.T) {
+	reg := NewRegistry()
+	var ran []string
+	reg.OnStart("a", func(ctx context.Context) error { ran = append(ran, "a"); return nil })
+	reg.OnStart("b", func(ctx context.Context) error { ran = append(ran, "b"); return errors.New("boom") })
+	reg.OnStart("c", func(ctx context.Context) error { ran = append(ran, "c"); return nil })
+
+	err := reg.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "starting b") {
+		t.Fatalf("err = %v, want it to name hook b", err)
+	}
+	if got := strings.Join(ran, ","); got != "a,b" {
+		t.Fatalf("ran %s, want a,b", got)
+	}
+}
+
+func TestShutdown_ReverseOrderJoinsErrorsAndRunsOnce(t *testing.T) {
+	reg := NewRegistry()
+	var ran []string
+	reg.OnShutdown("db", func(ctx context.Context) error { ran = append(ran, "db"); return nil })
+	reg.OnShutdown("redis", func(ctx context.Context) error { ran = append(ran, "redis"); return errors.New("redis down") })
+	reg.OnShutdown("audit", func(ctx context.Context) error { ran = append(ran, "audit"); return nil })
+
+	if reg.ShuttingDown() {
+		t.Fatal("ShuttingDown before Shutdown")
+	}
+	err := reg.Shutdown(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "redis: redis down") {
+		t.Fatalf("err = %v, want the redis error", err)
+	}
+	if got := strings.Join(ran, ","); got != "audit,redis,db" {
+		t.Fatalf("ran %s, want audit,redis,db", got)
+	}
+	if !reg.ShuttingDown() {
+		t.Fatal("ShuttingDown should be true after Shutdown")
+	}
+
+	if again := reg.Shutdown(context.Background()); again != err {
+		t.Fatalf("second Shutdown = %v, want the first result", again)
+	}
+	if len(ran) != 3 {
+		t.Fatalf("hooks ran again: %v", ran)
+	}
+}
+
+func TestRun_DrainsInFlightRequestsBeforeHooks(t *testing.T) {
+	addr := freeAddr(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	reg := NewRegistry()
+	var mu sync.Mutex
+	var events []string
+	note := func(event string) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}
+
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			io.WriteString(w, "done")
+			note("handled")
+		}),
+	}
+	reg.OnStart("probe", func(ctx context.Context) error { note("start"); return nil })
+	reg.OnShutdown("probe", func(ctx context.Context) error { note("shutdown"); return nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- reg.Run(ctx, srv, Options{DrainTimeout: 5 * time.Second}) }()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := getWithRetry("http://" + addr)
+		if err != nil {
+			body <- "error: " + err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+
+	<-started
+	cancel()
+	waitFor(t, reg.ShuttingDown)
+	close(release)
+
+	if got := <-body; got != "done" {
+		t.Fatalf("in-flight request got %q, want done", got)
+	}
+	if err := <-runErr; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(events, ","); got != "start,handled,shutdown" {
+		t.Fatalf("events = %s, want start,handled,shutdown", got)
+	}
+}
+
+func TestRun_ListenFailureRunsShutdownHooks(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	reg := NewRegistry()
+	closed := false
+	reg.OnShutdown("probe", func(ctx context.Context) error { closed = true; return nil })
+
+	err = reg.Run(context.Background(), &http.Server{Addr: ln.Addr().String()}, Options{DrainTimeout: time.Second})
+	if err == nil {
+		t.Fatal("Run on a taken port should fail")
+	}
+	if !closed {
+		t.Fatal("shutdown hooks should run when the server fails to start")
+	}
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+// getWithRetry waits for the server goroutine to start listening
+func getWithRetry(url string) (*http.Response, error) {
+	var err error
+	for i := 0; i < 50; i++ {
+		var resp *http.Response
+		if resp, err = http.Get(url); err == nil {
+			return resp, nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return nil, err
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,90 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Options controls how the server shuts down
+type Options struct {
+	// Delay keeps serving after the signal while ShuttingDown reports true,
+	// so load balancers polling readiness stop sending traffic before the
+	// listener closes
+	Delay time.Duration
+
+	// DrainTimeout is how long in-flight requests get to finish, and then
+	// how long the shutdown hooks get
+	DrainTimeout time.Duration
+}
+
+// Serve runs srv until SIGINT or SIGTERM, then shuts down gracefully with
+// Run. A second signal during shutdown exits immediately.
+func Serve(srv *http.Server, opts Options) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop() // Restore the default handlers so a second signal kills the process
+	}()
+	defer stop()
+
+	return defaultRegistry.Run(ctx, srv, opts)
+}
+
+// Run starts the hooks and srv, and serves until ctx is done or the server
+// fails. It then reports ShuttingDown, keeps serving for opts.Delay, stops
+// accepting connections, gives in-flight requests up to opts.DrainTimeout to
+// finish, and runs the shutdown hooks with a fresh DrainTimeout of their own.
+func (reg *Registry) Run(ctx context.Context, srv *http.Server, opts Options) error {
+	drainTimeout := opts.DrainTimeout
+
+	if err := reg.Start(ctx); err != nil {
+		reg.shutdownWithin(drainTimeout)
+		return err
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		// The server never started or stopped by itself, e.g. the port is taken
+		return errors.Join(err, reg.shutdownWithin(drainTimeout))
+	case <-ctx.Done():
+	}
+
+	reg.beginShutdown()
+	if opts.Delay > 0 {
+		log.Info("shutting down, still serving", "delay", opts.Delay.String())
+		time.Sleep(opts.Delay)
+	}
+	log.Info("shutting down, draining requests", "timeout", drainTimeout.String())
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	drainErr := srv.Shutdown(drainCtx)
+	cancel()
+	if drainErr != nil {
+		log.Warn("drain incomplete, closing remaining connections", "err", drainErr)
+		srv.Close()
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		drainErr = errors.Join(drainErr, err)
+	}
+
+	err := errors.Join(drainErr, reg.shutdownWithin(drainTimeout))
+	if err == nil {
+		log.Info("shutdown complete")
+	}
+	return err
+}
+
+func (reg *Registry) shutdownWithin(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return reg.Shutdown(ctx)
+}
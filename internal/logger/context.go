@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// scope holds the request fields added to log records. Middleware further in
+// fills the route and user ID in after the scope is created, so it is shared
+// by pointer rather than copied into each derived context.
+type scope struct {
+	mu         sync.Mutex
+	requestID  string
+	route      string
+	userID     string
+	skipAccess bool
+}
+
+type contextKey struct{}
+
+// WithRequest returns a context whose log records carry requestID, and the
+// route and user ID once SetRoute and SetUserID fill them in
+func WithRequest(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &scope{requestID: requestID})
+}
+
+func scopeFrom(ctx context.Context) *scope {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(contextKey{}).(*scope)
+	return s
+}
+
+// attrs lists the fields that are set
+func (s *scope) attrs() []slog.Attr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attrs := make([]slog.Attr, 0, 3)
+	if s.requestID != "" {
+		attrs = append(attrs, slog.String("request_id", s.requestID))
+	}
+	if s.route != "" {
+		attrs = append(attrs, slog.String("route", s.route))
+	}
+	if s.userID != "" {
+		attrs = append(attrs, slog.String("user_id", s.userID))
+	}
+	return attrs
+}
+
+// FromContext returns the default logger with the request fields of ctx, as
+// they are when it is called
+func FromContext(ctx context.Context) *slog.Logger {
+	s := scopeFrom(ctx)
+	if s == nil {
+		return slog.Default()
+	}
+	attrs := s.attrs()
+	args := make([]any, len(attrs))
+	for i, attr := range attrs {
+		args[i] = attr
+	}
+	return slog.Default().With(args...)
+}
+
+// RequestID returns the request ID of ctx, or "" outside a request
+func RequestID(ctx context.Context) string {
+	s := scopeFrom(ctx)
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requestID
+}
+
+// Route returns the route pattern the request matched, or ""
+func Route(ctx context.Context) string {
+	s := scopeFrom(ctx)
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.route
+}
+
+// UserID returns the logged-in user's ID for the request, or ""
+func UserID(ctx context.Context) string {
+	s := scopeFrom(ctx)
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.userID
+}
+
+// SetRoute records the route pattern the request matched
+func SetRoute(ctx context.Context, route string) {
+	if s := scopeFrom(ctx); s != nil {
+		s.mu.Lock()
+		s.route = route
+		s.mu.Unlock()
+	}
+}
+
+// SetUserID records the logged-in user, or clears it with ""
+func SetUserID(ctx context.Context, userID string) {
+	if s := scopeFrom(ctx); s != nil {
+		s.mu.Lock()
+		s.userID = userID
+		s.mu.Unlock()
+	}
+}
+
+// SkipAccessLog leaves the request out of the access log, e.g. for health
+// probes polled every few seconds
+func SkipAccessLog(ctx context.Context) {
+	if s := scopeFrom(ctx); s != nil {
+		s.mu.Lock()
+		s.skipAccess = true
+		s.mu.Unlock()
+	}
+}
+
+// AccessLogSkipped reports whether SkipAccessLog was called for the request
+func AccessLogSkipped(ctx context.Context) bool {
+	s := scopeFrom(ctx)
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.skipAccess
+}
@@ -0,0 +1,94 @@
+// Package logger is the framework logger, built on log/slog.
+//
+// Init installs a text or JSON handler chosen by LOG_FORMAT at the LOG_LEVEL
+// threshold as the slog default, which also routes the standard log package
+// through it. Framework packages log through a component logger:
+//
+//	var log = logger.Component("ratelimit")
+//
+//	log.WarnContext(r.Context(), "store error", "err", err)
+//
+// Records logged with a request context carry that request's ID, route and
+// user ID; see WithRequest. Application code can use FromContext to get the
+// same fields on a plain *slog.Logger.
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+
+	"gohst/internal/config"
+)
+
+// Init installs the logger configured by LOG_FORMAT and LOG_LEVEL as the
+// slog default
+func Init() {
+	format, level := config.LOG_FORMAT_TEXT, config.LOG_LEVEL_DEFAULT
+	if config.Log != nil {
+		format, level = config.Log.Format, config.Log.Level
+	}
+	slog.SetDefault(New(os.Stderr, format, level))
+}
+
+// New builds a logger writing to w. Unknown formats fall back to text and
+// unknown levels to info.
+func New(w io.Writer, format string, level string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+	if format == config.LOG_FORMAT_JSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// ParseLevel reads debug, info, warn or error, defaulting to info
+func ParseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// Component returns a logger that tags records with component=name. It
+// resolves the slog default on every record, so it can be assigned to a
+// package-level variable before Init runs.
+func Component(name string) *slog.Logger {
+	return slog.New(&componentHandler{
+		wrap: func(h slog.Handler) slog.Handler {
+			return h.WithAttrs([]slog.Attr{slog.String("component", name)})
+		},
+	})
+}
+
+// componentHandler hands records to the current default handler, adding the
+// request fields from the record's context
+type componentHandler struct {
+	wrap func(slog.Handler) slog.Handler
+}
+
+func (h *componentHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return slog.Default().Handler().Enabled(ctx, level)
+}
+
+func (h *componentHandler) Handle(ctx context.Context, r slog.Record) error {
+	if s := scopeFrom(ctx); s != nil {
+		r.AddAttrs(s.attrs()...)
+	}
+	return h.wrap(slog.Default().Handler()).Handle(ctx, r)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	wrap := h.wrap
+	return &componentHandler{wrap: func(inner slog.Handler) slog.Handler {
+		return wrap(inner).WithAttrs(attrs)
+	}}
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	wrap := h.wrap
+	return &componentHandler{wrap: func(inner slog.Handler) slog.Handler {
+		return wrap(inner).WithGroup(name)
+	}}
+}
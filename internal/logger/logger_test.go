@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// captureDefault installs a JSON logger writing to a buffer as the slog
+// default for the test
+func captureDefault(t *testing.T, level string) *bytes.Buffer {
+	t.Helper()
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	var buf bytes.Buffer
+	slog.SetDefault(New(&buf, "json", level))
+	return &buf
+}
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("decoding %q: %v", buf.String(), err)
+	}
+	return record
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]slog.Level{
+		"debug":   slog.LevelDebug,
+		"info":    slog.LevelInfo,
+		"WARN":    slog.LevelWarn,
+		"error":   slog.LevelError,
+		"":        slog.LevelInfo,
+		"verbose": slog.LevelInfo,
+	}
+	for in, want := range cases {
+		if got := ParseLevel(in); got != want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestNew_TextFallback(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, "xml", "info").Info("hello", "n", 1)
+	if !strings.Contains(buf.String(), "msg=hello n=1") {
+		t.Fatalf("got %q, want text output", buf.String())
+	}
+}
+
+func TestComponent_AddsRequestFields(t *testing.T) {
+	buf := captureDefault(t, "info")
+	log := Component("ratelimit")
+
+	ctx := WithRequest(context.Background(), "req-1")
+	SetRoute(ctx, "GET /users/{id}")
+	SetUserID(ctx, "42")
+	log.InfoContext(ctx, "denied", "key", "login")
+
+	record := decode(t, buf)
+	want := map[string]any{
+		"msg":        "denied",
+		"component":  "ratelimit",
+		"request_id": "req-1",
+		"route":      "GET /users/{id}",
+		"user_id":    "42",
+		"key":        "login",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+}
+
+func TestComponent_ResolvesDefaultLazily(t *testing.T) {
+	log := Component("db")
+	buf := captureDefault(t, "warn")
+
+	log.Info("connected")
+	if buf.Len() != 0 {
+		t.Fatalf("info written below the warn threshold: %q", buf.String())
+	}
+	log.Warn("slow")
+	if record := decode(t, buf); record["component"] != "db" {
+		t.Fatalf("record = %v", record)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	buf := captureDefault(t, "info")
+
+	ctx := WithRequest(context.Background(), "req-2")
+	FromContext(ctx).Info("hello")
+	record := decode(t, buf)
+	if record["request_id"] != "req-2" {
+		t.Fatalf("request_id = %v", record["request_id"])
+	}
+	if _, ok := record["user_id"]; ok {
+		t.Fatal("unset user_id should be left out")
+	}
+
+	if FromContext(context.Background()) != slog.Default() {
+		t.Fatal("outside a request FromContext should return the default logger")
+	}
+}
+
+func TestScope_SharedAcrossDerivedContexts(t *testing.T) {
+	ctx := WithRequest(context.Background(), "req-3")
+	inner := context.WithValue(ctx, struct{}{}, "x")
+
+	SetRoute(inner, "POST /login")
+	SetUserID(inner, "7")
+	SkipAccessLog(inner)
+
+	if Route(ctx) != "POST /login" || UserID(ctx) != "7" || !AccessLogSkipped(ctx) {
+		t.Fatal("fields set on a derived context should be seen by the outer one")
+	}
+	if RequestID(ctx) != "req-3" {
+		t.Fatalf("RequestID = %q", RequestID(ctx))
+	}
+
+	// Outside a request the setters are no-ops
+	bg := context.Background()
+	SetRoute(bg, "GET /")
+	SkipAccessLog(bg)
+	if Route(bg) != "" || AccessLogSkipped(bg) || RequestID(bg) != "" {
+		t.Fatal("background context should carry no request fields")
+	}
+}
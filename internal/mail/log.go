@@ -0,0 +1,30 @@
+package mail
+
+import (
+	"context"
+	"strings"
+)
+
+// LogMailer writes messages to the server log instead of sending them.
+type LogMailer struct {
+	From string
+}
+
+// Send logs the message
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipient
+	}
+
+	body := msg.Text
+	if body == "" {
+		body = msg.HTML
+	}
+	log.InfoContext(ctx, "mail (log driver)",
+		"from", m.From,
+		"to", strings.Join(msg.To, ", "),
+		"subject", msg.Subject,
+		"body", body,
+	)
+	return nil
+}
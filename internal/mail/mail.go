@@ -0,0 +1,105 @@
+// Package mail sends application email through a configurable driver.
+//
+// The driver is chosen by MAIL_DRIVER: "log" prints messages to the server
+// log, which is safe for development, and "smtp" delivers them through the
+// MAIL_SMTP_* server. Apps can replace the default with SetMailer, e.g. in
+// tests or to plug in an API-based provider.
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"gohst/internal/config"
+	"gohst/internal/logger"
+)
+
+var log = logger.Component("mail")
+
+const (
+	DRIVER_LOG  = "log"
+	DRIVER_SMTP = "smtp"
+)
+
+// ErrNoRecipient is returned when a message has no To address.
+var ErrNoRecipient = errors.New("mail: message has no recipient")
+
+// Message is a single email. When both Text and HTML are set the message is
+// sent as multipart/alternative.
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var (
+	mu      sync.RWMutex
+	current Mailer
+)
+
+// New builds the mailer selected by the config. Unknown drivers fall back to
+// the log driver so mail is never silently dropped.
+func New(conf *config.MailConfig) Mailer {
+	if conf == nil {
+		return &LogMailer{}
+	}
+
+	from := FormatAddress(conf.FromName, conf.From)
+	switch conf.Driver {
+	case DRIVER_SMTP:
+		return NewSMTPMailer(conf.SMTP, from)
+	case DRIVER_LOG, "":
+		return &LogMailer{From: from}
+	default:
+		log.Warn("unknown MAIL_DRIVER, falling back", "driver", conf.Driver, "fallback", DRIVER_LOG)
+		return &LogMailer{From: from}
+	}
+}
+
+// Default returns the app-wide mailer, building it from config.Mail on first use.
+func Default() Mailer {
+	mu.RLock()
+	m := current
+	mu.RUnlock()
+	if m != nil {
+		return m
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		current = New(config.Mail)
+	}
+	return current
+}
+
+// SetMailer replaces the app-wide mailer. Pass nil to rebuild it from config.
+func SetMailer(m Mailer) {
+	mu.Lock()
+	current = m
+	mu.Unlock()
+}
+
+// Send delivers a message with the app-wide mailer.
+func Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipient
+	}
+	return Default().Send(ctx, msg)
+}
+
+// FormatAddress renders a display name and address as a From header value.
+func FormatAddress(name, address string) string {
+	if name == "" {
+		return address
+	}
+	return fmt.Sprintf("%q <%s>", name, address)
+}
@@ -0,0 +1,81 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gohst/internal/config"
+)
+
+type recordingMailer struct {
+	sent []Message
+}
+
+func (r *recordingMailer) Send(ctx context.Context, msg Message) error {
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func TestNew_SelectsDriver(t *testing.T) {
+	if _, ok := New(&config.MailConfig{Driver: DRIVER_SMTP, SMTP: &config.SMTPConfig{}}).(*SMTPMailer); !ok {
+		t.Fatal("expected smtp driver")
+	}
+	if _, ok := New(&config.MailConfig{Driver: "carrier-pigeon"}).(*LogMailer); !ok {
+		t.Fatal("expected unknown drivers to fall back to log")
+	}
+}
+
+func TestSend_UsesSetMailer(t *testing.T) {
+	rec := &recordingMailer{}
+	SetMailer(rec)
+	defer SetMailer(nil)
+
+	if err := Send(context.Background(), Message{Subject: "no one"}); !errors.Is(err, ErrNoRecipient) {
+		t.Fatalf("expected ErrNoRecipient, got %v", err)
+	}
+	if err := Send(context.Background(), Message{To: []string{"a@example.com"}, Subject: "hi"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(rec.sent) != 1 || rec.sent[0].Subject != "hi" {
+		t.Fatalf("unexpected sent messages: %+v", rec.sent)
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	data, err := buildMessage(`"App" <noreply@example.com>`, Message{
+		To:      []string{"a@example.com"},
+		Subject: "Reset",
+		Text:    "line one\nline two",
+		HTML:    "<p>hi</p>",
+	}, now)
+	if err != nil {
+		t.Fatalf("buildMessage: %v", err)
+	}
+
+	out := string(data)
+	for _, want := range []string{
+		"To: a@example.com\r\n",
+		"Subject: Reset\r\n",
+		"multipart/alternative",
+		"line one\r\nline two",
+		"Content-Type: text/html; charset=utf-8",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("message missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestBuildMessage_RejectsHeaderInjection(t *testing.T) {
+	_, err := buildMessage("noreply@example.com", Message{
+		To:      []string{"a@example.com"},
+		Subject: "hi\r\nBcc: victim@example.com",
+	}, time.Now())
+	if err == nil {
+		t.Fatal("expected an error for a subject containing CRLF")
+	}
+}
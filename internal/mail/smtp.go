@@ -0,0 +1,181 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gohst/internal/config"
+)
+
+// SMTP_IMPLICIT_TLS_PORT is the submissions port, which starts with TLS
+// instead of upgrading the connection with STARTTLS.
+const SMTP_IMPLICIT_TLS_PORT = 465
+
+// SMTPMailer delivers messages through an SMTP server. When TLS is enabled
+// the connection must be encrypted, either implicitly on port 465 or with
+// STARTTLS; otherwise sending fails rather than leaking credentials.
+type SMTPMailer struct {
+	conf *config.SMTPConfig
+	from string
+}
+
+// NewSMTPMailer creates an SMTP mailer sending as from
+func NewSMTPMailer(conf *config.SMTPConfig, from string) *SMTPMailer {
+	if conf == nil {
+		conf = &config.SMTPConfig{Port: config.MAIL_SMTP_PORT_DEFAULT}
+	}
+	return &SMTPMailer{conf: conf, from: from}
+}
+
+// Send delivers the message
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipient
+	}
+
+	sender, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("mail: invalid from address %q: %w", m.from, err)
+	}
+	data, err := buildMessage(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.conf.User != "" {
+		auth := smtp.PlainAuth("", m.conf.User, m.conf.Password, m.conf.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("mail: smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(sender.Address); err != nil {
+		return fmt.Errorf("mail: smtp MAIL FROM: %w", err)
+	}
+	for _, to := range msg.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("mail: invalid recipient %q: %w", to, err)
+		}
+		if err := client.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("mail: smtp RCPT TO %s: %w", addr.Address, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("mail: smtp DATA: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("mail: smtp write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mail: smtp DATA: %w", err)
+	}
+	return client.Quit()
+}
+
+// dial connects to the server, negotiating TLS when it is enabled
+func (m *SMTPMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.conf.Host, strconv.Itoa(m.conf.Port))
+	tlsConfig := &tls.Config{ServerName: m.conf.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	if m.conf.TLS && m.conf.Port == SMTP_IMPLICIT_TLS_PORT {
+		dialer := &tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mail: smtp dial %s: %w", addr, err)
+	}
+
+	client, err := smtp.NewClient(conn, m.conf.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("mail: smtp handshake: %w", err)
+	}
+
+	if m.conf.TLS && m.conf.Port != SMTP_IMPLICIT_TLS_PORT {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("mail: %s does not support STARTTLS", addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("mail: smtp STARTTLS: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// buildMessage renders the headers and body of a message
+func buildMessage(from string, msg Message, now time.Time) ([]byte, error) {
+	for _, header := range append([]string{from, msg.Subject}, msg.To...) {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, fmt.Errorf("mail: header contains a line break")
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	switch {
+	case msg.Text != "" && msg.HTML != "":
+		boundary := randomBoundary()
+		fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+		writePart(&buf, boundary, "text/plain", msg.Text)
+		writePart(&buf, boundary, "text/html", msg.HTML)
+		fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	case msg.HTML != "":
+		buf.WriteString("Content-Type: text/html; charset=utf-8\r\n\r\n")
+		buf.WriteString(normalizeNewlines(msg.HTML))
+	default:
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+		buf.WriteString(normalizeNewlines(msg.Text))
+	}
+	return buf.Bytes(), nil
+}
+
+func writePart(buf *bytes.Buffer, boundary, contentType, body string) {
+	fmt.Fprintf(buf, "--%s\r\n", boundary)
+	fmt.Fprintf(buf, "Content-Type: %s; charset=utf-8\r\n\r\n", contentType)
+	buf.WriteString(normalizeNewlines(body))
+	buf.WriteString("\r\n")
+}
+
+// normalizeNewlines converts bare line feeds to the CRLF SMTP requires
+func normalizeNewlines(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+func randomBoundary() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
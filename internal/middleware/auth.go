@@ -8,7 +8,7 @@ import (
 )
 
 // Auth middleware ensures that requests are from authenticated users
-// If not authenticated, redirects to login page
+// If not authenticated, remembers the page and redirects to the login page
 func Auth(next http.Handler) http.Handler {
     return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
         sess := session.FromContext(r.Context())
@@ -16,10 +16,11 @@ func Auth(next http.Handler) http.Handler {
         // Check if user is authenticated using existing auth function
         if !auth.IsAuthenticated(sess) {
             // Store intended destination for post-login redirect
+            auth.RememberIntendedURL(sess, r)
             sess.SetFlash("error", "Please log in to access this page")
 
             // Redirect to login page
-            http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+            http.Redirect(w, r, auth.LoginPath(), http.StatusSeeOther)
             return
         }
 
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"gohst/internal/auth"
+	"gohst/internal/session"
+)
+
+// BearerAuth authenticates API requests with an access token sent as
+// "Authorization: Bearer <token>". The auth data is put in an ephemeral
+// session, so handlers read it with session.FromContext and auth.GetAuthData
+// just like browser routes. Requests without a valid token get a JSON 401.
+// Use it instead of SessionMiddleware and CSRF on API route groups.
+func BearerAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := auth.BearerToken(r)
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			jsonError(w, http.StatusUnauthorized, "An access token is required")
+			return
+		}
+
+		sess, err := auth.AuthenticateToken(r.Context(), token)
+		if err != nil {
+			if !errors.Is(err, auth.ErrInvalidAccessToken) {
+				log.ErrorContext(r.Context(), "authenticating access token", "err", err)
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			jsonError(w, http.StatusUnauthorized, "The access token is invalid or has expired")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(session.WithSession(r.Context(), sess)))
+	})
+}
+
+// Ability allows requests whose access token was granted every listed
+// ability, e.g. Ability("posts:write"). Browser sessions pass, since
+// abilities only narrow what tokens can do. Place after BearerAuth or Auth.
+func Ability(abilities ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sess := session.FromContext(r.Context())
+			if sess == nil || !auth.IsAuthenticated(sess) {
+				jsonError(w, http.StatusUnauthorized, "Authentication required")
+				return
+			}
+
+			for _, ability := range abilities {
+				if !auth.TokenCan(sess, ability) {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="insufficient_scope", scope="`+strings.Join(abilities, " ")+`"`)
+					jsonError(w, http.StatusForbidden, "The access token lacks the "+ability+" ability")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// jsonError writes an API error response
+func jsonError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":   http.StatusText(status),
+		"message": message,
+	})
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"gohst/internal/auth"
+	"gohst/internal/session"
+)
+
+// Can enforces that the authenticated user has every listed permission,
+// e.g. Can("users.edit"). Guests are sent to the login page; users without
+// the permission get a 403.
+func Can(permissions ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sess := session.FromContext(r.Context())
+			if !auth.IsAuthenticated(sess) {
+				auth.RememberIntendedURL(sess, r)
+				sess.SetFlash("error", "Please log in to access this page")
+				http.Redirect(w, r, auth.LoginPath(), http.StatusSeeOther)
+				return
+			}
+
+			for _, permission := range permissions {
+				if !auth.Can(r.Context(), sess, permission) {
+					http.Error(w, "Forbidden", http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
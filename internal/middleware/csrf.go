@@ -5,7 +5,6 @@ import (
 	"gohst/internal/config"
 	"gohst/internal/session"
 	"gohst/internal/utils"
-	"log"
 	"net/http"
 )
 
@@ -46,10 +45,7 @@ func CSRF(next http.Handler) http.Handler {
 			// Constant-time comparison to prevent timing attacks
 			sessionToken, _ := token.(string)
 			if subtle.ConstantTimeCompare([]byte(sessionToken), []byte(requestToken)) != 1 {
-				app := config.GetAppConfig()
-				if app.IsDevelopment() {
-					log.Println("CSRF token mismatch")
-				}
+				log.DebugContext(r.Context(), "CSRF token mismatch")
 				http.Error(w, "CSRF token invalid", http.StatusForbidden)
 				return
 			}
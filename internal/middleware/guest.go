@@ -17,8 +17,8 @@ func Guest(next http.Handler) http.Handler {
             // Optional: add a friendly message
             sess.SetFlash("info", "You are already logged in")
 
-            // Redirect to dashboard or home
-            http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+            // Redirect to AUTH_GUEST_REDIRECT_PATH
+            http.Redirect(w, r, auth.GuestRedirectPath(), http.StatusSeeOther)
             return
         }
 
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"gohst/internal/auth"
+	"gohst/internal/session"
+)
+
+// NotImpersonating refuses sensitive actions, such as changing credentials
+// or minting tokens, while an admin is viewing the app as another user.
+func NotImpersonating(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess := session.FromContext(r.Context())
+		if sess != nil && auth.IsImpersonating(sess) {
+			http.Error(w, "Not available while impersonating a user", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
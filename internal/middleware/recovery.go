@@ -2,7 +2,6 @@ package middleware
 
 import (
 	"fmt"
-	"log"
 	"net/http"
 	"runtime/debug"
 
@@ -26,7 +25,12 @@ func Recover(next http.Handler) http.Handler {
 				stack := debug.Stack()
 
 				// Always log the full panic + stack trace server-side regardless of environment
-				log.Printf("[PANIC RECOVERED] %s %s — %v\n%s", r.Method, r.URL.Path, err, stack)
+				log.ErrorContext(r.Context(), "panic recovered",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"panic", fmt.Sprint(err),
+					"stack", string(stack),
+				)
 
 				app := config.GetAppConfig()
 				var errorMsg string
@@ -57,7 +61,7 @@ func RecoverGoroutine(name string, fn func()) {
 	defer func() {
 		if err := recover(); err != nil {
 			stack := debug.Stack()
-			log.Printf("[PANIC RECOVERED] goroutine %q — %v\n%s", name, err, stack)
+			log.Error("panic recovered", "goroutine", name, "panic", fmt.Sprint(err), "stack", string(stack))
 		}
 	}()
 	fn()
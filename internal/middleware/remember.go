@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"gohst/internal/auth"
+	"gohst/internal/session"
+)
+
+// Remember logs guests back in from their remember-me cookie once their
+// session has ended. It must run after SessionMiddleware and before
+// middleware that checks authentication, such as Auth and Guest.
+func Remember(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess := session.FromContext(r.Context())
+
+		if _, err := auth.Restore(sess, r); err != nil {
+			if errors.Is(err, auth.ErrRememberTokenTheft) {
+				log.WarnContext(r.Context(), "possible remember-me token theft; revoked the user's tokens and sessions")
+				sess.SetFlash("login_error", "For your security you have been logged out everywhere. Please log in again.")
+			} else {
+				log.ErrorContext(r.Context(), "restoring remembered login", "err", err)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
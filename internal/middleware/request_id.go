@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"gohst/internal/logger"
+)
+
+// REQUEST_ID_HEADER carries the request ID in and out. An incoming ID from a
+// proxy or load balancer is kept so one ID follows the request across services.
+const REQUEST_ID_HEADER = "X-Request-ID"
+
+// MAX_REQUEST_ID_LENGTH caps accepted IDs; longer ones are replaced
+const MAX_REQUEST_ID_LENGTH = 128
+
+// RequestID gives every request an ID, taken from X-Request-ID when the
+// client sent a well-formed one and generated otherwise. The ID is echoed in
+// the response header and added to the request's log records; read it with
+// logger.RequestID. RegisterRouter applies it to every request.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(REQUEST_ID_HEADER)
+		if !validRequestID(id) {
+			id = newRequestID()
+			r.Header.Set(REQUEST_ID_HEADER, id)
+		}
+		w.Header().Set(REQUEST_ID_HEADER, id)
+
+		next.ServeHTTP(w, r.WithContext(logger.WithRequest(r.Context(), id)))
+	})
+}
+
+// validRequestID accepts IDs made of letters, digits and - _ . : only, so a
+// client cannot smuggle anything odd into logs or headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > MAX_REQUEST_ID_LENGTH {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -7,30 +7,21 @@ import (
 	"gohst/internal/session"
 )
 
-// Role enforces that the authenticated user has one of the allowed roles.
+// Role enforces that the authenticated user has one of the allowed roles,
+// directly or through role inheritance. Prefer Can for new routes; roles
+// are coarse and permissions say what a route actually needs.
 func Role(allowed ...string) func(http.Handler) http.Handler {
-	allowedSet := make(map[string]struct{}, len(allowed))
-	for _, role := range allowed {
-		allowedSet[role] = struct{}{}
-	}
-
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			sess := session.FromContext(r.Context())
-			authData := auth.GetAuthData(sess)
-			if authData == nil {
+			if !auth.IsAuthenticated(sess) {
+				auth.RememberIntendedURL(sess, r)
 				sess.SetFlash("error", "Please log in to access this page")
-				http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
-				return
-			}
-
-			rp, ok := authData.(auth.RoleProvider)
-			if !ok || rp.RoleName() == "" {
-				http.Error(w, "Forbidden", http.StatusForbidden)
+				http.Redirect(w, r, auth.LoginPath(), http.StatusSeeOther)
 				return
 			}
 
-			if _, ok := allowedSet[rp.RoleName()]; !ok {
+			if !auth.HasRole(sess, allowed...) {
 				http.Error(w, "Forbidden", http.StatusForbidden)
 				return
 			}
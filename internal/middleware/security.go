@@ -2,7 +2,6 @@ package middleware
 
 import (
 	"io"
-	"log"
 	"net/http"
 
 	"gohst/internal/config"
@@ -91,7 +90,7 @@ func CSPReportHandler() http.Handler {
 		}
 		defer r.Body.Close()
 
-		log.Printf("[CSP Violation] %s", string(body))
+		log.WarnContext(r.Context(), "CSP violation", "report", string(body))
 		w.WriteHeader(http.StatusNoContent)
 	})
 }
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"gohst/internal/auth"
+	"gohst/internal/session"
+)
+
+// TwoFactorSetupPath is where users who must enroll in two-factor
+// authentication are sent by TwoFactorSetup. The app router sets it from the
+// enrollment route's name; while it is empty those users are refused.
+var TwoFactorSetupPath string
+
+// TwoFactorSetup sends authenticated users who are required to use
+// two-factor authentication, such as admins when AUTH_TWO_FACTOR_REQUIRE_ADMIN
+// is set, to the enrollment page until they have enrolled.
+func TwoFactorSetup(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess := session.FromContext(r.Context())
+
+		if auth.IsAuthenticated(sess) && auth.NeedsTwoFactorSetup(sess) {
+			if TwoFactorSetupPath == "" {
+				http.Error(w, "Two-factor authentication setup required", http.StatusForbidden)
+				return
+			}
+			sess.SetFlash("error", "Please set up two-factor authentication to continue")
+			http.Redirect(w, r, TwoFactorSetupPath, http.StatusSeeOther)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
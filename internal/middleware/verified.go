@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"gohst/internal/auth"
+	"gohst/internal/session"
+)
+
+// VerifyNoticePath is where unverified users are sent by Verified. The app
+// router sets it from the notice route's name; while it is empty those users
+// are refused.
+var VerifyNoticePath string
+
+// Verified middleware ensures the authenticated user has verified their email.
+// Use it after Auth; unverified users are redirected to the verification notice.
+func Verified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess := session.FromContext(r.Context())
+
+		if auth.IsAuthenticated(sess) && !auth.IsVerified(sess) {
+			if VerifyNoticePath == "" {
+				http.Error(w, "Email verification required", http.StatusForbidden)
+				return
+			}
+			sess.SetFlash("error", "Please verify your email address to access this page")
+			http.Redirect(w, r, VerifyNoticePath, http.StatusSeeOther)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,90 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gohst/internal/session"
+)
+
+// FLOW_TTL is how long a user has to finish logging in at the provider
+const FLOW_TTL = 10 * time.Minute
+
+// flow is the pending login kept in the session between the redirect to the
+// provider and the callback
+type flow struct {
+	Provider  string
+	State     string
+	Nonce     string
+	Verifier  string
+	StartedAt time.Time
+}
+
+var flowKey = session.NewKey[*flow]("_gohst_oidc_flow_")
+
+func init() {
+	gob.Register(&flow{})
+}
+
+// Begin starts a login: it stores a fresh state, nonce and PKCE verifier in
+// the session and returns the provider URL to redirect the user to
+func Begin(ctx context.Context, sess *session.Session, p *Provider) (string, error) {
+	f := &flow{
+		Provider:  p.Name,
+		State:     randomString(),
+		Nonce:     randomString(),
+		Verifier:  randomString(),
+		StartedAt: p.now(),
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, f.State, f.Nonce, f.Verifier)
+	if err != nil {
+		return "", err
+	}
+	if err := flowKey.Set(sess, f); err != nil {
+		return "", err
+	}
+	return authURL, nil
+}
+
+// Complete finishes a login from the provider's callback request. The
+// pending login is consumed whatever the outcome, so a callback works once.
+// It returns the validated ID token claims.
+func Complete(ctx context.Context, sess *session.Session, p *Provider, r *http.Request) (*Claims, error) {
+	f, ok := flowKey.Get(sess)
+	flowKey.Remove(sess)
+
+	query := r.URL.Query()
+	if !ok || f.Provider != p.Name || p.now().Sub(f.StartedAt) > FLOW_TTL ||
+		subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(f.State)) != 1 {
+		return nil, ErrInvalidState
+	}
+
+	if reason := query.Get("error"); reason != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrProviderError, reason, query.Get("error_description"))
+	}
+	code := query.Get("code")
+	if code == "" {
+		return nil, fmt.Errorf("%w: no authorization code", ErrProviderError)
+	}
+
+	token, err := p.Exchange(ctx, code, f.Verifier)
+	if err != nil {
+		return nil, err
+	}
+	return p.VerifyIDToken(ctx, token.IDToken, f.Nonce)
+}
+
+// randomString returns 32 random bytes in base64url, which is also a valid
+// PKCE verifier
+func randomString() string {
+	b := make([]byte, 32)
+	rand.Read(b) // crypto/rand.Read never fails
+	return base64.RawURLEncoding.EncodeToString(b)
+}
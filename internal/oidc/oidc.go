@@ -0,0 +1,253 @@
+// Package oidc implements OpenID Connect login with the authorization code
+// flow. Logins are protected with PKCE (S256), state and nonce, and ID tokens
+// are validated against the keys the provider publishes in its JWKS.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DISCOVERY_PATH = "/.well-known/openid-configuration"
+
+	// CLOCK_SKEW is the leeway allowed on ID token timestamps
+	CLOCK_SKEW = time.Minute
+
+	// JWKS_MIN_REFRESH limits how often the keys are fetched again when a
+	// token names a key we do not know, e.g. after the provider rotated keys
+	JWKS_MIN_REFRESH = time.Minute
+
+	// MAX_RESPONSE_BYTES caps the provider responses we read
+	MAX_RESPONSE_BYTES = 1 << 20
+)
+
+var (
+	// ErrUnknownProvider is returned for provider names that are not configured.
+	ErrUnknownProvider = errors.New("oidc: unknown provider")
+	// ErrInvalidState is returned when a callback does not belong to a login
+	// started in this session, or that login has expired.
+	ErrInvalidState = errors.New("oidc: invalid or expired login state")
+	// ErrInvalidIDToken is returned for ID tokens that fail validation.
+	ErrInvalidIDToken = errors.New("oidc: invalid ID token")
+	// ErrProviderError is returned when the provider refuses the login or the
+	// code exchange.
+	ErrProviderError = errors.New("oidc: provider error")
+)
+
+// Config describes a provider registered with the app
+type Config struct {
+	// Name identifies the provider in URLs and the user_identities table
+	Name        string
+	DisplayName string
+	// Issuer is the provider's issuer URL, where discovery is served
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Metadata is the part of the provider's discovery document we use
+type Metadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	UserinfoEndpoint      string   `json:"userinfo_endpoint"`
+	SigningAlgs           []string `json:"id_token_signing_alg_values_supported"`
+	TokenAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// TokenResponse is the token endpoint's answer to a code exchange
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// Provider talks to one OpenID Connect provider. Its discovery document and
+// keys are fetched on first use and cached.
+type Provider struct {
+	Config
+	HTTPClient *http.Client
+
+	now func() time.Time
+
+	mu            sync.Mutex
+	metadata      *Metadata
+	keys          []jsonWebKey
+	keysFetchedAt time.Time
+}
+
+// NewProvider creates a provider from its config
+func NewProvider(conf Config) *Provider {
+	conf.Issuer = strings.TrimRight(conf.Issuer, "/")
+	if len(conf.Scopes) == 0 {
+		conf.Scopes = []string{"openid", "email", "profile"}
+	}
+	if !slices.Contains(conf.Scopes, "openid") {
+		conf.Scopes = append([]string{"openid"}, conf.Scopes...)
+	}
+	if conf.DisplayName == "" {
+		conf.DisplayName = conf.Name
+	}
+	return &Provider{
+		Config:     conf,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+	}
+}
+
+// WithClock replaces the time source, for tests
+func (p *Provider) WithClock(now func() time.Time) *Provider {
+	p.now = now
+	return p
+}
+
+// Metadata returns the provider's discovery document. The issuer it names
+// must be the configured one, as OpenID Connect Discovery requires.
+func (p *Provider) Metadata(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	cached := p.metadata
+	p.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	var metadata Metadata
+	if err := p.getJSON(ctx, p.Issuer+DISCOVERY_PATH, &metadata); err != nil {
+		return nil, fmt.Errorf("oidc: discovery for %s: %w", p.Name, err)
+	}
+	if strings.TrimRight(metadata.Issuer, "/") != p.Issuer {
+		return nil, fmt.Errorf("oidc: discovery for %s names issuer %q", p.Name, metadata.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc: discovery for %s is missing endpoints", p.Name)
+	}
+
+	p.mu.Lock()
+	p.metadata = &metadata
+	p.mu.Unlock()
+	return &metadata, nil
+}
+
+// AuthCodeURL returns the provider URL that starts a login. The verifier is
+// sent as its S256 challenge.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(p.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return metadata.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange trades an authorization code for tokens
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (*TokenResponse, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	// client_secret_basic is the default every provider must support
+	usePost := len(metadata.TokenAuthMethods) > 0 &&
+		!slices.Contains(metadata.TokenAuthMethods, "client_secret_basic") &&
+		slices.Contains(metadata.TokenAuthMethods, "client_secret_post")
+	if usePost || p.ClientSecret == "" {
+		form.Set("client_id", p.ClientID)
+	}
+	if usePost && p.ClientSecret != "" {
+		form.Set("client_secret", p.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !usePost && p.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request to %s: %w", p.Name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_BYTES))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		_ = json.Unmarshal(body, &failure)
+		return nil, fmt.Errorf("%w: token endpoint answered %d %s %s", ErrProviderError, resp.StatusCode, failure.Error, failure.Description)
+	}
+
+	var token TokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("oidc: token response from %s: %w", p.Name, err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in the token response", ErrProviderError)
+	}
+	return &token, nil
+}
+
+// getJSON fetches a JSON document from the provider
+func (p *Provider) getJSON(ctx context.Context, endpoint string, dest any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, MAX_RESPONSE_BYTES)).Decode(dest)
+}
@@ -0,0 +1,269 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"gohst/internal/config"
+	"gohst/internal/oidc/oidctest"
+	"gohst/internal/session"
+)
+
+const testCallback = "http://app.test/auth/oidc/stub/callback"
+
+type testAppConfig struct{}
+
+func (testAppConfig) GetURL() string          { return "http://app.test" }
+func (testAppConfig) GetDistPath() string     { return "static/dist" }
+func (testAppConfig) IsProduction() bool      { return false }
+func (testAppConfig) IsDevelopment() bool     { return false }
+func (testAppConfig) IsMaintenanceMode() bool { return false }
+
+// harness is a stub provider, a client for it and a session store, so tests
+// can run the browser's side of a login
+type harness struct {
+	t      *testing.T
+	stub   *oidctest.Provider
+	client *Provider
+	sm     *session.SessionManager
+	cookie *http.Cookie
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	config.RegisterAppConfig(testAppConfig{})
+	config.Session = &config.SessionConfig{Length: config.SESSION_LENGTH_DEFAULT, Name: "_test_session"}
+
+	stub := oidctest.NewProvider("gohst-client", "s3cret")
+	t.Cleanup(stub.Close)
+
+	memory, storeType := session.NewMemorySessionManager("_test_session")
+	return &harness{
+		t:    t,
+		stub: stub,
+		client: NewProvider(Config{
+			Name:         "stub",
+			Issuer:       stub.Issuer(),
+			ClientID:     "gohst-client",
+			ClientSecret: "s3cret",
+			RedirectURL:  testCallback,
+		}),
+		sm: session.NewSessionManagerWithStore(memory, storeType, "_test_session"),
+	}
+}
+
+// serve runs fn in a request that carries the session cookie
+func (h *harness) serve(target string, fn func(sess *session.Session, r *http.Request)) {
+	h.t.Helper()
+	handler := h.sm.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fn(session.FromContext(r.Context()), r)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if h.cookie != nil {
+		req.AddCookie(h.cookie)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "_test_session" {
+			h.cookie = c
+		}
+	}
+}
+
+// begin starts a login and returns the provider's login URL
+func (h *harness) begin() string {
+	h.t.Helper()
+	var authURL string
+	h.serve("/auth/oidc/stub", func(sess *session.Session, r *http.Request) {
+		var err error
+		if authURL, err = Begin(r.Context(), sess, h.client); err != nil {
+			h.t.Fatalf("Begin: %v", err)
+		}
+	})
+	return authURL
+}
+
+// complete sends the callback to the app and returns the login result
+func (h *harness) complete(callback *url.URL) (*Claims, error) {
+	h.t.Helper()
+	var (
+		claims *Claims
+		err    error
+	)
+	h.serve(callback.RequestURI(), func(sess *session.Session, r *http.Request) {
+		claims, err = Complete(r.Context(), sess, h.client, r)
+	})
+	return claims, err
+}
+
+// login runs a whole login through the stub provider
+func (h *harness) login() (*Claims, error) {
+	h.t.Helper()
+	callback, err := h.stub.Authorize(h.begin())
+	if err != nil {
+		h.t.Fatalf("Authorize: %v", err)
+	}
+	return h.complete(callback)
+}
+
+func TestLogin_EndToEnd(t *testing.T) {
+	h := newHarness(t)
+
+	authURL := h.begin()
+	parsed, _ := url.Parse(authURL)
+	q := parsed.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("state") == "" || q.Get("nonce") == "" {
+		t.Fatalf("login URL lacks PKCE, state or nonce: %s", authURL)
+	}
+	if q.Get("redirect_uri") != testCallback || q.Get("scope") != "openid email profile" {
+		t.Fatalf("unexpected login URL: %s", authURL)
+	}
+
+	callback, err := h.stub.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	claims, err := h.complete(callback)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if claims.Subject != "stub-user-1" || claims.Email != "stub.user@example.com" || !bool(claims.EmailVerified) {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	// The callback cannot be replayed
+	if _, err := h.complete(callback); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState on replay, got %v", err)
+	}
+}
+
+func TestComplete_RejectsForeignState(t *testing.T) {
+	h := newHarness(t)
+	callback, err := h.stub.Authorize(h.begin())
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+
+	q := callback.Query()
+	q.Set("state", "forged")
+	callback.RawQuery = q.Encode()
+	if _, err := h.complete(callback); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
+
+func TestComplete_RejectsCallbackFromAnotherSession(t *testing.T) {
+	h := newHarness(t)
+	callback, err := h.stub.Authorize(h.begin())
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+
+	// A victim who never started a login is sent the attacker's callback
+	h.cookie = nil
+	if _, err := h.complete(callback); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
+
+func TestComplete_ProviderError(t *testing.T) {
+	h := newHarness(t)
+	authURL, _ := url.Parse(h.begin())
+
+	callback, _ := url.Parse(testCallback)
+	callback.RawQuery = url.Values{
+		"state": {authURL.Query().Get("state")},
+		"error": {"access_denied"},
+	}.Encode()
+	if _, err := h.complete(callback); !errors.Is(err, ErrProviderError) {
+		t.Fatalf("expected ErrProviderError, got %v", err)
+	}
+}
+
+func TestComplete_PKCEMismatch(t *testing.T) {
+	h := newHarness(t)
+	callback, err := h.stub.Authorize(h.begin())
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+
+	// Swap in a flow with the same state but another verifier, as if the
+	// code had been stolen and redeemed elsewhere
+	h.serve("/", func(sess *session.Session, r *http.Request) {
+		f, _ := flowKey.Get(sess)
+		f.Verifier = "another-verifier"
+		flowKey.Set(sess, f)
+	})
+	if _, err := h.complete(callback); !errors.Is(err, ErrProviderError) {
+		t.Fatalf("expected the code exchange to fail, got %v", err)
+	}
+}
+
+func TestVerifyIDToken_RejectsBadClaims(t *testing.T) {
+	for name, tamper := range map[string]func(map[string]any){
+		"audience": func(c map[string]any) { c["aud"] = "someone-else" },
+		"issuer":   func(c map[string]any) { c["iss"] = "https://evil.example.com" },
+		"nonce":    func(c map[string]any) { c["nonce"] = "replayed" },
+		"expired":  func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"future":   func(c map[string]any) { c["iat"] = time.Now().Add(time.Hour).Unix() },
+		"subject":  func(c map[string]any) { delete(c, "sub") },
+		"azp": func(c map[string]any) {
+			c["aud"] = []string{"gohst-client", "other"}
+			c["azp"] = "other"
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := newHarness(t)
+			h.stub.Claims = tamper
+			if _, err := h.login(); !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("expected ErrInvalidIDToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIDToken_Signature(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+
+	// Learn the keys, then rotate: the unknown key ID triggers a refetch
+	if _, err := h.login(); err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	h.stub.RotateKey()
+	now := time.Now()
+	h.client.WithClock(func() time.Time { return now.Add(2 * JWKS_MIN_REFRESH) })
+	if _, err := h.login(); err != nil {
+		t.Fatalf("login after key rotation: %v", err)
+	}
+
+	for _, raw := range []string{
+		"not-a-jwt",
+		"eyJhbGciOiJub25lIn0.eyJzdWIiOiJ4In0.",
+		"eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJ4In0.c2ln",
+	} {
+		if _, err := h.client.VerifyIDToken(ctx, raw, "nonce"); !errors.Is(err, ErrInvalidIDToken) {
+			t.Errorf("%s: expected ErrInvalidIDToken, got %v", raw, err)
+		}
+	}
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	h := newHarness(t)
+
+	// Same server under another name: discovery answers with its own issuer
+	issuer := strings.Replace(h.stub.Issuer(), "127.0.0.1", "localhost", 1)
+	p := NewProvider(Config{Name: "stub", Issuer: issuer, ClientID: "gohst-client"})
+	_, err := p.Metadata(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "names issuer") {
+		t.Fatalf("expected an issuer mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package oidc
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"gohst/internal/config"
+	"gohst/internal/logger"
+	"gohst/internal/routes"
+)
+
+var log = logger.Component("oidc")
+
+// CALLBACK_ROUTE names the route providers send users back to. It takes the
+// provider name as its "provider" parameter.
+const CALLBACK_ROUTE = "oidc.callback"
+
+var (
+	mu        sync.RWMutex
+	providers []*Provider
+	loaded    bool
+)
+
+// Providers returns the configured providers in OIDC_PROVIDERS order,
+// building them from config.OIDC on first use
+func Providers() []*Provider {
+	mu.RLock()
+	if loaded {
+		defer mu.RUnlock()
+		return providers
+	}
+	mu.RUnlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !loaded {
+		providers = fromConfig(config.OIDC)
+		loaded = true
+	}
+	return providers
+}
+
+// Get returns the provider with the given name
+func Get(name string) (*Provider, error) {
+	for _, p := range Providers() {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+}
+
+// SetProviders replaces the configured providers. Pass none to rebuild them
+// from config on next use.
+func SetProviders(ps ...*Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers = ps
+	loaded = len(ps) > 0
+}
+
+func fromConfig(conf *config.OIDCConfig) []*Provider {
+	if conf == nil {
+		return nil
+	}
+	for _, err := range conf.Skipped {
+		log.Warn("skipping provider", "err", err)
+	}
+	if len(conf.Providers) == 0 {
+		return nil
+	}
+
+	baseURL := strings.TrimRight(config.GetAppConfig().GetURL(), "/")
+	ps := make([]*Provider, 0, len(conf.Providers))
+	for _, pc := range conf.Providers {
+		ps = append(ps, NewProvider(Config{
+			Name:         pc.Name,
+			DisplayName:  pc.DisplayName,
+			Issuer:       pc.Issuer,
+			ClientID:     pc.ClientID,
+			ClientSecret: pc.ClientSecret,
+			RedirectURL:  baseURL + routes.URL(CALLBACK_ROUTE, "provider", pc.Name),
+			Scopes:       pc.Scopes,
+		}))
+	}
+	return ps
+}
@@ -0,0 +1,347 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	_ "crypto/sha512" // SHA-384 and SHA-512 for RS384, RS512 and friends
+)
+
+// Claims are the ID token claims the app uses
+type Claims struct {
+	Issuer          string   `json:"iss"`
+	Subject         string   `json:"sub"`
+	Audience        audience `json:"aud"`
+	AuthorizedParty string   `json:"azp"`
+	Expiry          int64    `json:"exp"`
+	IssuedAt        int64    `json:"iat"`
+	Nonce           string   `json:"nonce"`
+
+	Email         string      `json:"email"`
+	EmailVerified lenientBool `json:"email_verified"`
+	Name          string      `json:"name"`
+	GivenName     string      `json:"given_name"`
+	FamilyName    string      `json:"family_name"`
+	Picture       string      `json:"picture"`
+}
+
+// audience accepts the aud claim as a string or a list of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// lenientBool accepts true, false and their string forms, which some
+// providers send for email_verified
+type lenientBool bool
+
+func (l *lenientBool) UnmarshalJSON(b []byte) error {
+	switch strings.Trim(string(b), `"`) {
+	case "true":
+		*l = true
+	case "false", "null", "":
+		*l = false
+	default:
+		return fmt.Errorf("invalid boolean %s", b)
+	}
+	return nil
+}
+
+// jsonWebKey is a public key from the provider's JWKS
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+
+	key crypto.PublicKey
+}
+
+// signingAlgs maps the accepted JWS algorithms to their hash. HMAC and
+// "none" are never accepted.
+var signingAlgs = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+}
+
+// CodeChallenge returns the S256 PKCE challenge of a verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyIDToken checks the ID token's signature against the provider's
+// keys and its issuer, audience, expiry and nonce, and returns its claims
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidIDToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidIDToken)
+	}
+	hash, ok := signingAlgs[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidIDToken, header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidIDToken)
+	}
+
+	key, err := p.signingKey(ctx, header.Kid, header.Alg)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(key, header.Alg, hash, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidIDToken)
+	}
+	if err := p.validateClaims(&claims, nonce); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	return &claims, nil
+}
+
+// validateClaims applies the ID token validation rules of OpenID Connect
+// Core section 3.1.3.7
+func (p *Provider) validateClaims(claims *Claims, nonce string) error {
+	now := p.now()
+
+	if strings.TrimRight(claims.Issuer, "/") != p.Issuer {
+		return fmt.Errorf("issued by %q", claims.Issuer)
+	}
+	if claims.Subject == "" {
+		return errors.New("no subject")
+	}
+	if !slices.Contains(claims.Audience, p.ClientID) {
+		return errors.New("not issued for this client")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != "" && claims.AuthorizedParty != p.ClientID {
+		return errors.New("issued to another party")
+	}
+	if claims.Expiry == 0 || now.After(time.Unix(claims.Expiry, 0).Add(CLOCK_SKEW)) {
+		return errors.New("expired")
+	}
+	if claims.IssuedAt == 0 || time.Unix(claims.IssuedAt, 0).After(now.Add(CLOCK_SKEW)) {
+		return errors.New("issued in the future")
+	}
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return errors.New("nonce mismatch")
+	}
+	return nil
+}
+
+// signingKey returns the key that signed a token, refetching the JWKS once
+// per JWKS_MIN_REFRESH when the key is unknown
+func (p *Provider) signingKey(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	key := findKey(p.keys, kid, alg)
+	stale := p.now().Sub(p.keysFetchedAt) >= JWKS_MIN_REFRESH
+	p.mu.Unlock()
+	if key != nil {
+		return key, nil
+	}
+	if !stale {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidIDToken, kid)
+	}
+
+	keys, err := p.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.keysFetchedAt = p.now()
+	p.mu.Unlock()
+
+	if key = findKey(keys, kid, alg); key == nil {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidIDToken, kid)
+	}
+	return key, nil
+}
+
+// fetchKeys downloads and parses the provider's JWKS, skipping keys of
+// types we cannot use
+func (p *Provider) fetchKeys(ctx context.Context) ([]jsonWebKey, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, metadata.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc: keys for %s: %w", p.Name, err)
+	}
+
+	keys := make([]jsonWebKey, 0, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		jwk.key = key
+		keys = append(keys, jwk)
+	}
+	return keys, nil
+}
+
+// findKey picks the key with the given ID, or the only key that fits the
+// algorithm when the token names none
+func findKey(keys []jsonWebKey, kid, alg string) crypto.PublicKey {
+	var candidates []jsonWebKey
+	for _, jwk := range keys {
+		if jwk.Alg != "" && jwk.Alg != alg {
+			continue
+		}
+		if !keyFitsAlg(jwk.key, alg) {
+			continue
+		}
+		if kid != "" && jwk.Kid == kid {
+			return jwk.key
+		}
+		candidates = append(candidates, jwk)
+	}
+	if kid == "" && len(candidates) == 1 {
+		return candidates[0].key
+	}
+	return nil
+}
+
+func keyFitsAlg(key crypto.PublicKey, alg string) bool {
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		return (alg == "ES256" && pub.Curve == elliptic.P256()) || (alg == "ES384" && pub.Curve == elliptic.P384())
+	}
+	return false
+}
+
+// publicKey decodes an RSA or EC public key
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC point")
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+	}
+	return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+}
+
+// verifySignature checks a JWS signature over the signing input
+func verifySignature(key crypto.PublicKey, alg string, hash crypto.Hash, input string, signature []byte) error {
+	h := hash.New()
+	h.Write([]byte(input))
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(alg, "PS") {
+			return rsa.VerifyPSS(pub, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		return rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	}
+	return errors.New("unsupported key")
+}
+
+// decodeSegment decodes a base64url JSON segment of a JWT
+func decodeSegment(segment string, dest any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dest)
+}
@@ -0,0 +1,247 @@
+// Package qr is a small, dependency-free QR code encoder that renders SVG.
+//
+// It supports byte mode at every version (1-40) and error correction level,
+// which is all the app needs for otpauth:// URIs and similar links.
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Level is the error correction level.
+type Level int
+
+const (
+	Low      Level = iota // recovers ~7% of the symbol
+	Medium                // ~15%
+	Quartile              // ~25%
+	High                  // ~30%
+)
+
+const (
+	MIN_VERSION = 1
+	MAX_VERSION = 40
+
+	// QUIET_ZONE is the light border, in modules, scanners need around the code
+	QUIET_ZONE = 4
+)
+
+// ErrTooLong is returned when the data does not fit in a version 40 symbol.
+var ErrTooLong = errors.New("qr: data too long")
+
+// Code is an encoded QR symbol.
+type Code struct {
+	Version int
+	Level   Level
+	Mask    int
+	Size    int
+
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// formatBits are the two bits identifying each level in the format information
+var formatBits = [...]int{Low: 1, Medium: 0, Quartile: 3, High: 2}
+
+// eccCodewordsPerBlock and numECBlocks are indexed by level, then version
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+var numECBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// Encode builds the smallest symbol that holds data in byte mode at the
+// given level, choosing the mask with the lowest penalty.
+func Encode(data []byte, level Level) (*Code, error) {
+	if level < Low || level > High {
+		return nil, fmt.Errorf("qr: invalid level %d", level)
+	}
+
+	version := 0
+	for v := MIN_VERSION; v <= MAX_VERSION; v++ {
+		if byteCapacity(v, level) >= len(data) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	codewords := addECCAndInterleave(dataCodewords(data, version, level), version, level)
+
+	c := newCode(version, level)
+	c.drawFunctionPatterns()
+	c.drawCodewords(codewords)
+
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if penalty := c.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			bestMask, bestPenalty = mask, penalty
+		}
+		c.applyMask(mask) // XOR again to undo
+	}
+	c.Mask = bestMask
+	c.applyMask(bestMask)
+	c.drawFormatBits(bestMask)
+	c.isFunction = nil
+	return c, nil
+}
+
+// EncodeString is Encode for text
+func EncodeString(text string, level Level) (*Code, error) {
+	return Encode([]byte(text), level)
+}
+
+// Dark reports whether the module at column x, row y is dark
+func (c *Code) Dark(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.Size && y < c.Size && c.modules[y][x]
+}
+
+// SVG renders the code as a standalone SVG document scaled to fit its
+// container, with the standard quiet zone.
+func (c *Code) SVG() string {
+	dim := c.Size + QUIET_ZONE*2
+
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+QUIET_ZONE, y+QUIET_ZONE)
+			}
+		}
+	}
+
+	return fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges" role="img">`+
+			`<rect width="100%%" height="100%%" fill="#ffffff"/><path d="%s" fill="#000000"/></svg>`,
+		dim, dim, path.String(),
+	)
+}
+
+// SVG encodes text at the medium level and renders it
+func SVG(text string) (string, error) {
+	code, err := EncodeString(text, Medium)
+	if err != nil {
+		return "", err
+	}
+	return code.SVG(), nil
+}
+
+// byteCapacity is how many bytes fit in byte mode at a version and level
+func byteCapacity(version int, level Level) int {
+	bits := numDataCodewords(version, level)*8 - 4 - charCountBits(version)
+	return bits / 8
+}
+
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// numRawDataModules counts the modules available for data and ECC codewords
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*numECBlocks[level][version]
+}
+
+// dataCodewords builds the byte mode segment with terminator and padding
+func dataCodewords(data []byte, version int, level Level) []byte {
+	var bb bitBuffer
+	bb.append(0x4, 4) // byte mode
+	bb.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+
+	capacity := numDataCodewords(version, level) * 8
+	terminator := capacity - len(bb)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bb.append(0, terminator)
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := 0xEC; len(bb) < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+
+	result := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			result[i>>3] |= 1 << (7 - uint(i&7))
+		}
+	}
+	return result
+}
+
+// addECCAndInterleave splits data into blocks, appends Reed-Solomon ECC to
+// each and interleaves them into the final codeword sequence
+func addECCAndInterleave(data []byte, version int, level Level) []byte {
+	numBlocks := numECBlocks[level][version]
+	blockECCLen := eccCodewordsPerBlock[level][version]
+	rawCodewords := numRawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := rsDivisor(blockECCLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		datLen := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			datLen++
+		}
+		dat := data[k : k+datLen]
+		k += datLen
+
+		block := make([]byte, 0, shortBlockLen+1)
+		block = append(block, dat...)
+		if i < numShortBlocks {
+			block = append(block, 0) // placeholder so all blocks line up; skipped below
+		}
+		block = append(block, rsRemainder(dat, divisor)...)
+		blocks[i] = block
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := 0; i < shortBlockLen+1; i++ {
+		for j, block := range blocks {
+			if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(val, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*bb = append(*bb, (val>>uint(i))&1 != 0)
+	}
+}
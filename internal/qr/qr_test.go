@@ -0,0 +1,181 @@
+package qr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon_KnownVector(t *testing.T) {
+	// "HELLO WORLD" at 1-M, from the worked example in the QR specification guides
+	data := []byte{0x20, 0x5B, 0x0B, 0x78, 0xD1, 0x72, 0xDC, 0x4D, 0x43, 0x40, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	want := []byte{0xC4, 0x23, 0x27, 0x77, 0xEB, 0xD7, 0xE7, 0xE2, 0x5D, 0x17}
+
+	if got := rsRemainder(data, rsDivisor(len(want))); !bytes.Equal(got, want) {
+		t.Fatalf("ECC = % X, want % X", got, want)
+	}
+}
+
+func TestFormatAndVersionInfo(t *testing.T) {
+	if got := formatInfo(Medium, 0); got != 0x5412 {
+		t.Errorf("formatInfo(M, 0) = %#x, want 0x5412", got)
+	}
+	if got := formatInfo(Low, 0); got != 0x77C4 {
+		t.Errorf("formatInfo(L, 0) = %#x, want 0x77c4", got)
+	}
+	if got := versionInfo(7); got != 0x07C94 {
+		t.Errorf("versionInfo(7) = %#x, want 0x7c94", got)
+	}
+}
+
+func TestByteCapacity(t *testing.T) {
+	tests := []struct {
+		version int
+		level   Level
+		want    int
+	}{
+		{1, Low, 17}, {1, Medium, 14}, {1, Quartile, 11}, {1, High, 7},
+		{7, Medium, 122}, {10, Medium, 213}, {40, Low, 2953}, {40, High, 1273},
+	}
+	for _, tt := range tests {
+		if got := byteCapacity(tt.version, tt.level); got != tt.want {
+			t.Errorf("byteCapacity(%d, %d) = %d, want %d", tt.version, tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+	inputs := []string{
+		"hi",
+		"otpauth://totp/Gohst:user%40example.com?secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP&issuer=Gohst&algorithm=SHA1&digits=6&period=30",
+		strings.Repeat("0123456789abcdef", 40),
+	}
+	for _, in := range inputs {
+		for level := Low; level <= High; level++ {
+			code, err := EncodeString(in, level)
+			if err != nil {
+				t.Fatalf("Encode(%d bytes, %d): %v", len(in), level, err)
+			}
+			if got := decode(t, code); got != in {
+				t.Fatalf("round trip at version %d level %d: got %q, want %q", code.Version, level, got, in)
+			}
+		}
+	}
+}
+
+func TestEncode_TooLong(t *testing.T) {
+	if _, err := Encode(make([]byte, 3000), Low); err != ErrTooLong {
+		t.Fatalf("expected ErrTooLong, got %v", err)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	// 14 bytes fit version 1 at level M: 21 modules plus the quiet zone
+	svg, err := SVG("otpauth://x?a=")
+	if err != nil {
+		t.Fatalf("SVG: %v", err)
+	}
+	if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, `viewBox="0 0 29 29"`) {
+		t.Fatalf("unexpected SVG: %.120s", svg)
+	}
+}
+
+// decode reads a symbol back: it checks the format bits, unmasks, collects
+// the codewords, verifies each block's ECC and parses the byte segment.
+func decode(t *testing.T, code *Code) string {
+	t.Helper()
+
+	ref := newCode(code.Version, code.Level)
+	ref.drawFunctionPatterns()
+
+	var format int
+	for i := 0; i < 8; i++ {
+		if code.Dark(code.Size-1-i, 8) {
+			format |= 1 << uint(i)
+		}
+	}
+	for i := 8; i < 15; i++ {
+		if code.Dark(8, code.Size-15+i) {
+			format |= 1 << uint(i)
+		}
+	}
+	if format != formatInfo(code.Level, code.Mask) {
+		t.Fatalf("format bits %#x do not match level %d mask %d", format, code.Level, code.Mask)
+	}
+
+	// Unmask a copy of the data modules
+	ref.modules = make([][]bool, code.Size)
+	for y := range ref.modules {
+		ref.modules[y] = append([]bool(nil), code.modules[y]...)
+	}
+	ref.applyMask(code.Mask)
+
+	raw := make([]byte, numRawDataModules(code.Version)/8)
+	i := 0
+	for right := code.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < code.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = code.Size - 1 - vert
+				}
+				if !ref.isFunction[y][x] && i < len(raw)*8 {
+					if ref.modules[y][x] {
+						raw[i>>3] |= 1 << (7 - uint(i&7))
+					}
+					i++
+				}
+			}
+		}
+	}
+
+	// De-interleave
+	numBlocks := numECBlocks[code.Level][code.Version]
+	eccLen := eccCodewordsPerBlock[code.Level][code.Version]
+	numShort := numBlocks - len(raw)%numBlocks
+	shortLen := len(raw) / numBlocks
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for pos := 0; pos < shortLen+1; pos++ {
+		for b := range blocks {
+			if pos == shortLen-eccLen && b < numShort {
+				continue
+			}
+			blocks[b] = append(blocks[b], raw[k])
+			k++
+		}
+	}
+
+	var data []byte
+	divisor := rsDivisor(eccLen)
+	for b, block := range blocks {
+		datLen := len(block) - eccLen
+		if !bytes.Equal(rsRemainder(block[:datLen], divisor), block[datLen:]) {
+			t.Fatalf("block %d ECC mismatch", b)
+		}
+		data = append(data, block[:datLen]...)
+	}
+
+	// Parse the byte mode segment
+	readBits := func(offset, n int) int {
+		v := 0
+		for i := 0; i < n; i++ {
+			bitIndex := offset + i
+			v = v<<1 | int((data[bitIndex>>3]>>(7-uint(bitIndex&7)))&1)
+		}
+		return v
+	}
+	if mode := readBits(0, 4); mode != 0x4 {
+		t.Fatalf("mode = %#x, want byte mode", mode)
+	}
+	countBits := charCountBits(code.Version)
+	n := readBits(4, countBits)
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(readBits(4+countBits+i*8, 8))
+	}
+	return string(out)
+}
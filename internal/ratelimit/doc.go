@@ -24,7 +24,6 @@
 //	    limiter.Middleware,
 //	    session.SM.SessionMiddleware,
 //	    middleware.CSRF,
-//	    middleware.Logger,
 //	)
 //
 // # Preset Policies
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"strings"
 
-	"gohst/internal/auth"
 	"gohst/internal/session"
 )
 
@@ -44,9 +43,9 @@ func KeyByIP() KeyFunc {
 func KeyByUserElseIP() KeyFunc {
 	return func(r *http.Request) (string, string) {
 		sess := session.FromContext(r.Context())
-		if sess != nil && auth.IsAuthenticated(sess) {
-			if uid, ok := sess.Get("user_id"); ok && uid != nil {
-				return fmt.Sprintf("user:%v", uid), KeyTypeUser
+		if sess != nil {
+			if uid := sess.UserID(); uid != "" {
+				return "user:" + uid, KeyTypeUser
 			}
 		}
 		ip := ClientIP(r)
@@ -63,9 +62,9 @@ func KeyByTokenElseUserElseIP() KeyFunc {
 		}
 		// Check for authenticated user
 		sess := session.FromContext(r.Context())
-		if sess != nil && auth.IsAuthenticated(sess) {
-			if uid, ok := sess.Get("user_id"); ok && uid != nil {
-				return fmt.Sprintf("user:%v", uid), KeyTypeUser
+		if sess != nil {
+			if uid := sess.UserID(); uid != "" {
+				return "user:" + uid, KeyTypeUser
 			}
 		}
 		// Fallback to IP
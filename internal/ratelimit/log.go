@@ -3,7 +3,7 @@ package ratelimit
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"net/http"
 	"time"
 
 	"gohst/internal/db"
@@ -29,6 +29,16 @@ type LogStore interface {
 	Log(entry LogEntry) error
 }
 
+// denyHook, when set, is told about every denied request
+var denyHook func(r *http.Request, entry LogEntry)
+
+// SetDenyHook registers a function called for every denied request, e.g.
+// audit.RecordRateLimit to keep denials in the audit log. It is called
+// whether or not a log store is attached.
+func SetDenyHook(fn func(r *http.Request, entry LogEntry)) {
+	denyHook = fn
+}
+
 // ──────────────────────────────────────────────
 // Database log store (PostgreSQL)
 // ──────────────────────────────────────────────
@@ -42,7 +52,7 @@ type DBLogStore struct {
 func NewDBLogStore() *DBLogStore {
 	primary := db.GetPrimaryDB()
 	if primary == nil {
-		log.Println("[ratelimit] warning: no primary DB available for log store")
+		log.Warn("no primary DB available for log store")
 		return &DBLogStore{}
 	}
 	return &DBLogStore{db: primary.DB}
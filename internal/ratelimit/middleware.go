@@ -3,7 +3,6 @@ package ratelimit
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -93,7 +92,7 @@ func (l *Limiter) Middleware(next http.Handler) http.Handler {
 		if l.policy.ConcurrencyLimit > 0 && l.concurrencyStore != nil {
 			ok, err := l.concurrencyStore.Acquire(key, l.policy.ConcurrencyLimit)
 			if err != nil {
-				log.Printf("[ratelimit] concurrency store error key=%s: %v", truncateKey(key), err)
+				log.ErrorContext(r.Context(), "concurrency store error", "key", truncateKey(key), "err", err)
 			}
 			if !ok {
 				l.denyResponse(w, r, Result{
@@ -107,7 +106,7 @@ func (l *Limiter) Middleware(next http.Handler) http.Handler {
 			}
 			defer func() {
 				if err := l.concurrencyStore.Release(key); err != nil {
-					log.Printf("[ratelimit] concurrency release error key=%s: %v", truncateKey(key), err)
+					log.ErrorContext(r.Context(), "concurrency release error", "key", truncateKey(key), "err", err)
 				}
 			}()
 		}
@@ -130,24 +129,35 @@ func (l *Limiter) Middleware(next http.Handler) http.Handler {
 // denyResponse writes a 429 response with proper headers and logging.
 func (l *Limiter) denyResponse(w http.ResponseWriter, r *http.Request, result Result, key, keyType, reason string) {
 	// Log at warn level (never log raw secrets)
-	log.Printf("[ratelimit] DENIED %s %s | type=%s scope=%s key=%s retryAfter=%ds reason=%s",
-		r.Method, r.URL.Path, keyType, l.policy.Scope, truncateKey(key), result.RetryAfter, reason)
+	log.WarnContext(r.Context(), "request denied",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"key_type", keyType,
+		"scope", l.policy.Scope,
+		"key", truncateKey(key),
+		"retry_after", result.RetryAfter,
+		"reason", reason,
+	)
+
+	entry := LogEntry{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		KeyType:    keyType,
+		KeyHash:    truncateKey(key),
+		Scope:      l.policy.Scope,
+		RetryAfter: result.RetryAfter,
+		ClientIP:   ClientIP(r),
+	}
 
 	// Log to database if configured
 	if l.logStore != nil {
-		entry := LogEntry{
-			Method:     r.Method,
-			Path:       r.URL.Path,
-			KeyType:    keyType,
-			KeyHash:    truncateKey(key),
-			Scope:      l.policy.Scope,
-			RetryAfter: result.RetryAfter,
-			ClientIP:   ClientIP(r),
-		}
 		if err := l.logStore.Log(entry); err != nil {
-			log.Printf("[ratelimit] failed to write log entry: %v", err)
+			log.ErrorContext(r.Context(), "failed to write log entry", "err", err)
 		}
 	}
+	if denyHook != nil {
+		denyHook(r, entry)
+	}
 
 	// Custom handler?
 	if l.onLimit != nil && l.onLimit(w, r, result) {
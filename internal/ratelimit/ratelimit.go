@@ -1,32 +1,42 @@
 package ratelimit
 
 import (
-	"log"
+	"context"
 	"time"
 
 	"gohst/internal/config"
+	"gohst/internal/lifecycle"
+	"gohst/internal/logger"
 )
 
+var log = logger.Component("ratelimit")
+
 // ──────────────────────────────────────────────
 // Factory helpers
 // ──────────────────────────────────────────────
 
 // NewStore creates a Store based on the current config ("memory" or "redis").
+// The store is closed when the server shuts down.
 func NewStore() Store {
+	var store Store
 	switch config.RateLimit.Store {
 	case "redis":
-		log.Println("[ratelimit] using Redis store")
-		return NewRedisStore()
+		log.Info("using Redis store")
+		store = NewRedisStore()
 	default:
-		log.Println("[ratelimit] using in-memory store")
-		return NewMemoryStore(2 * time.Minute)
+		log.Info("using in-memory store")
+		store = NewMemoryStore(2 * time.Minute)
 	}
+	lifecycle.OnShutdown("ratelimit", func(ctx context.Context) error {
+		return store.Close()
+	})
+	return store
 }
 
 // NewLogStore creates a LogStore based on config.
 func NewLogStoreFromConfig() LogStore {
 	if config.RateLimit.LogTableEnabled {
-		log.Println("[ratelimit] database logging enabled")
+		log.Info("database logging enabled")
 		return NewDBLogStore()
 	}
 	return NopLogStore{}
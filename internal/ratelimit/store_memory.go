@@ -20,6 +20,7 @@ type MemoryStore struct {
 	mu      sync.Mutex
 	entries map[string]*memEntry
 	stop    chan struct{}
+	closing sync.Once
 }
 
 // NewMemoryStore creates a MemoryStore with a background cleanup goroutine
@@ -76,9 +77,9 @@ func (s *MemoryStore) Reset(key string) error {
 	return nil
 }
 
-// Close stops the background cleanup goroutine.
+// Close stops the background cleanup goroutine. It is safe to call more than once.
 func (s *MemoryStore) Close() error {
-	close(s.stop)
+	s.closing.Do(func() { close(s.stop) })
 	return nil
 }
 
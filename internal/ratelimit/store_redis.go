@@ -3,12 +3,13 @@ package ratelimit
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 
 	"gohst/internal/config"
+	"gohst/internal/health"
+	"gohst/internal/redisconn"
 )
 
 // ──────────────────────────────────────────────
@@ -20,26 +21,20 @@ import (
 // An atomic Lua script performs the refill-then-consume operation so that
 // concurrent requests can never over-admit.
 type RedisStore struct {
-	client *redis.Client
+	client redis.UniversalClient
 	prefix string
 }
 
-// NewRedisStore creates a RedisStore. It reads connection details from the
-// rate-limit config (falling back to session Redis config).
+// NewRedisStore creates a RedisStore on the shared Redis client, namespaced
+// with the rate-limit key prefix, and reports it to the readiness probe.
 func NewRedisStore() *RedisStore {
-	cfg := config.RateLimit.Redis
-	host := cfg.Host
-	port := cfg.Port
-	password := cfg.Password
-	db := cfg.DB
-	prefix := config.RateLimit.RedisPrefix
-
-	client := redis.NewClient(&redis.Options{
-		Addr:     host + ":" + strconv.Itoa(port),
-		Password: password,
-		DB:       db,
-	})
+	store := NewRedisStoreWithClient(redisconn.Client(), config.RateLimit.RedisPrefix)
+	health.Register("redis:ratelimit", store.Ping)
+	return store
+}
 
+// NewRedisStoreWithClient creates a RedisStore on the given client.
+func NewRedisStoreWithClient(client redis.UniversalClient, prefix string) *RedisStore {
 	return &RedisStore{
 		client: client,
 		prefix: prefix,
@@ -161,9 +156,14 @@ func (s *RedisStore) Reset(key string) error {
 	return s.client.Del(context.Background(), s.prefix+key).Err()
 }
 
-// Close shuts down the Redis client.
+// Ping checks the store can reach Redis.
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// Close is a no-op: the client is shared and closed with redisconn.Close.
 func (s *RedisStore) Close() error {
-	return s.client.Close()
+	return nil
 }
 
 // ──────────────────────────────────────────────
@@ -173,13 +173,13 @@ func (s *RedisStore) Close() error {
 // RedisConcurrencyStore manages per-key concurrency using Redis INCR/DECR
 // with a safety TTL so keys auto-expire if a release is missed.
 type RedisConcurrencyStore struct {
-	client *redis.Client
+	client redis.UniversalClient
 	prefix string
 	ttl    time.Duration // safety TTL for auto-release
 }
 
 // NewRedisConcurrencyStore creates a concurrency store backed by Redis.
-func NewRedisConcurrencyStore(client *redis.Client, prefix string, ttl time.Duration) *RedisConcurrencyStore {
+func NewRedisConcurrencyStore(client redis.UniversalClient, prefix string, ttl time.Duration) *RedisConcurrencyStore {
 	return &RedisConcurrencyStore{
 		client: client,
 		prefix: prefix + "conc:",
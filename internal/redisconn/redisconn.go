@@ -0,0 +1,169 @@
+// Package redisconn builds the Redis client shared by sessions, rate limiting
+// and any other subsystem that needs Redis.
+//
+// Every subsystem uses the same connection, configured once through the
+// REDIS_* settings and set up by Init at startup, and namespaces its keys
+// with its own prefix:
+//
+//	client := redisconn.Client()
+//	client.Set(ctx, config.Session.RedisPrefix+id, data, ttl)
+//
+// Standalone, Sentinel and Cluster deployments are supported, each
+// optionally over TLS. Keys touched by a single command or Lua script must
+// live in the same hash slot to work in cluster mode.
+package redisconn
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"gohst/internal/config"
+	"gohst/internal/lifecycle"
+)
+
+var (
+	mu           sync.Mutex
+	shared       redis.UniversalClient
+	registerOnce sync.Once
+)
+
+// Init builds the shared client from config.Redis. Call it once at startup
+// so a bad REDIS_* setting stops the process before anything depends on it.
+// The client connects lazily, so Init succeeds while Redis itself is down.
+func Init() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if shared != nil {
+		return nil
+	}
+	client, err := New(config.Redis)
+	if err != nil {
+		return fmt.Errorf("redisconn: %w", err)
+	}
+	shared = client
+
+	// Registered on first init, so the subsystems set up on top of
+	// Redis afterwards are shut down before it
+	registerOnce.Do(func() {
+		lifecycle.OnShutdown("redis", func(ctx context.Context) error {
+			return Close()
+		})
+	})
+	return nil
+}
+
+// Client returns the shared client set up by Init or SetClient.
+func Client() redis.UniversalClient {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if shared == nil {
+		panic("redisconn: Client called before Init")
+	}
+	return shared
+}
+
+// SetClient replaces the shared client, e.g. with one pointing at a test server.
+func SetClient(client redis.UniversalClient) {
+	mu.Lock()
+	defer mu.Unlock()
+	shared = client
+}
+
+// Close closes the shared client. Call it once during shutdown.
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if shared == nil {
+		return nil
+	}
+	err := shared.Close()
+	shared = nil
+	return err
+}
+
+// New builds a client for the given configuration without sharing it.
+func New(cfg *config.RedisConnectionConfig) (redis.UniversalClient, error) {
+	if cfg == nil {
+		cfg = &config.RedisConnectionConfig{}
+	}
+
+	addrs := cfg.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{fmt.Sprintf("%s:%d", config.REDIS_HOST_DEFAULT, config.REDIS_PORT_DEFAULT)}
+	}
+
+	tlsConfig, err := newTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Mode {
+	case "", config.REDIS_MODE_STANDALONE:
+		return redis.NewClient(&redis.Options{
+			Addr:      addrs[0],
+			Username:  cfg.Username,
+			Password:  cfg.Password,
+			DB:        cfg.DB,
+			TLSConfig: tlsConfig,
+		}), nil
+	case config.REDIS_MODE_SENTINEL:
+		if cfg.MasterName == "" {
+			return nil, fmt.Errorf("sentinel mode requires REDIS_MASTER_NAME")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.MasterName,
+			SentinelAddrs:    addrs,
+			SentinelUsername: cfg.SentinelUsername,
+			SentinelPassword: cfg.SentinelPassword,
+			Username:         cfg.Username,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+			TLSConfig:        tlsConfig,
+		}), nil
+	case config.REDIS_MODE_CLUSTER:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     addrs,
+			Username:  cfg.Username,
+			Password:  cfg.Password,
+			TLSConfig: tlsConfig,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown REDIS_MODE %q (want standalone, sentinel or cluster)", cfg.Mode)
+	}
+}
+
+// newTLSConfig returns nil when TLS is disabled
+func newTLSConfig(cfg *config.RedisTLSConfig) (*tls.Config, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading REDIS_TLS_CA_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("REDIS_TLS_CA_FILE %s contains no certificates", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
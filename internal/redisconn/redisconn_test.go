@@ -0,0 +1,85 @@
+package redisconn
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+
+	"gohst/internal/config"
+)
+
+func TestNew_Modes(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *config.RedisConnectionConfig
+		cluster bool
+		wantErr bool
+	}{
+		{name: "default", cfg: nil},
+		{name: "standalone", cfg: &config.RedisConnectionConfig{Mode: config.REDIS_MODE_STANDALONE, Addrs: []string{"redis:6379"}}},
+		{name: "sentinel", cfg: &config.RedisConnectionConfig{Mode: config.REDIS_MODE_SENTINEL, Addrs: []string{"s1:26379", "s2:26379"}, MasterName: "mymaster"}},
+		{name: "sentinel without master", cfg: &config.RedisConnectionConfig{Mode: config.REDIS_MODE_SENTINEL, Addrs: []string{"s1:26379"}}, wantErr: true},
+		{name: "cluster", cfg: &config.RedisConnectionConfig{Mode: config.REDIS_MODE_CLUSTER, Addrs: []string{"n1:6379", "n2:6379"}}, cluster: true},
+		{name: "unknown mode", cfg: &config.RedisConnectionConfig{Mode: "mesh"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			_, isCluster := client.(*redis.ClusterClient)
+			if isCluster != tt.cluster {
+				t.Fatalf("expected cluster client %v, got %T", tt.cluster, client)
+			}
+		})
+	}
+}
+
+func TestNew_TLS(t *testing.T) {
+	cfg := &config.RedisConnectionConfig{
+		TLS: &config.RedisTLSConfig{Enabled: true, ServerName: "redis.internal"},
+	}
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	opts := client.(*redis.Client).Options()
+	if opts.TLSConfig == nil || opts.TLSConfig.ServerName != "redis.internal" {
+		t.Fatalf("expected TLS to be configured, got %+v", opts.TLSConfig)
+	}
+
+	cfg.TLS.CAFile = filepath.Join(t.TempDir(), "missing.pem")
+	if _, err := New(cfg); err == nil {
+		t.Fatal("expected a missing CA file to be reported")
+	}
+}
+
+func TestInit_InvalidConfig(t *testing.T) {
+	prev := config.Redis
+	t.Cleanup(func() { config.Redis = prev })
+
+	config.Redis = &config.RedisConnectionConfig{Mode: "mesh"}
+	if err := Init(); err == nil {
+		t.Fatal("expected an unknown mode to fail Init")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Client to panic without a successful Init")
+		}
+	}()
+	Client()
+}
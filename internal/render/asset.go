@@ -3,7 +3,6 @@ package render
 import (
 	"fmt"
 	"html/template"
-	"log"
 
 	"gohst/internal/config"
 	"gohst/internal/utils"
@@ -54,10 +53,10 @@ func AssetsHeadDev() template.HTML {
 // AssetsHeadProd returns the HTML for production mode assets, using the Vite manifest.
 func AssetsHeadProd() template.HTML {
 	var html string
-	log.Println("MANIFEST:", config.Vite.Manifest)
+	log.Debug("building asset tags", "manifest_entries", len(config.Vite.Manifest))
 	for _, entry := range config.Vite.Manifest {
 		asset := ManifestAsset(entry)
-		log.Println("Asset:", asset)
+		log.Debug("asset", "type", asset.Type, "file", asset.File)
 		if len(asset.File) > 0 {
 			url := utils.BuildDistURL(asset.File)
             switch asset.Type {
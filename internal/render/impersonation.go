@@ -0,0 +1,27 @@
+package render
+
+import (
+	"context"
+	"net/http"
+
+	"gohst/internal/auth"
+	"gohst/internal/session"
+)
+
+const impersonatorCtxKey ctxKey = "gohst_impersonator"
+
+// withImpersonator puts the impersonating user's auth data in the page
+// context so layouts can show that the session is impersonating
+func withImpersonator(ctx context.Context, r *http.Request) context.Context {
+	sess := session.FromContext(r.Context())
+	if sess == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, impersonatorCtxKey, auth.Impersonator(sess))
+}
+
+// GetImpersonatorFromCtx returns the auth data of the user impersonating the
+// logged-in user, or nil when nobody is
+func GetImpersonatorFromCtx(ctx context.Context) any {
+	return ctx.Value(impersonatorCtxKey)
+}
@@ -19,6 +19,12 @@ type AuthUser interface {
 	GetName() string
 }
 
+// VerifiableUser is implemented by auth data that tracks email verification,
+// letting views nag users who have not verified yet.
+type VerifiableUser interface {
+	IsEmailVerified() bool
+}
+
 // SetPageContext stores all shared page data in the request context so any
 // templ component in the tree can access it without being passed explicitly.
 func SetPageContext(
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/a-h/templ"
+
+	"gohst/internal/auth"
+	"gohst/internal/session"
+)
+
+const permissionsCtxKey ctxKey = "gohst_permissions"
+
+// withPermissions loads the user's permissions into the page context so
+// views can check them without touching the session
+func withPermissions(ctx context.Context, r *http.Request) context.Context {
+	sess := session.FromContext(r.Context())
+	if sess == nil {
+		return ctx
+	}
+	perms, err := auth.Permissions(r.Context(), sess)
+	if err != nil {
+		log.ErrorContext(r.Context(), "loading permissions failed", "err", err)
+	}
+	return context.WithValue(ctx, permissionsCtxKey, perms)
+}
+
+// GetPermissionsFromCtx returns the permissions of the authenticated user
+func GetPermissionsFromCtx(ctx context.Context) []string {
+	if v, ok := ctx.Value(permissionsCtxKey).([]string); ok {
+		return v
+	}
+	return nil
+}
+
+// Can reports whether the user viewing the page has the permission:
+//
+//	if render.Can(ctx, "users.edit") { ... }
+func Can(ctx context.Context, permission string) bool {
+	return auth.AbilityAllows(GetPermissionsFromCtx(ctx), permission)
+}
+
+// IfCan renders its children only when the user has the permission:
+//
+//	@render.IfCan("users.delete") {
+//		<button>Delete</button>
+//	}
+//
+// Hiding an element is no substitute for checking the permission in the
+// handler or with middleware.Can.
+func IfCan(permission string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		if !Can(ctx, permission) {
+			return nil
+		}
+		return templ.GetChildren(ctx).Render(ctx, w)
+	})
+}
@@ -3,12 +3,15 @@ package render
 import (
 	"encoding/json"
 	"html/template"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+
+	"gohst/internal/logger"
 )
 
+var log = logger.Component("render")
+
 var defaultTmplExt string = "*.html"
 
 var templates *template.Template
@@ -20,13 +23,14 @@ func LoadTemplateDir(dir string, ext ...string) *template.Template{
     }
 
 	pattern := filepath.Join(dir, templateExt)
-	log.Println("Loading templates from directory", pattern)
+	log.Debug("loading templates", "pattern", pattern)
     templates = template.Must(template.ParseGlob(pattern))
 
 	var err error
     templates, err = template.ParseGlob(pattern)
     if err != nil {
-        log.Fatalf("Error loading templates from directory %s: %v", dir, err)
+        log.Error("loading templates failed", "dir", dir, "err", err)
+        os.Exit(1)
     }
 
 	return templates
@@ -49,15 +53,17 @@ func LoadAllTemplates(dir string) {
 	})
 
 	if err != nil {
-		log.Fatalf("Error walking through templates directory: %v", err)
+		log.Error("walking templates directory failed", "dir", dir, "err", err)
+		os.Exit(1)
 	}
 
 	templates, err = template.ParseFiles(allFiles...)
 	if err != nil {
-		log.Fatalf("Error parsing templates: %v", err)
+		log.Error("parsing templates failed", "err", err)
+		os.Exit(1)
 	}
 
-	log.Println("Templates loaded successfully:", templates.DefinedTemplates())
+	log.Debug("templates loaded", "templates", templates.DefinedTemplates())
 }
 
 func Text(w http.ResponseWriter, text string) {
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strings"
 
@@ -124,6 +123,8 @@ func (v *View) Render(w http.ResponseWriter, r *http.Request, page Page) error {
 	}
 
 	ctx := SetPageContext(r.Context(), csrf, authData, flash, fieldErrors, req)
+	ctx = withPermissions(ctx, r)
+	ctx = withImpersonator(ctx, r)
 
 	title := page.Title
 	if title == "" {
@@ -178,6 +179,8 @@ func (v *View) RenderPartial(w http.ResponseWriter, r *http.Request, component t
 	}
 
 	ctx := SetPageContext(r.Context(), csrf, authData, flash, fieldErrors, req)
+	ctx = withPermissions(ctx, r)
+	ctx = withImpersonator(ctx, r)
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	return component.Render(ctx, w)
 }
@@ -196,7 +199,7 @@ func RenderError(w http.ResponseWriter, title string, err interface{}) {
 }
 
 func (v *View) handleError(w http.ResponseWriter, err error) error {
-	log.Println("Error executing template:", err)
+	log.Error("executing template failed", "err", err)
 	RenderError(w, "Template Rendering Error", err)
 	return nil // Error handled
 }
@@ -0,0 +1,272 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"gohst/internal/logger"
+	"gohst/internal/middleware"
+)
+
+// Middleware wraps a handler, as used with middleware.Chain
+type Middleware = func(http.Handler) http.Handler
+
+// Group registers routes under a path prefix, each wrapped in the group's
+// middleware. Groups nest: a child adds its prefix and middleware to its
+// parent's. All groups made from one New share a single ServeMux, so routes
+// are matched on their full path and handlers see the path unstripped.
+type Group struct {
+	mux        *http.ServeMux
+	prefix     string
+	middleware []Middleware
+}
+
+// Route is a registered route, returned so it can be named
+type Route struct {
+	info *RouteInfo
+}
+
+// RouteInfo describes a registered route for listings
+type RouteInfo struct {
+	Method     string
+	Pattern    string
+	Name       string
+	Middleware []string
+}
+
+var (
+	tableMu sync.RWMutex
+	table   []*RouteInfo
+	names   = map[string]*RouteInfo{}
+)
+
+// New returns the root group of a new ServeMux
+func New() *Group {
+	return &Group{mux: http.NewServeMux()}
+}
+
+// Group returns a child group under prefix whose routes also run mw, inside
+// this group's middleware
+func (g *Group) Group(prefix string, mw ...Middleware) *Group {
+	return &Group{
+		mux:        g.mux,
+		prefix:     g.prefix + strings.TrimRight(prefix, "/"),
+		middleware: append(append([]Middleware(nil), g.middleware...), mw...),
+	}
+}
+
+// Use adds middleware to the group, wrapping the routes registered on it
+// afterwards and the groups made from it afterwards
+func (g *Group) Use(mw ...Middleware) {
+	g.middleware = append(g.middleware, mw...)
+}
+
+// Handle registers handler for a ServeMux pattern such as "GET /login" or
+// "POST /tokens/{id}/revoke", relative to the group prefix. mw wraps this
+// route only, inside the group's middleware.
+func (g *Group) Handle(pattern string, handler http.Handler, mw ...Middleware) *Route {
+	method, path := splitPattern(pattern)
+	full := g.prefix + path
+
+	registered := full
+	if method != "" {
+		registered = method + " " + full
+	}
+	stack := append(append([]Middleware(nil), g.middleware...), mw...)
+	g.mux.Handle(registered, tagRoute(registered, middleware.Chain(handler, stack...)))
+
+	info := &RouteInfo{Method: method, Pattern: full, Middleware: middlewareNames(stack)}
+	tableMu.Lock()
+	table = append(table, info)
+	tableMu.Unlock()
+	return &Route{info: info}
+}
+
+// HandleFunc registers a handler function, like Handle
+func (g *Group) HandleFunc(pattern string, handler http.HandlerFunc, mw ...Middleware) *Route {
+	return g.Handle(pattern, handler, mw...)
+}
+
+// Mount hands every request under prefix to handler with the prefix
+// stripped, for handlers that do their own routing such as a file server.
+// Like Handle, the prefix may start with a method ("GET /static"); mounts
+// without one conflict with method routes on a parent path such as "GET /".
+// The rest of the path is the "path" parameter when building its URL.
+func (g *Group) Mount(prefix string, handler http.Handler, mw ...Middleware) *Route {
+	method, path := splitPattern(prefix)
+	path = strings.TrimRight(path, "/")
+
+	pattern := path + "/{path...}"
+	if method != "" {
+		pattern = method + " " + pattern
+	}
+	return g.Handle(pattern, http.StripPrefix(g.prefix+path, handler), mw...)
+}
+
+// splitPattern separates the optional method from a ServeMux pattern
+func splitPattern(pattern string) (method string, path string) {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		return "", pattern
+	}
+	return method, strings.TrimLeft(path, " ")
+}
+
+// ServeHTTP dispatches to the routes registered on the group's ServeMux
+func (g *Group) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// Name names the route for URL. Names are global and must be unique.
+func (rt *Route) Name(name string) *Route {
+	tableMu.Lock()
+	defer tableMu.Unlock()
+
+	if existing, ok := names[name]; ok && existing != rt.info {
+		panic(fmt.Sprintf("routes: name %q is already used by %s", name, existing.Pattern))
+	}
+	rt.info.Name = name
+	names[name] = rt.info
+	return rt
+}
+
+// Routes lists every registered route, sorted by path then method
+func Routes() []RouteInfo {
+	tableMu.RLock()
+	defer tableMu.RUnlock()
+
+	routes := make([]RouteInfo, 0, len(table))
+	for _, info := range table {
+		routes = append(routes, *info)
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+var wildcardPattern = regexp.MustCompile(`\{([^}]*)\}`)
+
+// Resolve builds the path of a named route. params are name/value pairs
+// filling the route's wildcards; pairs that match no wildcard become the
+// query string.
+//
+//	routes.Resolve("admin.users.impersonate", "id", 7) // "/admin/users/7/impersonate"
+//	routes.Resolve("admin.users", "page", 2)           // "/admin/users?page=2"
+func Resolve(name string, params ...any) (string, error) {
+	tableMu.RLock()
+	info, ok := names[name]
+	tableMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("routes: no route named %q", name)
+	}
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("routes: odd number of params for %q", name)
+	}
+
+	values := make(map[string]string, len(params)/2)
+	var order []string
+	for i := 0; i < len(params); i += 2 {
+		key := fmt.Sprint(params[i])
+		if _, seen := values[key]; !seen {
+			order = append(order, key)
+		}
+		values[key] = fmt.Sprint(params[i+1])
+	}
+
+	var missing []string
+	path := wildcardPattern.ReplaceAllStringFunc(info.Pattern, func(wildcard string) string {
+		key := strings.Trim(wildcard, "{}")
+		if key == "$" {
+			return ""
+		}
+		remainder := strings.HasSuffix(key, "...")
+		key = strings.TrimSuffix(key, "...")
+
+		value, ok := values[key]
+		if !ok {
+			missing = append(missing, key)
+			return wildcard
+		}
+		delete(values, key)
+		if remainder {
+			// A trailing wildcard may span segments; escape each one
+			segments := strings.Split(value, "/")
+			for i, segment := range segments {
+				segments[i] = url.PathEscape(segment)
+			}
+			return strings.Join(segments, "/")
+		}
+		return url.PathEscape(value)
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("routes: %q needs %s", name, strings.Join(missing, ", "))
+	}
+
+	query := url.Values{}
+	for _, key := range order {
+		if value, ok := values[key]; ok {
+			query.Set(key, value)
+		}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path, nil
+}
+
+// URL builds the path of a named route like Resolve. An unknown name or a
+// missing parameter is a programming error and panics, so URL can be used
+// inline in handlers and templates.
+func URL(name string, params ...any) string {
+	path, err := Resolve(name, params...)
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
+
+// middlewareNames names each middleware by its function for route listings,
+// e.g. "middleware.Auth" or "ratelimit.Limiter.Middleware"
+func middlewareNames(stack []Middleware) []string {
+	labels := make([]string, 0, len(stack))
+	for _, mw := range stack {
+		labels = append(labels, funcName(mw))
+	}
+	return labels
+}
+
+var closureSuffix = regexp.MustCompile(`(\.func\d+)+$`)
+
+func funcName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "?"
+	}
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, "-fm")
+	name = closureSuffix.ReplaceAllString(name, "")
+	return strings.NewReplacer("(*", "", ")", "").Replace(name)
+}
+
+// tagRoute records the matched pattern on the request's log scope before
+// the route's own middleware runs
+func tagRoute(pattern string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.SetRoute(r.Context(), pattern)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,210 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gohst/internal/logger"
+)
+
+// resetTable clears the package route table between tests
+func resetTable(t *testing.T) {
+	t.Helper()
+	tableMu.Lock()
+	table = nil
+	names = map[string]*RouteInfo{}
+	tableMu.Unlock()
+}
+
+func tag(label string, trace *[]string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*trace = append(*trace, label)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func serve(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestGroup_PrefixAndFullPath(t *testing.T) {
+	resetTable(t)
+	root := New()
+	var seen string
+	root.Group("/auth").Group("/password").HandleFunc("GET /reset/{token}", func(w http.ResponseWriter, r *http.Request) {
+		seen = r.URL.Path + " " + r.PathValue("token")
+	})
+
+	rec := serve(root, http.MethodGet, "/auth/password/reset/abc")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if seen != "/auth/password/reset/abc abc" {
+		t.Fatalf("handler saw %q", seen)
+	}
+	if rec := serve(root, http.MethodPost, "/auth/password/reset/abc"); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status = %d, want 405", rec.Code)
+	}
+}
+
+func TestGroup_MiddlewareOrder(t *testing.T) {
+	resetTable(t)
+	var trace []string
+	root := New()
+	g := root.Group("/account", tag("group", &trace))
+	g.Use(tag("use", &trace))
+	child := g.Group("/tokens", tag("child", &trace))
+	child.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		trace = append(trace, "handler")
+	}, tag("route", &trace))
+
+	serve(root, http.MethodGet, "/account/tokens/")
+	want := "group,use,child,route,handler"
+	if got := strings.Join(trace, ","); got != want {
+		t.Fatalf("order = %s, want %s", got, want)
+	}
+}
+
+func TestGroup_UseAfterChildDoesNotLeak(t *testing.T) {
+	resetTable(t)
+	var trace []string
+	root := New()
+	g := root.Group("/a")
+	child := g.Group("/b")
+	g.Use(tag("late", &trace))
+	child.HandleFunc("GET /c", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve(root, http.MethodGet, "/a/b/c")
+	if len(trace) != 0 {
+		t.Fatalf("child ran parent middleware added after it: %v", trace)
+	}
+}
+
+func TestMount_StripsPrefix(t *testing.T) {
+	resetTable(t)
+	root := New()
+	var seen string
+	root.Mount("GET /static", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.URL.Path
+	})).Name("static")
+	root.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve(root, http.MethodGet, "/static/css/app.css")
+	if seen != "/css/app.css" {
+		t.Fatalf("mounted handler saw %q, want /css/app.css", seen)
+	}
+	if got := URL("static", "path", "css/app.css"); got != "/static/css/app.css" {
+		t.Fatalf("URL = %q", got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	resetTable(t)
+	root := New()
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	root.HandleFunc("GET /{$}", noop).Name("home")
+	root.HandleFunc("GET /admin/users", noop).Name("admin.users")
+	root.HandleFunc("POST /admin/users/{id}/impersonate", noop).Name("admin.users.impersonate")
+
+	tests := []struct {
+		name   string
+		params []any
+		want   string
+	}{
+		{"home", nil, "/"},
+		{"admin.users", []any{"page", 2}, "/admin/users?page=2"},
+		{"admin.users.impersonate", []any{"id", 7}, "/admin/users/7/impersonate"},
+		{"admin.users.impersonate", []any{"id", "a b/c"}, "/admin/users/a%20b%2Fc/impersonate"},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(tt.name, tt.params...)
+		if err != nil {
+			t.Fatalf("Resolve(%q): %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q, %v) = %q, want %q", tt.name, tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	resetTable(t)
+	New().HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {}).Name("users.show")
+
+	if _, err := Resolve("users.missing"); err == nil {
+		t.Error("unknown name should fail")
+	}
+	if _, err := Resolve("users.show"); err == nil {
+		t.Error("missing parameter should fail")
+	}
+	if _, err := Resolve("users.show", "id"); err == nil {
+		t.Error("odd number of params should fail")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("URL should panic on an unknown name")
+		}
+	}()
+	URL("users.missing")
+}
+
+func TestName_DuplicatePanics(t *testing.T) {
+	resetTable(t)
+	root := New()
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	root.HandleFunc("GET /a", noop).Name("dup")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("reusing a route name should panic")
+		}
+	}()
+	root.HandleFunc("GET /b", noop).Name("dup")
+}
+
+func TestRoutes_Listing(t *testing.T) {
+	resetTable(t)
+	root := New()
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	g := root.Group("/tokens", testMiddleware)
+	g.HandleFunc("POST /{$}", noop).Name("tokens.create")
+	g.HandleFunc("GET /{$}", noop).Name("tokens")
+	root.HandleFunc("GET /about", noop)
+
+	got := Routes()
+	if len(got) != 3 {
+		t.Fatalf("got %d routes, want 3", len(got))
+	}
+	if got[0].Pattern != "/about" || got[0].Name != "" {
+		t.Errorf("first route = %+v", got[0])
+	}
+	if got[1].Method != "GET" || got[1].Name != "tokens" || got[2].Method != "POST" {
+		t.Errorf("routes not sorted by path then method: %+v", got[1:])
+	}
+	if len(got[1].Middleware) != 1 || got[1].Middleware[0] != "routes.testMiddleware" {
+		t.Errorf("middleware = %v, want [routes.testMiddleware]", got[1].Middleware)
+	}
+}
+
+func testMiddleware(next http.Handler) http.Handler { return next }
+
+func TestHandle_TagsRouteForLogging(t *testing.T) {
+	resetTable(t)
+	root := New()
+	root.Group("/users").HandleFunc("GET /{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	ctx := logger.WithRequest(r.Context(), "req-1")
+	root.ServeHTTP(httptest.NewRecorder(), r.WithContext(ctx))
+
+	if got := logger.Route(ctx); got != "GET /users/{id}" {
+		t.Fatalf("route = %q, want the matched pattern", got)
+	}
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"net/http"
 
+	"gohst/internal/audit"
 	"gohst/internal/middleware"
 )
 
@@ -15,14 +16,29 @@ type Router interface {
 // It wraps the application router with outer framework middleware that applies globally
 // to every request before any route group or controller middleware runs:
 //
+//   - RequestID: accepts or generates X-Request-ID and starts the request's log scope.
+//   - Logger: writes one access log line per request once it completes.
 //   - Recover: catches panics so a single bad request cannot crash the server.
 //   - SecurityHeaders: sets CSP, frame-options, HSTS, and other hardening headers.
 //   - NotFound: intercepts 404 responses and renders the framework not-found page.
+//   - audit.Middleware: keeps the client IP, user agent and request ID for audit events.
 func RegisterRouter(r Router) http.Handler {
-	return middleware.Chain(
-		r.SetupRoutes(),
+	return middleware.Chain(r.SetupRoutes(), globalMiddleware()...)
+}
+
+// GlobalMiddleware names the middleware RegisterRouter wraps every request
+// in, outermost first
+func GlobalMiddleware() []string {
+	return middlewareNames(globalMiddleware())
+}
+
+func globalMiddleware() []Middleware {
+	return []Middleware{
+		middleware.RequestID,
+		middleware.Logger,
 		middleware.Recover,
 		middleware.SecurityHeaders,
 		middleware.NotFound(),
-	)
+		audit.Middleware,
+	}
 }
@@ -0,0 +1,235 @@
+package session
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"gohst/internal/config"
+)
+
+// COOKIE_MAX_SIZE is the largest name=value pair browsers reliably accept.
+const COOKIE_MAX_SIZE = 4096
+
+var (
+	// ErrCookieTooLarge is returned when the sealed session does not fit in a cookie.
+	ErrCookieTooLarge = errors.New("session: encoded session exceeds the 4KB cookie limit")
+
+	// ErrStatelessStore is returned by lookups that need server-side state.
+	ErrStatelessStore = errors.New("session: cookie store keeps no server-side state")
+
+	// ErrInvalidCookie is returned when a cookie cannot be opened with any key.
+	ErrInvalidCookie = errors.New("session: cookie could not be authenticated")
+)
+
+// CookieValueStore is implemented by stores that carry the session payload in
+// the cookie itself instead of a server-side record keyed by the session ID.
+type CookieValueStore interface {
+	CookieValue(session *SessionData) (string, error)
+}
+
+// CookieSessionManager keeps the whole session in an encrypted cookie.
+// The SessionData is gob-encoded, compressed and sealed with
+// XChaCha20-Poly1305. The first key seals new cookies; every key is tried
+// when opening, which allows rotation through SESSION_COOKIE_OLD_KEYS.
+type CookieSessionManager struct {
+	keys       [][]byte
+	cookieName string
+}
+
+// NewCookieSessionManager initializes a cookie-only session manager.
+// When no keys are passed they are read from config; if none are configured
+// an ephemeral key is generated, which invalidates sessions on restart.
+func NewCookieSessionManager(cookieName string, keys ...[]byte) (*CookieSessionManager, string) {
+	if len(keys) == 0 {
+		parsed, err := ParseCookieKeys(config.Session.Cookie)
+		if err != nil {
+			log.Error("invalid session cookie key", "err", err)
+		}
+		keys = parsed
+	}
+
+	if len(keys) == 0 {
+		log.Warn("SESSION_COOKIE_KEY is not set, using an ephemeral key; sessions will not survive a restart")
+		key := make([]byte, chacha20poly1305.KeySize)
+		rand.Read(key)
+		keys = [][]byte{key}
+	}
+
+	return &CookieSessionManager{keys: keys, cookieName: cookieName}, SESSION_TYPE_COOKIE
+}
+
+// ParseCookieKeys decodes the configured key and old keys. Keys may be hex or
+// base64 encoded and must decode to exactly 32 bytes.
+func ParseCookieKeys(conf *config.CookieConfig) ([][]byte, error) {
+	if conf == nil || conf.Key == "" {
+		return nil, nil
+	}
+
+	var keys [][]byte
+	for _, raw := range append([]string{conf.Key}, conf.OldKeys...) {
+		key, err := decodeCookieKey(raw)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func decodeCookieKey(raw string) ([]byte, error) {
+	decoders := []func(string) ([]byte, error){
+		hex.DecodeString,
+		base64.StdEncoding.DecodeString,
+		base64.URLEncoding.DecodeString,
+		base64.RawStdEncoding.DecodeString,
+		base64.RawURLEncoding.DecodeString,
+	}
+	for _, decode := range decoders {
+		if key, err := decode(raw); err == nil && len(key) == chacha20poly1305.KeySize {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("cookie key must be %d bytes encoded as hex or base64", chacha20poly1305.KeySize)
+}
+
+// StartSession creates a new session and writes it to the cookie
+func (csm *CookieSessionManager) StartSession(w http.ResponseWriter, r *http.Request) (*SessionData, string) {
+	sessionData, sessionID := newSessionData()
+
+	value, err := csm.CookieValue(sessionData)
+	if err != nil {
+		log.ErrorContext(r.Context(), "sealing session cookie", "err", err)
+		return sessionData, sessionID
+	}
+
+	http.SetCookie(w, sessionCookie(csm.cookieName, value, sessionData.Expires))
+
+	return sessionData, sessionID
+}
+
+// GetSession opens the session cookie and returns its data if still valid
+func (csm *CookieSessionManager) GetSession(r *http.Request) (*SessionData, string) {
+	cookie, err := r.Cookie(csm.cookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, ""
+	}
+
+	sessionData, err := csm.open(cookie.Value)
+	if err != nil {
+		log.DebugContext(r.Context(), "opening session cookie", "err", err)
+		return nil, ""
+	}
+
+	if time.Now().After(sessionData.Expires) {
+		return nil, ""
+	}
+
+	return sessionData, sessionData.ID
+}
+
+// SetValue is a no-op; Session re-seals the cookie after every write.
+func (csm *CookieSessionManager) SetValue(sessionID string, key string, value interface{}) {}
+
+// GetValue always misses because values cannot be looked up by ID.
+func (csm *CookieSessionManager) GetValue(sessionID string, key string) (interface{}, bool) {
+	return nil, false
+}
+
+// GetSessionByID is not supported by the cookie store.
+func (csm *CookieSessionManager) GetSessionByID(ctx context.Context, sessionID string) (*SessionData, error) {
+	return nil, ErrStatelessStore
+}
+
+// Remove is a no-op; Session re-seals the cookie after every removal.
+func (csm *CookieSessionManager) Remove(sessionID string, key string) error {
+	return nil
+}
+
+// Save verifies the session still fits in a cookie. Nothing is stored.
+func (csm *CookieSessionManager) Save(sessionID string, session *SessionData) error {
+	_, err := csm.CookieValue(session)
+	return err
+}
+
+// Delete is a no-op; the old cookie is replaced by the caller.
+func (csm *CookieSessionManager) Delete(sessionID string) error {
+	return nil
+}
+
+// CookieValue seals the session into a cookie-safe string.
+func (csm *CookieSessionManager) CookieValue(session *SessionData) (string, error) {
+	var buf bytes.Buffer
+	zw, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if err := gob.NewEncoder(zw).Encode(session); err != nil {
+		return "", fmt.Errorf("session: encoding cookie session: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	aead, err := chacha20poly1305.NewX(csm.keys[0])
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+buf.Len()+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, buf.Bytes(), []byte(csm.cookieName))
+	value := base64.RawURLEncoding.EncodeToString(sealed)
+
+	if len(csm.cookieName)+1+len(value) > COOKIE_MAX_SIZE {
+		return "", fmt.Errorf("%w (%d bytes)", ErrCookieTooLarge, len(csm.cookieName)+1+len(value))
+	}
+
+	return value, nil
+}
+
+// open authenticates and decodes a sealed cookie value using any known key.
+func (csm *CookieSessionManager) open(value string) (*SessionData, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(sealed) < chacha20poly1305.NonceSizeX {
+		return nil, ErrInvalidCookie
+	}
+
+	nonce, ciphertext := sealed[:chacha20poly1305.NonceSizeX], sealed[chacha20poly1305.NonceSizeX:]
+
+	for _, key := range csm.keys {
+		aead, err := chacha20poly1305.NewX(key)
+		if err != nil {
+			continue
+		}
+		plain, err := aead.Open(nil, nonce, ciphertext, []byte(csm.cookieName))
+		if err != nil {
+			continue
+		}
+
+		var session SessionData
+		zr := flate.NewReader(bytes.NewReader(plain))
+		err = gob.NewDecoder(io.LimitReader(zr, 1<<20)).Decode(&session)
+		zr.Close()
+		if err != nil {
+			return nil, fmt.Errorf("session: decoding cookie session: %w", err)
+		}
+		return &session, nil
+	}
+
+	return nil, ErrInvalidCookie
+}
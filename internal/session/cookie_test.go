@@ -0,0 +1,196 @@
+package session
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gohst/internal/config"
+)
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestCookieStore_RoundTrip(t *testing.T) {
+	initTestConfig()
+	store, _ := NewCookieSessionManager("_test_session", testKey(t))
+
+	rr := httptest.NewRecorder()
+	data, id := store.StartSession(rr, nil)
+	data.Values["user"] = "alice"
+
+	value, err := store.CookieValue(data)
+	if err != nil {
+		t.Fatalf("CookieValue: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "_test_session", Value: value})
+
+	got, gotID := store.GetSession(req)
+	if got == nil {
+		t.Fatal("expected session to be opened")
+	}
+	if gotID != id {
+		t.Fatalf("expected ID %q, got %q", id, gotID)
+	}
+	if got.Values["user"] != "alice" {
+		t.Fatalf("expected value to survive round trip, got %v", got.Values["user"])
+	}
+}
+
+func TestCookieStore_KeyRotation(t *testing.T) {
+	initTestConfig()
+	oldKey, newKey := testKey(t), testKey(t)
+
+	oldStore, _ := NewCookieSessionManager("_test_session", oldKey)
+	value, err := oldStore.CookieValue(&SessionData{ID: "abc", Values: map[string]any{}, Expires: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "_test_session", Value: value})
+
+	rotated, _ := NewCookieSessionManager("_test_session", newKey, oldKey)
+	if got, _ := rotated.GetSession(req); got == nil || got.ID != "abc" {
+		t.Fatal("expected cookie sealed with an old key to open after rotation")
+	}
+
+	dropped, _ := NewCookieSessionManager("_test_session", newKey)
+	if got, _ := dropped.GetSession(req); got != nil {
+		t.Fatal("expected cookie to be rejected once the old key is removed")
+	}
+}
+
+func TestCookieStore_RejectsTampering(t *testing.T) {
+	initTestConfig()
+	store, _ := NewCookieSessionManager("_test_session", testKey(t))
+	value, err := store.CookieValue(&SessionData{ID: "abc", Values: map[string]any{}, Expires: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := []byte(value)
+	tampered[len(tampered)/2] ^= 'A' ^ 'B'
+	if bytes.Equal(tampered, []byte(value)) {
+		t.Fatal("tampering did not change the value")
+	}
+
+	if _, err := store.open(string(tampered)); !errors.Is(err, ErrInvalidCookie) {
+		t.Fatalf("expected ErrInvalidCookie, got %v", err)
+	}
+
+	// A cookie sealed for one name must not open under another.
+	other, _ := NewCookieSessionManager("_other_session", store.keys[0])
+	if _, err := other.open(value); !errors.Is(err, ErrInvalidCookie) {
+		t.Fatalf("expected cookie bound to its name, got %v", err)
+	}
+}
+
+func TestCookieStore_RejectsExpired(t *testing.T) {
+	initTestConfig()
+	store, _ := NewCookieSessionManager("_test_session", testKey(t))
+	value, _ := store.CookieValue(&SessionData{ID: "abc", Values: map[string]any{}, Expires: time.Now().Add(-time.Minute)})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "_test_session", Value: value})
+
+	if got, _ := store.GetSession(req); got != nil {
+		t.Fatal("expected expired cookie session to be ignored")
+	}
+}
+
+func TestCookieStore_TooLarge(t *testing.T) {
+	initTestConfig()
+	store, _ := NewCookieSessionManager("_test_session", testKey(t))
+
+	// Random bytes do not compress, so this cannot fit in 4KB.
+	noise := make([]byte, 4096)
+	rand.Read(noise)
+
+	data := &SessionData{ID: "abc", Values: map[string]any{"blob": string(noise)}, Expires: time.Now().Add(time.Hour)}
+	if _, err := store.CookieValue(data); !errors.Is(err, ErrCookieTooLarge) {
+		t.Fatalf("expected ErrCookieTooLarge, got %v", err)
+	}
+	if err := store.Save(data.ID, data); !errors.Is(err, ErrCookieTooLarge) {
+		t.Fatalf("expected Save to report ErrCookieTooLarge, got %v", err)
+	}
+}
+
+func TestCookieStore_Regenerate(t *testing.T) {
+	initTestConfig()
+	store, storeType := NewCookieSessionManager("_test_session", testKey(t))
+	sm := &SessionManager{StoreType: storeType, store: store, cookieName: "_test_session"}
+
+	rr := httptest.NewRecorder()
+	data, id := store.StartSession(rr, nil)
+	sess := &Session{id: id, data: data, manager: sm, w: rr}
+	sess.Set("user", "alice")
+
+	sess.Regenerate()
+	sess.Flush()
+	if sess.ID() == id {
+		t.Fatal("expected a new session ID")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(lastCookie(t, rr, "_test_session"))
+	got, gotID := store.GetSession(req)
+	if got == nil || gotID != sess.ID() || got.Values["user"] != "alice" {
+		t.Fatalf("expected regenerated cookie to carry the new ID and old values, got %+v", got)
+	}
+
+	sess.RegenerateNew()
+	sess.Flush()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(lastCookie(t, rr, "_test_session"))
+	got, _ = store.GetSession(req)
+	if got == nil {
+		t.Fatal("expected RegenerateNew cookie to open")
+	}
+	if _, ok := got.Values["user"]; ok {
+		t.Fatal("expected RegenerateNew to drop old values")
+	}
+	if _, ok := got.Values[string(CSRFKey)]; !ok {
+		t.Fatal("expected RegenerateNew to issue a fresh CSRF token")
+	}
+}
+
+func TestParseCookieKeys(t *testing.T) {
+	hexKey := strings.Repeat("ab", 32)
+	keys, err := ParseCookieKeys(&config.CookieConfig{Key: hexKey})
+	if err != nil || len(keys) != 1 || len(keys[0]) != 32 {
+		t.Fatalf("expected one 32 byte key, got %d keys, err %v", len(keys), err)
+	}
+
+	if _, err := ParseCookieKeys(&config.CookieConfig{Key: "too-short"}); err == nil {
+		t.Fatal("expected short key to be rejected")
+	}
+}
+
+// lastCookie returns the most recent Set-Cookie for name, as a browser would keep it.
+func lastCookie(t *testing.T, rr *httptest.ResponseRecorder, name string) *http.Cookie {
+	t.Helper()
+	var found *http.Cookie
+	for _, c := range (&http.Response{Header: rr.Header()}).Cookies() {
+		if c.Name == name {
+			found = c
+		}
+	}
+	if found == nil {
+		t.Fatalf("no %s cookie set", name)
+	}
+	return found
+}
@@ -11,6 +11,11 @@ type SessionData struct {
 	ID 		string					`json:"id"`
 	Values  map[string]interface{} 	`json:"values"`
 	Expires time.Time				`json:"expires"`
+	CreatedAt time.Time				`json:"created_at"`
+	LastSeen  time.Time				`json:"last_seen"`
+	IP        string				`json:"ip"`
+	UserAgent string				`json:"user_agent"`
+	Fingerprint string				`json:"fingerprint"`
 	manager SessionStore			`json:"-"`
 }
 
@@ -30,6 +35,81 @@ func GetSessionLength() time.Duration {
 	return time.Duration(sessionLength) * time.Minute
 }
 
+// GetIdleTimeout returns how long a session may sit unused before it expires.
+// It falls back to the session length when no idle timeout is configured.
+func GetIdleTimeout() time.Duration {
+	if config.Session != nil && config.Session.IdleTimeout > 0 {
+		return time.Duration(config.Session.IdleTimeout) * time.Minute
+	}
+	return GetSessionLength()
+}
+
+// GetAbsoluteTimeout returns the maximum lifetime of a session regardless of
+// activity. Zero means sessions have no absolute limit.
+func GetAbsoluteTimeout() time.Duration {
+	if config.Session != nil && config.Session.AbsoluteTimeout > 0 {
+		return time.Duration(config.Session.AbsoluteTimeout) * time.Minute
+	}
+	return 0
+}
+
+// GetTouchInterval returns how often activity alone is written back to the
+// store to slide the idle expiry.
+func GetTouchInterval() time.Duration {
+	if config.Session != nil && config.Session.TouchInterval > 0 {
+		return time.Duration(config.Session.TouchInterval) * time.Minute
+	}
+	return time.Duration(config.SESSION_TOUCH_INTERVAL_DEFAULT) * time.Minute
+}
+
+// newSessionData builds an empty, unsaved session
+func newSessionData() (*SessionData, string) {
+	return newSessionDataAt(time.Now())
+}
+
+// newSessionDataAt builds an empty, unsaved session created at now
+func newSessionDataAt(now time.Time) (*SessionData, string) {
+	sessionID := GenerateSessionID()
+	sessionData := &SessionData{
+		ID:        sessionID,
+		Values:    make(map[string]any),
+		CreatedAt: now,
+	}
+	sessionData.touch(now)
+	return sessionData, sessionID
+}
+
+// touch records activity at now and recomputes Expires as the earlier of the
+// idle and absolute deadlines
+func (sd *SessionData) touch(now time.Time) {
+	if sd.CreatedAt.IsZero() {
+		sd.CreatedAt = now // sessions written before timeouts were tracked
+	}
+	sd.LastSeen = now
+	sd.Expires = now.Add(GetIdleTimeout())
+
+	if absolute := GetAbsoluteTimeout(); absolute > 0 {
+		if limit := sd.CreatedAt.Add(absolute); limit.Before(sd.Expires) {
+			sd.Expires = limit
+		}
+	}
+}
+
+// AbsoluteExpired reports whether the session has outlived the absolute timeout
+func (sd *SessionData) AbsoluteExpired(now time.Time) bool {
+	absolute := GetAbsoluteTimeout()
+	if absolute == 0 || sd.CreatedAt.IsZero() {
+		return false
+	}
+	return !now.Before(sd.CreatedAt.Add(absolute))
+}
+
+// needsTouch reports whether enough time has passed since the last recorded
+// activity that the idle expiry should be slid forward
+func (sd *SessionData) needsTouch(now time.Time) bool {
+	return sd.LastSeen.IsZero() || now.Sub(sd.LastSeen) >= GetTouchInterval()
+}
+
 // Set a session value through the session manager to maintain one source of truth
 func (sd *SessionData) Set(key string, value any)  {
     sd.Values[key] = value
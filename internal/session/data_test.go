@@ -0,0 +1,107 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionData_ExpiresAtEarlierDeadline(t *testing.T) {
+	initTestConfigWithTimeouts(30, 120)
+
+	created := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	data, _ := newSessionDataAt(created)
+	if want := created.Add(30 * time.Minute); !data.Expires.Equal(want) {
+		t.Fatalf("expected idle deadline %v, got %v", want, data.Expires)
+	}
+
+	// Activity near the end of the absolute window is capped by it.
+	data.touch(created.Add(110 * time.Minute))
+	if want := created.Add(120 * time.Minute); !data.Expires.Equal(want) {
+		t.Fatalf("expected absolute deadline %v, got %v", want, data.Expires)
+	}
+	if data.AbsoluteExpired(created.Add(119 * time.Minute)) {
+		t.Fatal("did not expect session to be past the absolute limit yet")
+	}
+	if !data.AbsoluteExpired(created.Add(120 * time.Minute)) {
+		t.Fatal("expected session to be past the absolute limit")
+	}
+}
+
+func TestSessionMiddleware_SlidesIdleExpiry(t *testing.T) {
+	initTestConfigWithTimeouts(30, 0)
+	sm, store := newCountingManager()
+
+	cookie, err := sm.Seed(func(sess *Session) { sess.Set("user_id", "42") })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend the last recorded activity was ten minutes ago.
+	data, _ := store.GetSessionByID(context.Background(), cookie.Value)
+	data.LastSeen = data.LastSeen.Add(-10 * time.Minute)
+	data.Expires = data.Expires.Add(-10 * time.Minute)
+	store.MemorySessionManager.Save(cookie.Value, data)
+	store.saves = 0
+
+	handler := sm.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if store.saves != 1 {
+		t.Fatalf("expected activity to be written once, got %d writes", store.saves)
+	}
+	touched, _ := store.GetSessionByID(context.Background(), cookie.Value)
+	if !touched.Expires.After(data.Expires) {
+		t.Fatalf("expected idle expiry to slide past %v, got %v", data.Expires, touched.Expires)
+	}
+
+	refreshed := lastCookie(t, rr, "_test_session")
+	if refreshed.Value != cookie.Value {
+		t.Fatal("did not expect a touch to change the session ID")
+	}
+	if diff := refreshed.Expires.Sub(touched.Expires); diff < -time.Second || diff > time.Second {
+		t.Fatalf("expected cookie Expires %v to match the session, got %v", touched.Expires, refreshed.Expires)
+	}
+}
+
+func TestSessionMiddleware_RegeneratesPastAbsoluteTimeout(t *testing.T) {
+	initTestConfigWithTimeouts(30, 60)
+	sm, store := newCountingManager()
+
+	cookie, err := sm.Seed(func(sess *Session) { sess.Set("user_id", "42") })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Created two hours ago but active moments ago.
+	data, _ := store.GetSessionByID(context.Background(), cookie.Value)
+	data.CreatedAt = data.CreatedAt.Add(-2 * time.Hour)
+	store.MemorySessionManager.Save(cookie.Value, data)
+
+	var seen any
+	handler := sm.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = FromContext(r.Context()).Get("user_id")
+		w.Write([]byte("ok"))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if seen != nil {
+		t.Fatalf("expected values to be dropped, handler saw %v", seen)
+	}
+	if _, err := store.GetSessionByID(context.Background(), cookie.Value); err == nil {
+		t.Fatal("expected the expired session to be deleted")
+	}
+	if fresh := lastCookie(t, rr, "_test_session"); fresh.Value == cookie.Value {
+		t.Fatal("expected a new session ID")
+	}
+}
@@ -3,11 +3,14 @@ package session
 import (
 	"context"
 	"encoding/gob"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,12 +21,16 @@ const SESSION_FILE_PATH_DEFAULT = "tmp/sessions"
 
 const SESSION_FILE_EXT = ".session"
 
+// SESSION_USER_INDEX_DIR holds one file per user listing their session IDs
+const SESSION_USER_INDEX_DIR = "users"
+
 // FileSessionManager manages file-based sessions
 type FileSessionManager struct {
 	sessions   map[string]*SessionData
 	mu         sync.Mutex
 	dir        string
 	cookieName string
+	stop       chan struct{}
 }
 
 // NewFileSessionManager initializes a file-based session manager
@@ -37,7 +44,7 @@ func NewFileSessionManager(storageDir string, cookieName string) (*FileSessionMa
 			storageDir = filepath.Join(cwd, storageDir)
 
 			if app.IsDevelopment() {
-				log.Println("Using absolute session path:", storageDir)
+				log.Debug("using absolute session path", "path", storageDir)
 			}
 		}
 	}
@@ -57,26 +64,19 @@ func (fsm *FileSessionManager) StartSession(w http.ResponseWriter, r *http.Reque
 		if err == nil && cookie.Value != "" {
 			// Try to load existing session from file
 			existingSession := fsm.loadSession(cookie.Value)
-			log.Println("Checking for existing session:", existingSession)
 			if existingSession != nil && time.Now().Before(existingSession.Expires) {
 				// Valid session found, use it
 				fsm.mu.Lock()
 				fsm.sessions[cookie.Value] = existingSession // Update memory cache
 				fsm.mu.Unlock()
-				log.Printf("Reusing existing session: %s", cookie.Value)
+				log.DebugContext(r.Context(), "reusing existing session")
 				return existingSession, cookie.Value
 			}
 		}
 	}
 
 	// No valid session found, create a new one
-	sessionID := GenerateSessionID()
-	sessionLength := GetSessionLength()
-	sessionData := &SessionData{
-		ID:      sessionID,
-		Values:  make(map[string]any),
-		Expires: time.Now().Add(sessionLength),
-	}
+	sessionData, sessionID := newSessionData()
 
 	fsm.mu.Lock()
 	fsm.sessions[sessionID] = sessionData
@@ -84,15 +84,9 @@ func (fsm *FileSessionManager) StartSession(w http.ResponseWriter, r *http.Reque
 
 	fsm.saveSession(sessionID, sessionData)
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     fsm.cookieName,
-		Value:    sessionID,
-		Path:     "/",
-		HttpOnly: true,
-		Expires:  time.Now().Add(sessionLength),
-	})
+	http.SetCookie(w, sessionCookie(fsm.cookieName, sessionID, sessionData.Expires))
 
-	log.Printf("Created new session: %s", sessionID)
+	log.Debug("created new session")
 	return sessionData, sessionID
 }
 
@@ -103,7 +97,7 @@ func (fsm *FileSessionManager) GetSession(r *http.Request) (*SessionData, string
 	if err != nil {
 		ctxSessionID, ok := r.Context().Value(SessionIDKey).(string)
 		if !ok {
-			log.Println("Session ID not found in context")
+			log.DebugContext(r.Context(), "session ID not found in context")
 			return nil, ""
 		}
 		sessionID = ctxSessionID
@@ -146,11 +140,8 @@ func (fsm *FileSessionManager) SetValue(sessionID string, key string, value inte
 			fsm.sessions[sessionID] = session
 		} else {
 			// Create new session if doesn't exist
-			session = &SessionData{
-				ID:      sessionID,
-				Values:  make(map[string]any),
-				Expires: time.Now().Add(GetSessionLength()),
-			}
+			session, _ = newSessionData()
+			session.ID = sessionID
 			fsm.sessions[sessionID] = session
 		}
 	}
@@ -207,26 +198,23 @@ func (fsm *FileSessionManager) saveSession(sessionID string, session *SessionDat
 
 // Load session from file
 func (fsm *FileSessionManager) loadSession(sessionID string) *SessionData {
-	app := config.GetAppConfig()
 	filePath := filepath.Join(fsm.dir, sessionID+SESSION_FILE_EXT)
 
 	file, err := os.Open(filePath)
 	if err != nil {
-		log.Printf("Error opening session file: %v", err)
+		log.Debug("opening session file", "err", err)
 		return nil
 	}
 	defer file.Close()
 
 	var session SessionData
 	if err := gob.NewDecoder(file).Decode(&session); err != nil {
-		log.Printf("Error decoding session: %v", err)
+		log.Error("decoding session file", "err", err)
 		os.Remove(filePath)
 		return nil
 	}
 
-	if app.IsDevelopment() {
-		log.Printf("Successfully loaded session: %s", sessionID)
-	}
+	log.Debug("loaded session from file")
 
 	return &session
 }
@@ -248,21 +236,25 @@ func (fsm *FileSessionManager) GetSessionByID(ctx context.Context, sessionID str
 func (fsm *FileSessionManager) CleanupExpiredSessions() {
 	files, err := os.ReadDir(fsm.dir)
 	if err != nil {
-		log.Println("Error reading session directory:", err)
+		log.Error("reading session directory", "err", err)
 		return
 	}
 
 	for _, file := range files {
+		// Only session files; skip temp files and the user index directory
+		if file.IsDir() || filepath.Ext(file.Name()) != SESSION_FILE_EXT {
+			continue
+		}
 		filePath := filepath.Join(fsm.dir, file.Name())
 
 		f, err := os.Open(filePath)
 		if err != nil {
 			continue
 		}
-		defer f.Close()
-
 		var session SessionData
-		if err := gob.NewDecoder(f).Decode(&session); err != nil {
+		err = gob.NewDecoder(f).Decode(&session)
+		f.Close()
+		if err != nil {
 			os.Remove(filePath) // Corrupted session file
 			continue
 		}
@@ -273,16 +265,43 @@ func (fsm *FileSessionManager) CleanupExpiredSessions() {
 	}
 }
 
-// Cleanup expired sessions periodically
+// StartSessionCleanup removes expired session files every interval until Close is called
 func (fsm *FileSessionManager) StartSessionCleanup(interval time.Duration) {
+	fsm.mu.Lock()
+	if fsm.stop != nil {
+		fsm.mu.Unlock()
+		return
+	}
+	fsm.stop = make(chan struct{})
+	stop := fsm.stop
+	fsm.mu.Unlock()
+
 	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
 		for {
-			time.Sleep(interval)
-			fsm.CleanupExpiredSessions()
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				fsm.CleanupExpiredSessions()
+			}
 		}
 	}()
 }
 
+// Close stops the cleanup goroutine
+func (fsm *FileSessionManager) Close() error {
+	fsm.mu.Lock()
+	defer fsm.mu.Unlock()
+
+	if fsm.stop != nil {
+		close(fsm.stop)
+		fsm.stop = nil
+	}
+	return nil
+}
+
 // RemoveValue deletes a key from the session data
 func (fsm *FileSessionManager) Remove(sessionID string, key string) error {
 	fsm.mu.Lock()
@@ -323,3 +342,79 @@ func (fsm *FileSessionManager) Delete(sessionID string) error {
 	filePath := filepath.Join(fsm.dir, sessionID+SESSION_FILE_EXT)
 	return os.Remove(filePath)
 }
+
+// userIndexPath returns the index file of a user; the ID is hex encoded so it
+// is always a safe file name
+func (fsm *FileSessionManager) userIndexPath(userID string) string {
+	return filepath.Join(fsm.dir, SESSION_USER_INDEX_DIR, hex.EncodeToString([]byte(userID))+".index")
+}
+
+// readUserIndex loads a user's session IDs. Callers must hold fsm.mu.
+func (fsm *FileSessionManager) readUserIndex(userID string) ([]string, error) {
+	content, err := os.ReadFile(fsm.userIndexPath(userID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(content)), nil
+}
+
+// writeUserIndex replaces a user's session IDs. Callers must hold fsm.mu.
+func (fsm *FileSessionManager) writeUserIndex(userID string, ids []string) error {
+	path := fsm.userIndexPath(userID)
+	if len(ids) == 0 {
+		err := os.Remove(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, []byte(strings.Join(ids, "\n")+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
+
+// IndexUserSession records that the session belongs to the user
+func (fsm *FileSessionManager) IndexUserSession(userID string, sessionID string) error {
+	fsm.mu.Lock()
+	defer fsm.mu.Unlock()
+
+	ids, err := fsm.readUserIndex(userID)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(ids, sessionID) {
+		return nil
+	}
+	return fsm.writeUserIndex(userID, append(ids, sessionID))
+}
+
+// UnindexUserSession removes the session from the user's index
+func (fsm *FileSessionManager) UnindexUserSession(userID string, sessionID string) error {
+	fsm.mu.Lock()
+	defer fsm.mu.Unlock()
+
+	ids, err := fsm.readUserIndex(userID)
+	if err != nil {
+		return err
+	}
+	return fsm.writeUserIndex(userID, slices.DeleteFunc(ids, func(id string) bool {
+		return id == sessionID
+	}))
+}
+
+// UserSessionIDs returns the indexed session IDs of the user
+func (fsm *FileSessionManager) UserSessionIDs(userID string) ([]string, error) {
+	fsm.mu.Lock()
+	defer fsm.mu.Unlock()
+
+	return fsm.readUserIndex(userID)
+}
@@ -0,0 +1,203 @@
+package session
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gohst/internal/config"
+)
+
+// FingerprintAction decides what happens when a session is presented by a
+// client whose fingerprint differs from the one the session was bound to.
+type FingerprintAction string
+
+const (
+	// FingerprintLog only reports the mismatch
+	FingerprintLog FingerprintAction = "log"
+	// FingerprintRegenerate keeps the data but rotates the session ID
+	FingerprintRegenerate FingerprintAction = "regenerate"
+	// FingerprintReauth discards the session, which forces a new login
+	FingerprintReauth FingerprintAction = "reauth"
+)
+
+// FingerprintEvent describes a fingerprint mismatch.
+// Expected and Actual are the bound and presented fingerprints.
+type FingerprintEvent struct {
+	Request   *http.Request
+	Session   *Session
+	SessionID string
+	UserID    string
+	Expected  string
+	Actual    string
+	Action    FingerprintAction
+}
+
+// FingerprintPolicy binds sessions to a coarse fingerprint: the user-agent
+// family plus a prefix of the client IP. It is opt-in per SessionManager.
+type FingerprintPolicy struct {
+	Action FingerprintAction
+
+	// IPv4Prefix is the number of leading IPv4 bits bound to the session;
+	// 0 binds the whole address
+	IPv4Prefix int
+
+	// ClientIP resolves the client address; defaults to the resolver set
+	// with SetClientIPResolver
+	ClientIP func(r *http.Request) string
+
+	// CoarsenIP is applied to addresses that are not IPv4, e.g.
+	// ratelimit.CoarsenIPv6 to bind only the /64
+	CoarsenIP func(ip string) string
+
+	// OnMismatch is called for every mismatch before the action is applied,
+	// letting the app alert the user
+	OnMismatch func(event FingerprintEvent)
+}
+
+// NewFingerprintPolicy builds a policy from config.Session.Fingerprint,
+// returning nil when fingerprinting is disabled.
+func NewFingerprintPolicy() *FingerprintPolicy {
+	if config.Session == nil || config.Session.Fingerprint == nil || !config.Session.Fingerprint.Enabled {
+		return nil
+	}
+
+	conf := config.Session.Fingerprint
+	action := FingerprintAction(conf.Action)
+	switch action {
+	case FingerprintLog, FingerprintRegenerate, FingerprintReauth:
+	default:
+		log.Warn("unknown SESSION_FINGERPRINT_ACTION, falling back", "action", conf.Action, "fallback", string(FingerprintLog))
+		action = FingerprintLog
+	}
+
+	return &FingerprintPolicy{
+		Action:     action,
+		IPv4Prefix: conf.IPv4Prefix,
+	}
+}
+
+// SetFingerprintPolicy enables fingerprint binding for sessions handled by
+// this manager. Pass nil to disable it.
+func (sm *SessionManager) SetFingerprintPolicy(policy *FingerprintPolicy) {
+	sm.fingerprint = policy
+}
+
+// Fingerprint computes the coarse fingerprint of a request
+func (p *FingerprintPolicy) Fingerprint(r *http.Request) string {
+	resolve := p.ClientIP
+	if resolve == nil {
+		resolve = clientIP
+	}
+	return UserAgentFamily(r.UserAgent()) + "|" + p.coarsen(resolve(r))
+}
+
+// coarsen reduces an address to the prefix that is bound to the session
+func (p *FingerprintPolicy) coarsen(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed != nil && parsed.To4() != nil {
+		if p.IPv4Prefix > 0 && p.IPv4Prefix < 32 {
+			masked := parsed.Mask(net.CIDRMask(p.IPv4Prefix, 32))
+			return masked.String() + "/" + strconv.Itoa(p.IPv4Prefix)
+		}
+		return ip
+	}
+	if p.CoarsenIP != nil {
+		return p.CoarsenIP(ip)
+	}
+	return ip
+}
+
+// check binds new sessions and applies the policy action on a mismatch
+func (p *FingerprintPolicy) check(sess *Session, r *http.Request) {
+	actual := p.Fingerprint(r)
+	expected := sess.data.Fingerprint
+
+	if expected == "" {
+		sess.data.Fingerprint = actual
+		sess.dirty = true
+		return
+	}
+	if expected == actual {
+		return
+	}
+
+	event := FingerprintEvent{
+		Request:   r,
+		Session:   sess,
+		SessionID: sess.id,
+		UserID:    sess.UserID(),
+		Expected:  expected,
+		Actual:    actual,
+		Action:    p.Action,
+	}
+	log.WarnContext(r.Context(), "session fingerprint mismatch",
+		"user_id", event.UserID,
+		"expected", expected,
+		"actual", actual,
+		"action", string(p.Action),
+	)
+	if p.OnMismatch != nil {
+		p.OnMismatch(event)
+	}
+
+	switch p.Action {
+	case FingerprintRegenerate:
+		sess.Regenerate()
+	case FingerprintReauth:
+		sess.RegenerateNew()
+	default:
+		// Keep the original binding so every request from the new client is reported
+		return
+	}
+
+	sess.data.Fingerprint = actual
+	sess.dirty = true
+}
+
+// UserAgentFamily reduces a user agent to its browser and platform, without
+// versions, so browser updates do not change a session's fingerprint.
+func UserAgentFamily(ua string) string {
+	return userAgentBrowser(ua) + "/" + userAgentPlatform(ua)
+}
+
+func userAgentBrowser(ua string) string {
+	// Order matters: Edge and Opera also claim Chrome, Chrome also claims Safari
+	switch {
+	case ua == "":
+		return "none"
+	case strings.Contains(ua, "Edg/") || strings.Contains(ua, "Edge/"):
+		return "Edge"
+	case strings.Contains(ua, "OPR/") || strings.Contains(ua, "Opera"):
+		return "Opera"
+	case strings.Contains(ua, "Firefox/") || strings.Contains(ua, "FxiOS/"):
+		return "Firefox"
+	case strings.Contains(ua, "Chrome/") || strings.Contains(ua, "CriOS/"):
+		return "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		return "Safari"
+	default:
+		return "Other"
+	}
+}
+
+func userAgentPlatform(ua string) string {
+	// iOS and Android UAs also mention Mac OS X and Linux, so check them first
+	switch {
+	case strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPad") || strings.Contains(ua, "iPod"):
+		return "iOS"
+	case strings.Contains(ua, "Android"):
+		return "Android"
+	case strings.Contains(ua, "Windows"):
+		return "Windows"
+	case strings.Contains(ua, "Macintosh") || strings.Contains(ua, "Mac OS X"):
+		return "macOS"
+	case strings.Contains(ua, "CrOS"):
+		return "ChromeOS"
+	case strings.Contains(ua, "Linux"):
+		return "Linux"
+	default:
+		return "Other"
+	}
+}
@@ -0,0 +1,127 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	firefoxWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0"
+	chromeMac      = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+)
+
+func TestUserAgentFamily(t *testing.T) {
+	tests := map[string]string{
+		firefoxWindows: "Firefox/Windows",
+		chromeMac:      "Chrome/macOS",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0":           "Edge/Windows",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1": "Safari/iOS",
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36":                   "Chrome/Android",
+		"": "none/Other",
+	}
+	for ua, want := range tests {
+		if got := UserAgentFamily(ua); got != want {
+			t.Errorf("UserAgentFamily(%q) = %q, want %q", ua, got, want)
+		}
+	}
+
+	// Browser updates must not change the family
+	updated := "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:130.0) Gecko/20100101 Firefox/130.0"
+	if UserAgentFamily(updated) != UserAgentFamily(firefoxWindows) {
+		t.Fatal("expected versions to be ignored")
+	}
+}
+
+func TestFingerprintPolicy_CoarsensIP(t *testing.T) {
+	policy := &FingerprintPolicy{
+		IPv4Prefix: 24,
+		CoarsenIP:  func(ip string) string { return "v6:" + ip },
+	}
+	if got := policy.coarsen("203.0.113.77"); got != "203.0.113.0/24" {
+		t.Fatalf("expected IPv4 /24, got %q", got)
+	}
+	if got := policy.coarsen("2001:db8::1"); got != "v6:2001:db8::1" {
+		t.Fatalf("expected IPv6 to go through CoarsenIP, got %q", got)
+	}
+}
+
+func TestFingerprintPolicy_Actions(t *testing.T) {
+	tests := []struct {
+		action     FingerprintAction
+		sameID     bool
+		keepValues bool
+	}{
+		{FingerprintLog, true, true},
+		{FingerprintRegenerate, false, true},
+		{FingerprintReauth, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.action), func(t *testing.T) {
+			initTestConfig()
+			store, storeType := NewMemorySessionManager("_test_session")
+			sm := NewSessionManagerWithStore(store, storeType, "_test_session")
+
+			var events []FingerprintEvent
+			sm.SetFingerprintPolicy(&FingerprintPolicy{
+				Action:     tt.action,
+				IPv4Prefix: 24,
+				OnMismatch: func(event FingerprintEvent) { events = append(events, event) },
+			})
+
+			var seen any
+			handler := sm.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sess := FromContext(r.Context())
+				if r.Method == http.MethodPost {
+					sess.Set("user_id", "42")
+				}
+				seen, _ = sess.Get("user_id")
+				w.Write([]byte("ok"))
+			}))
+
+			request := func(method, ua, remote string, cookie *http.Cookie) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(method, "/", nil)
+				req.Header.Set("User-Agent", ua)
+				req.RemoteAddr = remote
+				if cookie != nil {
+					req.AddCookie(cookie)
+				}
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+				return rr
+			}
+
+			// First request creates and binds the session
+			cookie := lastCookie(t, request(http.MethodPost, firefoxWindows, "203.0.113.10:5000", nil), "_test_session")
+
+			// Same browser, neighbouring address in the same /24: no mismatch
+			request(http.MethodGet, firefoxWindows, "203.0.113.99:5000", cookie)
+			if len(events) != 0 {
+				t.Fatalf("expected no mismatch within the IP prefix, got %d", len(events))
+			}
+
+			// Different browser family: mismatch
+			rr := request(http.MethodGet, chromeMac, "203.0.113.10:5000", cookie)
+			if len(events) != 1 {
+				t.Fatalf("expected one mismatch event, got %d", len(events))
+			}
+			if events[0].Expected == events[0].Actual || events[0].Action != tt.action {
+				t.Fatalf("unexpected event %+v", events[0])
+			}
+
+			_, stillValid := store.GetValue(cookie.Value, "user_id")
+			if stillValid != tt.sameID {
+				t.Fatalf("expected old session kept = %v", tt.sameID)
+			}
+			if (seen == "42") != tt.keepValues {
+				t.Fatalf("expected values kept = %v, handler saw %v", tt.keepValues, seen)
+			}
+			if !tt.sameID {
+				if fresh := lastCookie(t, rr, "_test_session"); fresh.Value == cookie.Value {
+					t.Fatal("expected a new session ID")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package session
+
+import (
+	"gohst/internal/config"
+)
+
+// testAppConfig is a minimal AppConfigProvider for session tests.
+type testAppConfig struct{}
+
+func (testAppConfig) GetURL() string          { return "http://localhost:3030" }
+func (testAppConfig) GetDistPath() string     { return "static/dist" }
+func (testAppConfig) IsProduction() bool      { return false }
+func (testAppConfig) IsDevelopment() bool     { return false }
+func (testAppConfig) IsMaintenanceMode() bool { return false }
+
+// initTestConfig sets up the config the session package reads at runtime.
+func initTestConfig() {
+	config.RegisterAppConfig(testAppConfig{})
+	config.Session = &config.SessionConfig{
+		Length: config.SESSION_LENGTH_DEFAULT,
+		Name:   "_test_session",
+	}
+}
+
+// initTestConfigWithTimeouts also sets the idle and absolute timeouts, in minutes.
+func initTestConfigWithTimeouts(idle, absolute int) {
+	initTestConfig()
+	config.Session.IdleTimeout = idle
+	config.Session.AbsoluteTimeout = absolute
+}
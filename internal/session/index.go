@@ -0,0 +1,235 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// USER_AGENT_MAX_LENGTH caps the user agent stored with each session.
+const USER_AGENT_MAX_LENGTH = 256
+
+// ErrSessionNotFound is returned when a session handle does not belong to the user.
+var ErrSessionNotFound = errors.New("session: session not found for user")
+
+// UserSessionIndex is implemented by stores that can find every session
+// belonging to a user. Entries are added when auth data is written and
+// pruned lazily when a listed session no longer exists.
+type UserSessionIndex interface {
+	IndexUserSession(userID string, sessionID string) error
+	UnindexUserSession(userID string, sessionID string) error
+	UserSessionIDs(userID string) ([]string, error)
+}
+
+// SessionInfo describes one of a user's sessions for device lists.
+// Handle identifies the session without exposing the session ID itself.
+type SessionInfo struct {
+	Handle    string
+	IP        string
+	UserAgent string
+	CreatedAt time.Time
+	LastSeen  time.Time
+	Expires   time.Time
+	Current   bool
+}
+
+var (
+	userKey      string
+	userResolver func(val any) string
+	revokeHook   func(ctx context.Context, data *SessionData)
+	clientIP     = remoteIP
+)
+
+// SetUserResolver registers the session key that holds auth data and a
+// function that extracts a user ID from it. The auth package registers it so
+// sessions can be indexed by user without importing auth.
+func SetUserResolver(key string, fn func(val any) string) {
+	userKey = key
+	userResolver = fn
+}
+
+// SetRevokeHook registers a function called with the data of every session
+// ended by RevokeSession or RevokeOtherSessions, so state tied to a session
+// outside the store, such as a remember-me token, can be cleaned up too.
+func SetRevokeHook(fn func(ctx context.Context, data *SessionData)) {
+	revokeHook = fn
+}
+
+// SetClientIPResolver replaces how the client IP recorded with each session
+// is derived, e.g. with ratelimit.ClientIP to honour trusted proxies.
+func SetClientIPResolver(fn func(r *http.Request) string) {
+	if fn != nil {
+		clientIP = fn
+	}
+}
+
+// remoteIP is the default client IP resolver: the peer address of the request
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// SessionHandle returns the opaque identifier used for a session in device lists
+func SessionHandle(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// UserID returns the ID of the user the session is authenticated as, if any
+func (s *Session) UserID() string {
+	if s.data == nil {
+		return ""
+	}
+	return userIDFromData(s.data)
+}
+
+func userIDFromData(data *SessionData) string {
+	if userResolver == nil || userKey == "" {
+		return ""
+	}
+	val, ok := data.Values[userKey]
+	if !ok || val == nil {
+		return ""
+	}
+	return userResolver(val)
+}
+
+// recordClient stores the client IP and user agent, marking the session dirty
+// only when they changed
+func (s *Session) recordClient(r *http.Request) {
+	ip := clientIP(r)
+	ua := r.UserAgent()
+	if len(ua) > USER_AGENT_MAX_LENGTH {
+		ua = ua[:USER_AGENT_MAX_LENGTH]
+	}
+	if s.data.IP != ip || s.data.UserAgent != ua {
+		s.data.IP = ip
+		s.data.UserAgent = ua
+		s.dirty = true
+	}
+}
+
+// indexUser records the session under its user when the store keeps an index
+func (s *Session) indexUser() {
+	idx, ok := s.manager.store.(UserSessionIndex)
+	if !ok {
+		return
+	}
+	if userID := s.UserID(); userID != "" {
+		idx.IndexUserSession(userID, s.id)
+	}
+}
+
+// userIndex returns the store's user index, or an error for stores without one
+func (sm *SessionManager) userIndex() (UserSessionIndex, error) {
+	idx, ok := sm.store.(UserSessionIndex)
+	if !ok {
+		return nil, ErrStatelessStore
+	}
+	return idx, nil
+}
+
+// UserSessions lists the live sessions of a user, most recently active first.
+// currentID marks the caller's own session. Stale index entries are pruned.
+func (sm *SessionManager) UserSessions(ctx context.Context, userID string, currentID string) ([]SessionInfo, error) {
+	idx, err := sm.userIndex()
+	if err != nil {
+		return nil, err
+	}
+	ids, err := idx.UserSessionIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var sessions []SessionInfo
+	for _, id := range ids {
+		data, err := sm.store.GetSessionByID(ctx, id)
+		if err != nil || data == nil || !now.Before(data.Expires) || userIDFromData(data) != userID {
+			idx.UnindexUserSession(userID, id)
+			continue
+		}
+		sessions = append(sessions, SessionInfo{
+			Handle:    SessionHandle(id),
+			IP:        data.IP,
+			UserAgent: data.UserAgent,
+			CreatedAt: data.CreatedAt,
+			LastSeen:  data.LastSeen,
+			Expires:   data.Expires,
+			Current:   id == currentID,
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+	return sessions, nil
+}
+
+// RevokeSession ends the user's session identified by handle
+func (sm *SessionManager) RevokeSession(ctx context.Context, userID string, handle string) error {
+	idx, err := sm.userIndex()
+	if err != nil {
+		return err
+	}
+	ids, err := idx.UserSessionIDs(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if SessionHandle(id) == handle {
+			sm.runRevokeHook(ctx, id)
+			sm.store.Delete(id)
+			return idx.UnindexUserSession(userID, id)
+		}
+	}
+	return ErrSessionNotFound
+}
+
+// RevokeOtherSessions ends every session of the user except currentID and
+// returns how many were revoked. Pass an empty currentID to revoke them all.
+func (sm *SessionManager) RevokeOtherSessions(ctx context.Context, userID string, currentID string) (int, error) {
+	idx, err := sm.userIndex()
+	if err != nil {
+		return 0, err
+	}
+	ids, err := idx.UserSessionIDs(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, id := range ids {
+		if id == currentID {
+			continue
+		}
+		if data, err := sm.store.GetSessionByID(ctx, id); err == nil && data != nil {
+			revoked++
+			if revokeHook != nil {
+				revokeHook(ctx, data)
+			}
+		}
+		sm.store.Delete(id)
+		idx.UnindexUserSession(userID, id)
+	}
+	return revoked, nil
+}
+
+// runRevokeHook passes the data of a session about to be revoked to the hook
+func (sm *SessionManager) runRevokeHook(ctx context.Context, id string) {
+	if revokeHook == nil {
+		return
+	}
+	if data, err := sm.store.GetSessionByID(ctx, id); err == nil && data != nil {
+		revokeHook(ctx, data)
+	}
+}
@@ -0,0 +1,178 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+const testUserKey = "_test_user_"
+
+// useTestUserResolver indexes sessions by the string stored under testUserKey.
+func useTestUserResolver(t *testing.T) {
+	t.Helper()
+	prevKey, prevResolver := userKey, userResolver
+	SetUserResolver(testUserKey, func(val any) string {
+		id, _ := val.(string)
+		return id
+	})
+	t.Cleanup(func() { userKey, userResolver = prevKey, prevResolver })
+}
+
+func TestUserSessionIndex(t *testing.T) {
+	stores := map[string]func(t *testing.T) *SessionManager{
+		"memory": func(t *testing.T) *SessionManager {
+			store, storeType := NewMemorySessionManager("_test_session")
+			return NewSessionManagerWithStore(store, storeType, "_test_session")
+		},
+		"file": func(t *testing.T) *SessionManager {
+			store, storeType := NewFileSessionManager(t.TempDir(), "_test_session")
+			return NewSessionManagerWithStore(store, storeType, "_test_session")
+		},
+	}
+
+	for name, newManager := range stores {
+		t.Run(name, func(t *testing.T) {
+			initTestConfig()
+			useTestUserResolver(t)
+			sm := newManager(t)
+			ctx := context.Background()
+
+			login := func(userID string) string {
+				cookie, err := sm.Seed(func(sess *Session) { sess.Set(testUserKey, userID) })
+				if err != nil {
+					t.Fatal(err)
+				}
+				return cookie.Value
+			}
+			current := login("7")
+			other := login("7")
+			login("7")
+			login("8")
+
+			sessions, err := sm.UserSessions(ctx, "7", current)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sessions) != 3 {
+				t.Fatalf("expected 3 sessions for user 7, got %d", len(sessions))
+			}
+			currentCount := 0
+			for _, info := range sessions {
+				if info.Current {
+					currentCount++
+					if info.Handle != SessionHandle(current) {
+						t.Fatal("expected the current flag on the caller's session")
+					}
+				}
+			}
+			if currentCount != 1 {
+				t.Fatalf("expected exactly one current session, got %d", currentCount)
+			}
+
+			if err := sm.RevokeSession(ctx, "8", SessionHandle(other)); !errors.Is(err, ErrSessionNotFound) {
+				t.Fatalf("expected another user's session to be off limits, got %v", err)
+			}
+			if err := sm.RevokeSession(ctx, "7", SessionHandle(other)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := sm.store.GetSessionByID(ctx, other); err == nil {
+				t.Fatal("expected the revoked session to be deleted")
+			}
+
+			revoked, err := sm.RevokeOtherSessions(ctx, "7", current)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if revoked != 1 {
+				t.Fatalf("expected 1 other session to be revoked, got %d", revoked)
+			}
+
+			sessions, _ = sm.UserSessions(ctx, "7", current)
+			if len(sessions) != 1 || !sessions[0].Current {
+				t.Fatalf("expected only the current session to remain, got %+v", sessions)
+			}
+			if sessions, _ := sm.UserSessions(ctx, "8", ""); len(sessions) != 1 {
+				t.Fatalf("expected user 8 to be untouched, got %d sessions", len(sessions))
+			}
+		})
+	}
+}
+
+func TestUserSessionIndex_PrunesStaleEntries(t *testing.T) {
+	initTestConfig()
+	useTestUserResolver(t)
+	store, storeType := NewMemorySessionManager("_test_session")
+	sm := NewSessionManagerWithStore(store, storeType, "_test_session")
+
+	cookie, err := sm.Seed(func(sess *Session) { sess.Set(testUserKey, "7") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Delete(cookie.Value)
+
+	sessions, err := sm.UserSessions(context.Background(), "7", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 0 {
+		t.Fatalf("expected the deleted session to be skipped, got %d", len(sessions))
+	}
+	if ids, _ := store.UserSessionIDs("7"); len(ids) != 0 {
+		t.Fatalf("expected the stale index entry to be pruned, got %v", ids)
+	}
+}
+
+func TestUserSessionIndex_CookieStoreUnsupported(t *testing.T) {
+	initTestConfig()
+	store, storeType := NewCookieSessionManager("_test_session", testKey(t))
+	sm := NewSessionManagerWithStore(store, storeType, "_test_session")
+
+	if _, err := sm.UserSessions(context.Background(), "7", ""); !errors.Is(err, ErrStatelessStore) {
+		t.Fatalf("expected ErrStatelessStore, got %v", err)
+	}
+}
+
+func TestRevokeHook(t *testing.T) {
+	initTestConfig()
+	useTestUserResolver(t)
+	var seen []string
+	SetRevokeHook(func(ctx context.Context, data *SessionData) {
+		device, _ := data.Values["device"].(string)
+		seen = append(seen, device)
+	})
+	t.Cleanup(func() { SetRevokeHook(nil) })
+
+	store, storeType := NewMemorySessionManager("_test_session")
+	sm := NewSessionManagerWithStore(store, storeType, "_test_session")
+	ctx := context.Background()
+
+	login := func(device string) string {
+		cookie, err := sm.Seed(func(sess *Session) {
+			sess.Set(testUserKey, "7")
+			sess.Set("device", device)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cookie.Value
+	}
+	current := login("laptop")
+	phone := login("phone")
+	login("tablet")
+
+	if err := sm.RevokeSession(ctx, "7", SessionHandle(phone)); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 1 || seen[0] != "phone" {
+		t.Fatalf("expected the hook to see the phone session, got %v", seen)
+	}
+
+	seen = nil
+	if _, err := sm.RevokeOtherSessions(ctx, "7", current); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 1 || seen[0] != "tablet" {
+		t.Fatalf("expected the hook to see only the tablet session, got %v", seen)
+	}
+}
@@ -1,13 +1,20 @@
 package session
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"net/http"
+	"time"
 
 	"gohst/internal/config"
+	"gohst/internal/lifecycle"
+	"gohst/internal/logger"
 )
 
+var log = logger.Component("session")
+
 type contextKey string
 
 type csrfKey string
@@ -26,14 +33,21 @@ func SessionName() string {
 
 const SESSION_STORE_DEFAULT = "file"
 
+// SESSION_CLEANUP_INTERVAL is how often file and memory stores sweep expired sessions
+const SESSION_CLEANUP_INTERVAL = time.Minute
+
 const (
-	SESSION_TYPE_FILE  = "file"
-	SESSION_TYPE_REDIS = "redis"
+	SESSION_TYPE_FILE   = "file"
+	SESSION_TYPE_REDIS  = "redis"
+	SESSION_TYPE_COOKIE = "cookie"
+	SESSION_TYPE_MEMORY = "memory"
 )
 
 var SESSION_VALID_TYPES = []string{
 	SESSION_TYPE_FILE,
 	SESSION_TYPE_REDIS,
+	SESSION_TYPE_COOKIE,
+	SESSION_TYPE_MEMORY,
 }
 
 var SM *SessionManager
@@ -41,14 +55,25 @@ var SMAdmin *SessionManager
 
 // SessionManager using Redis
 type SessionManager struct {
-	StoreType  string
-	store      SessionStore
-	cookieName string
+	StoreType   string
+	store       SessionStore
+	cookieName  string
+	fingerprint *FingerprintPolicy
 }
 
-// Initialize the session setup
+// Initialize the session setup. Expired sessions are swept in the
+// background while the server runs.
 func Init() {
 	InitSessionManager()
+
+	lifecycle.OnStart("sessions", func(ctx context.Context) error {
+		SM.StartCleanup(SESSION_CLEANUP_INTERVAL)
+		SMAdmin.StartCleanup(SESSION_CLEANUP_INTERVAL)
+		return nil
+	})
+	lifecycle.OnShutdown("sessions", func(ctx context.Context) error {
+		return errors.Join(SM.Close(), SMAdmin.Close())
+	})
 }
 
 // Initialize the session manager
@@ -77,9 +102,16 @@ func NewSessionManagerWithName(cookieName string) *SessionManager {
 		sessionStore = SESSION_STORE_DEFAULT
 	}
 
-	if sessionStore == "redis" {
+	switch sessionStore {
+	case SESSION_TYPE_REDIS:
 		store, storeType = NewRedisSessionManager(cookieName) // Redis session manager
-	} else {
+	case SESSION_TYPE_COOKIE:
+		store, storeType = NewCookieSessionManager(cookieName) // Encrypted cookie-only sessions
+	case SESSION_TYPE_MEMORY:
+		memory, memoryType := NewMemorySessionManager(cookieName) // In-process sessions for tests and dev
+		memory.StartSessionCleanup(SESSION_CLEANUP_INTERVAL)
+		store, storeType = memory, memoryType
+	default:
 		sessionFilePath := config.GetEnv("SESSION_FILE_PATH", SESSION_FILE_PATH_DEFAULT).(string)
 		store, storeType = NewFileSessionManager(sessionFilePath, cookieName) // File-based session manager
 	}
@@ -91,6 +123,68 @@ func NewSessionManagerWithName(cookieName string) *SessionManager {
 	}
 }
 
+// NewSessionManagerWithStore wraps an already constructed store, e.g. a
+// MemorySessionManager with an injected clock in handler tests.
+func NewSessionManagerWithStore(store SessionStore, storeType string, cookieName string) *SessionManager {
+	if cookieName == "" {
+		cookieName = SessionName()
+	}
+	return &SessionManager{
+		StoreType:  storeType,
+		store:      store,
+		cookieName: cookieName,
+	}
+}
+
+// Store returns the underlying session store
+func (sm *SessionManager) Store() SessionStore {
+	return sm.store
+}
+
+// Seed starts a new session, lets fn populate it through the regular Session
+// API, and returns the cookie a client would send back. Tests use it to
+// pre-seed auth data or flash values before calling a handler wrapped with
+// SessionMiddleware.
+func (sm *SessionManager) Seed(fn func(sess *Session)) (*http.Cookie, error) {
+	rec := &cookieRecorder{header: make(http.Header)}
+	sessionData, sid := newSessionData()
+
+	sess := &Session{
+		id:      sid,
+		data:    sessionData,
+		manager: sm,
+		w:       rec,
+		dirty:   true, // always persist, even if fn writes nothing
+	}
+	if fn != nil {
+		fn(sess)
+	}
+	if err := sess.Flush(); err != nil {
+		return nil, err
+	}
+
+	var seeded *http.Cookie
+	for _, c := range (&http.Response{Header: rec.header}).Cookies() {
+		if c.Name == sm.cookieName {
+			seeded = c
+		}
+	}
+	if seeded == nil {
+		return nil, errors.New("session: seeded session did not set a cookie")
+	}
+	return seeded, nil
+}
+
+// cookieRecorder is a header-only ResponseWriter used to capture Set-Cookie
+// headers outside of a real request.
+type cookieRecorder struct {
+	header http.Header
+}
+
+func (c *cookieRecorder) Header() http.Header         { return c.header }
+func (c *cookieRecorder) Write(b []byte) (int, error) { return len(b), nil }
+func (c *cookieRecorder) WriteHeader(int)             {}
+
 // GenerateSessionID creates a unique session ID
 func GenerateSessionID() string {
 	b := make([]byte, 32)
@@ -98,6 +192,28 @@ func GenerateSessionID() string {
 	return base64.URLEncoding.EncodeToString(b)
 }
 
+// cleaner is implemented by stores that sweep expired sessions in the background
+type cleaner interface {
+	StartSessionCleanup(interval time.Duration)
+	Close() error
+}
+
+// StartCleanup starts sweeping expired sessions, for stores that need it.
+// Redis and cookie sessions expire on their own.
+func (sm *SessionManager) StartCleanup(interval time.Duration) {
+	if c, ok := sm.store.(cleaner); ok {
+		c.StartSessionCleanup(interval)
+	}
+}
+
+// Close stops the store's background cleanup, if it has one
+func (sm *SessionManager) Close() error {
+	if c, ok := sm.store.(cleaner); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 // StartSession creates a session and stores it in Redis
 func (sm *SessionManager) StartSession(w http.ResponseWriter, r *http.Request) (*SessionData, string) {
 	return sm.store.StartSession(w, r)
@@ -0,0 +1,274 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MemorySessionManager is a thread-safe, in-process session store with TTL
+// expiry. It is meant for tests and single-process development; sessions are
+// lost on restart and are not shared between instances.
+type MemorySessionManager struct {
+	mu         sync.Mutex
+	sessions   map[string]*SessionData
+	users      map[string]map[string]struct{}
+	cookieName string
+	now        func() time.Time
+	stop       chan struct{}
+}
+
+// MemoryOption configures a MemorySessionManager.
+type MemoryOption func(*MemorySessionManager)
+
+// WithClock replaces time.Now, letting tests move time forward to expire sessions.
+func WithClock(now func() time.Time) MemoryOption {
+	return func(m *MemorySessionManager) { m.now = now }
+}
+
+// NewMemorySessionManager initializes an in-memory session manager
+func NewMemorySessionManager(cookieName string, opts ...MemoryOption) (*MemorySessionManager, string) {
+	m := &MemorySessionManager{
+		sessions:   make(map[string]*SessionData),
+		users:      make(map[string]map[string]struct{}),
+		cookieName: cookieName,
+		now:        time.Now,
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	return m, SESSION_TYPE_MEMORY
+}
+
+// StartSession creates a new session in memory and sets the cookie
+func (msm *MemorySessionManager) StartSession(w http.ResponseWriter, r *http.Request) (*SessionData, string) {
+	sessionData, sessionID := newSessionDataAt(msm.now())
+
+	msm.mu.Lock()
+	msm.sessions[sessionID] = sessionData
+	msm.mu.Unlock()
+
+	http.SetCookie(w, sessionCookie(msm.cookieName, sessionID, sessionData.Expires))
+
+	return copySessionData(sessionData), sessionID
+}
+
+// GetSession retrieves the session referenced by the request cookie
+func (msm *MemorySessionManager) GetSession(r *http.Request) (*SessionData, string) {
+	cookie, err := r.Cookie(msm.cookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, ""
+	}
+
+	session, err := msm.GetSessionByID(r.Context(), cookie.Value)
+	if err != nil {
+		return nil, ""
+	}
+	return session, cookie.Value
+}
+
+// SetValue stores a value in the session
+func (msm *MemorySessionManager) SetValue(sessionID string, key string, value interface{}) {
+	msm.mu.Lock()
+	defer msm.mu.Unlock()
+
+	session, ok := msm.live(sessionID)
+	if !ok {
+		return
+	}
+	session.Values[key] = value
+}
+
+// GetValue retrieves a value from the session
+func (msm *MemorySessionManager) GetValue(sessionID string, key string) (interface{}, bool) {
+	msm.mu.Lock()
+	defer msm.mu.Unlock()
+
+	session, ok := msm.live(sessionID)
+	if !ok {
+		return nil, false
+	}
+	val, ok := session.Values[key]
+	return val, ok
+}
+
+// GetSessionByID returns a copy of the session so callers cannot mutate the store
+func (msm *MemorySessionManager) GetSessionByID(ctx context.Context, sessionID string) (*SessionData, error) {
+	msm.mu.Lock()
+	defer msm.mu.Unlock()
+
+	session, ok := msm.live(sessionID)
+	if !ok {
+		return nil, fmt.Errorf("session not found: %s", sessionID)
+	}
+	return copySessionData(session), nil
+}
+
+// Remove deletes a key from the session
+func (msm *MemorySessionManager) Remove(sessionID string, key string) error {
+	msm.mu.Lock()
+	defer msm.mu.Unlock()
+
+	session, ok := msm.live(sessionID)
+	if !ok {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	delete(session.Values, key)
+	return nil
+}
+
+// Save replaces the entire session
+func (msm *MemorySessionManager) Save(sessionID string, session *SessionData) error {
+	msm.mu.Lock()
+	defer msm.mu.Unlock()
+
+	stored := copySessionData(session)
+	stored.ID = sessionID
+	if stored.Expires.IsZero() {
+		stored.touch(msm.now())
+	}
+	msm.sessions[sessionID] = stored
+	return nil
+}
+
+// Delete removes the entire session
+func (msm *MemorySessionManager) Delete(sessionID string) error {
+	msm.mu.Lock()
+	defer msm.mu.Unlock()
+
+	delete(msm.sessions, sessionID)
+	return nil
+}
+
+// IndexUserSession records that the session belongs to the user
+func (msm *MemorySessionManager) IndexUserSession(userID string, sessionID string) error {
+	msm.mu.Lock()
+	defer msm.mu.Unlock()
+
+	ids, ok := msm.users[userID]
+	if !ok {
+		ids = make(map[string]struct{})
+		msm.users[userID] = ids
+	}
+	ids[sessionID] = struct{}{}
+	return nil
+}
+
+// UnindexUserSession removes the session from the user's index
+func (msm *MemorySessionManager) UnindexUserSession(userID string, sessionID string) error {
+	msm.mu.Lock()
+	defer msm.mu.Unlock()
+
+	delete(msm.users[userID], sessionID)
+	if len(msm.users[userID]) == 0 {
+		delete(msm.users, userID)
+	}
+	return nil
+}
+
+// UserSessionIDs returns the indexed session IDs of the user
+func (msm *MemorySessionManager) UserSessionIDs(userID string) ([]string, error) {
+	msm.mu.Lock()
+	defer msm.mu.Unlock()
+
+	ids := make([]string, 0, len(msm.users[userID]))
+	for id := range msm.users[userID] {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Len returns the number of live sessions, which is handy in tests.
+func (msm *MemorySessionManager) Len() int {
+	msm.mu.Lock()
+	defer msm.mu.Unlock()
+
+	count := 0
+	for id := range msm.sessions {
+		if _, ok := msm.live(id); ok {
+			count++
+		}
+	}
+	return count
+}
+
+// CleanupExpiredSessions drops every expired session
+func (msm *MemorySessionManager) CleanupExpiredSessions() {
+	msm.mu.Lock()
+	defer msm.mu.Unlock()
+
+	for id := range msm.sessions {
+		msm.live(id)
+	}
+}
+
+// StartSessionCleanup sweeps expired sessions every interval until Close is called
+func (msm *MemorySessionManager) StartSessionCleanup(interval time.Duration) {
+	msm.mu.Lock()
+	if msm.stop != nil {
+		msm.mu.Unlock()
+		return
+	}
+	msm.stop = make(chan struct{})
+	stop := msm.stop
+	msm.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				msm.CleanupExpiredSessions()
+			}
+		}
+	}()
+}
+
+// Close stops the cleanup goroutine
+func (msm *MemorySessionManager) Close() error {
+	msm.mu.Lock()
+	defer msm.mu.Unlock()
+
+	if msm.stop != nil {
+		close(msm.stop)
+		msm.stop = nil
+	}
+	return nil
+}
+
+// live returns the session if it exists and has not expired, evicting it otherwise.
+// Callers must hold msm.mu.
+func (msm *MemorySessionManager) live(sessionID string) (*SessionData, bool) {
+	session, ok := msm.sessions[sessionID]
+	if !ok {
+		return nil, false
+	}
+	if !msm.now().Before(session.Expires) {
+		delete(msm.sessions, sessionID)
+		return nil, false
+	}
+	return session, true
+}
+
+// copySessionData makes a shallow copy of the session and its value map
+func copySessionData(session *SessionData) *SessionData {
+	values := make(map[string]any, len(session.Values))
+	for k, v := range session.Values {
+		values[k] = v
+	}
+	return &SessionData{
+		ID:          session.ID,
+		Values:      values,
+		Expires:     session.Expires,
+		CreatedAt:   session.CreatedAt,
+		LastSeen:    session.LastSeen,
+		IP:          session.IP,
+		UserAgent:   session.UserAgent,
+		Fingerprint: session.Fingerprint,
+	}
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for expiry tests.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestMemoryStore_ExpiresWithClock(t *testing.T) {
+	initTestConfig()
+	clock := &fakeClock{now: time.Now()}
+	store, _ := NewMemorySessionManager("_test_session", WithClock(clock.Now))
+
+	rr := httptest.NewRecorder()
+	_, id := store.StartSession(rr, nil)
+	store.SetValue(id, "user", "alice")
+
+	if val, ok := store.GetValue(id, "user"); !ok || val != "alice" {
+		t.Fatalf("expected stored value, got %v", val)
+	}
+
+	clock.Advance(GetSessionLength() + time.Second)
+
+	if _, ok := store.GetValue(id, "user"); ok {
+		t.Fatal("expected value to be gone after the session expired")
+	}
+	if store.Len() != 0 {
+		t.Fatalf("expected expired session to be evicted, %d left", store.Len())
+	}
+}
+
+func TestMemoryStore_CopiesOnRead(t *testing.T) {
+	initTestConfig()
+	store, _ := NewMemorySessionManager("_test_session")
+
+	_, id := store.StartSession(httptest.NewRecorder(), nil)
+	store.SetValue(id, "role", "user")
+
+	data, err := store.GetSessionByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.Values["role"] = "admin"
+
+	if val, _ := store.GetValue(id, "role"); val != "user" {
+		t.Fatalf("expected store to be isolated from callers, got %v", val)
+	}
+}
+
+func TestMemoryStore_SeededMiddleware(t *testing.T) {
+	initTestConfig()
+	store, storeType := NewMemorySessionManager("_test_session")
+	sm := NewSessionManagerWithStore(store, storeType, "_test_session")
+
+	cookie, err := sm.Seed(func(sess *Session) {
+		sess.Set("user_id", "42")
+		sess.SetFlash("success", "Welcome back")
+	})
+	if err != nil {
+		t.Fatalf("Seed: %v", err)
+	}
+
+	var userID any
+	var flash any
+	handler := sm.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess := FromContext(r.Context())
+		userID, _ = sess.Get("user_id")
+		flash = sess.GetFlash("success")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if userID != "42" {
+		t.Fatalf("expected seeded user_id, got %v", userID)
+	}
+	if flash != "Welcome back" {
+		t.Fatalf("expected seeded flash, got %v", flash)
+	}
+	if val, ok := store.GetValue(cookie.Value, "_gohst_flash_success"); ok {
+		t.Fatalf("expected flash to be consumed, still have %v", val)
+	}
+}
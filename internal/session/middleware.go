@@ -1,37 +1,112 @@
 package session
 
 import (
-	"context"
-	"log"
 	"net/http"
+	"time"
 
-	"gohst/internal/config"
+	"gohst/internal/logger"
 )
 
-// Middleware to attach session to request context
+// Middleware to attach session to request context.
+// Session writes are buffered and flushed once, either just before the
+// response headers are written or when the handler returns.
 func (sm *SessionManager) SessionMiddleware(next http.Handler) http.Handler {
-    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		app := config.GetAppConfig()
-        // Load or create raw session data
-        sessionData, sid := sm.store.GetSession(r)
-        if sessionData == nil {
-            sessionData, sid = sm.store.StartSession(w, r)
-
-			if app.IsDevelopment() {
-				log.Println("Started a new session with ID:", sid)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Load raw session data, or build a new session that is only
+		// persisted once something is written to it
+		sessionData, sid := sm.store.GetSession(r)
+		persisted := sessionData != nil
+		if !persisted {
+			sessionData, sid = newSessionData()
+
+			log.DebugContext(r.Context(), "started a new session")
+		}
+
+		// Wrap it in Session type
+		sess := &Session{
+			id:        sid,
+			data:      sessionData,
+			manager:   sm,
+			w:         w,
+			persisted: persisted,
+		}
+
+		now := time.Now()
+		if persisted {
+			switch {
+			case sessionData.AbsoluteExpired(now) || !now.Before(sessionData.Expires):
+				// Past the idle or absolute limit: activity must not revive it,
+				// so start over under a new ID
+				log.DebugContext(r.Context(), "session expired, regenerating")
+				sess.RegenerateNew()
+			case sessionData.needsTouch(now):
+				// Slide the idle expiry, at most once per touch interval,
+				// and refresh the user index entry along with it
+				sess.dirty = true
+				sess.reindex = true
+				sess.recordClient(r)
 			}
-        }
-
-        // Wrap it in Session type
-        sess := &Session{
-            id:      sid,
-            data:    sessionData,
-            manager: sm,
-            w:       w,
-        }
-
-        // Put the *Session into context (so handlers can grab it)
-        ctx := context.WithValue(r.Context(), sessionKey, sess)
-        next.ServeHTTP(w, r.WithContext(ctx))
-    })
+		} else {
+			sess.recordClient(r)
+		}
+
+		// Bind new sessions to the client and check existing ones
+		if sm.fingerprint != nil {
+			sm.fingerprint.check(sess, r)
+		}
+
+		sw := &sessionWriter{ResponseWriter: w, sess: sess}
+
+		// Tag the request's log records with the user, and again afterwards
+		// if the handler logged someone in; a logout keeps the user who left
+		logger.SetUserID(r.Context(), sess.UserID())
+
+		// Put the *Session into context (so handlers can grab it)
+		ctx := WithSession(r.Context(), sess)
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		sess.Flush()
+		if userID := sess.UserID(); userID != "" {
+			logger.SetUserID(r.Context(), userID)
+		}
+	})
+}
+
+// sessionWriter flushes the session before the response headers go out,
+// which is the last point at which the session cookie can still be set.
+type sessionWriter struct {
+	http.ResponseWriter
+	sess        *Session
+	wroteHeader bool
+}
+
+func (sw *sessionWriter) WriteHeader(code int) {
+	if !sw.wroteHeader {
+		sw.wroteHeader = true
+		sw.sess.Flush()
+		sw.sess.headersSent = true
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *sessionWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher for streaming handlers
+func (sw *sessionWriter) Flush() {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (sw *sessionWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
 }
@@ -0,0 +1,130 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// countingStore records how often the middleware writes to the backend.
+type countingStore struct {
+	*MemorySessionManager
+	saves   int
+	deletes int
+}
+
+func (c *countingStore) Save(sessionID string, session *SessionData) error {
+	c.saves++
+	return c.MemorySessionManager.Save(sessionID, session)
+}
+
+func (c *countingStore) Delete(sessionID string) error {
+	c.deletes++
+	return c.MemorySessionManager.Delete(sessionID)
+}
+
+func newCountingManager() (*SessionManager, *countingStore) {
+	memory, storeType := NewMemorySessionManager("_test_session")
+	store := &countingStore{MemorySessionManager: memory}
+	return NewSessionManagerWithStore(store, storeType, "_test_session"), store
+}
+
+func countCookies(rr *httptest.ResponseRecorder, name string) int {
+	n := 0
+	for _, c := range (&http.Response{Header: rr.Header()}).Cookies() {
+		if c.Name == name {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSessionMiddleware_FlushesOnce(t *testing.T) {
+	initTestConfig()
+	sm, store := newCountingManager()
+
+	handler := sm.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess := FromContext(r.Context())
+		for _, field := range []string{"email", "email_confirm", "first_name", "last_name", "password"} {
+			sess.SetOld(field, "value")
+		}
+		sess.SetFlash("register_error", "All fields are required")
+		http.Redirect(w, r, "/register", http.StatusSeeOther)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/register", nil))
+
+	if store.saves != 1 {
+		t.Fatalf("expected exactly one store write, got %d", store.saves)
+	}
+	if n := countCookies(rr, "_test_session"); n != 1 {
+		t.Fatalf("expected exactly one Set-Cookie, got %d", n)
+	}
+}
+
+func TestSessionMiddleware_NoWriteWhenUnchanged(t *testing.T) {
+	initTestConfig()
+	sm, store := newCountingManager()
+
+	cookie, err := sm.Seed(func(sess *Session) { sess.Set("user_id", "42") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.saves = 0
+
+	handler := sm.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Get("user_id")
+		w.Write([]byte("ok"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if store.saves != 0 {
+		t.Fatalf("expected no store writes, got %d", store.saves)
+	}
+	if n := countCookies(rr, "_test_session"); n != 0 {
+		t.Fatalf("expected no Set-Cookie, got %d", n)
+	}
+}
+
+func TestSessionMiddleware_RegenerateFlushesNewID(t *testing.T) {
+	initTestConfig()
+	sm, store := newCountingManager()
+
+	cookie, err := sm.Seed(func(sess *Session) { sess.Set("cart", "3 items") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.saves = 0
+
+	handler := sm.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess := FromContext(r.Context())
+		sess.Set("user_id", "42")
+		sess.Regenerate()
+		w.Write([]byte("ok"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if store.saves != 1 || store.deletes != 1 {
+		t.Fatalf("expected one save and one delete, got %d saves and %d deletes", store.saves, store.deletes)
+	}
+	if _, ok := store.GetValue(cookie.Value, "cart"); ok {
+		t.Fatal("expected the old session to be deleted")
+	}
+
+	fresh := lastCookie(t, rr, "_test_session")
+	if fresh.Value == cookie.Value {
+		t.Fatal("expected the cookie to carry the regenerated ID")
+	}
+	if val, _ := store.GetValue(fresh.Value, "user_id"); val != "42" {
+		t.Fatalf("expected values to move to the new session, got %v", val)
+	}
+}
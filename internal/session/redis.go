@@ -4,107 +4,73 @@ import (
 	"bytes"
 	"context"
 	"encoding/gob"
-	"log"
 	"net/http"
-	"strconv"
 	"time"
 
 	"gohst/internal/config"
+	"gohst/internal/health"
+	"gohst/internal/redisconn"
 
 	"github.com/redis/go-redis/v9"
 )
 
-const REDIS_DB_DEFAULT = 0
+const SESSION_REDIS_PREFIX_DEFAULT = "gohst:sess:"
 
-const REDIS_HOST_DEFAULT = "localhost"
-
-const REDIS_PASSWORD_DEFAULT = ""
-
-const REDIS_PORT_DEFAULT = 6379
-
-// RedisSessionManager handles Redis-based sessions
+// RedisSessionManager handles Redis-based sessions on the shared Redis client.
+// Session keys are namespaced with config.Session.RedisPrefix.
 type RedisSessionManager struct {
-	redisClient *redis.Client
+	redisClient redis.UniversalClient
 	cookieName  string
+	prefix      string
 }
 
-// NewRedisSessionManager initializes Redis connection
+// NewRedisSessionManager initializes a session manager on the shared Redis client
 func NewRedisSessionManager(cookieName string) (*RedisSessionManager, string) {
-	redisConf := config.Session.Redis
-	password := REDIS_PASSWORD_DEFAULT
-	db := REDIS_DB_DEFAULT
-
-	if redisConf != nil {
-		if redisConf.Host != "" {
-			password = redisConf.Password
-		}
-		if redisConf.Port != 0 {
-			db = redisConf.DB
-		}
-	}
-
-	client := redis.NewClient(&redis.Options{
-		Addr:     GetRedisHostAddr(),
-		Password: password,
-		DB:       db,
+	prefix := SESSION_REDIS_PREFIX_DEFAULT
+	if config.Session != nil && config.Session.RedisPrefix != "" {
+		prefix = config.Session.RedisPrefix
+	}
+	client := redisconn.Client()
+	health.Register("redis:sessions", func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
 	})
-
-	return &RedisSessionManager{redisClient: client, cookieName: cookieName}, "redis"
+	return NewRedisSessionManagerWithClient(client, prefix, cookieName), SESSION_TYPE_REDIS
 }
 
-// GetRedisHostAddr returns the Redis host address
-func GetRedisHostAddr() string {
-	redis := config.Session.Redis
-	host := REDIS_HOST_DEFAULT
-	port := REDIS_PORT_DEFAULT
-
-	if redis != nil {
-		if redis.Host != "" {
-			host = redis.Host
-		}
-		if redis.Port != 0 {
-			port = redis.Port
-		}
-	}
+// NewRedisSessionManagerWithClient builds a Redis session manager on the given client
+func NewRedisSessionManagerWithClient(client redis.UniversalClient, prefix string, cookieName string) *RedisSessionManager {
+	return &RedisSessionManager{redisClient: client, cookieName: cookieName, prefix: prefix}
+}
 
-	return host + ":" + strconv.Itoa(port)
+// key namespaces a session ID
+func (rsm *RedisSessionManager) key(sessionID string) string {
+	return rsm.prefix + sessionID
 }
 
 // StartSession creates a session in Redis using Gob
 func (rsm *RedisSessionManager) StartSession(w http.ResponseWriter, r *http.Request) (*SessionData, string) {
-	sessionID := GenerateSessionID()
 	ctx := context.Background()
 
-	sessionData := &SessionData{
-		ID:      sessionID,
-		Values:  make(map[string]interface{}),
-		Expires: time.Now().Add(30 * time.Minute),
-		manager: rsm,
-	}
+	sessionData, sessionID := newSessionData()
+	sessionData.manager = rsm
 
 	// Encode session to Gob format
 	var buf bytes.Buffer
 	encoder := gob.NewEncoder(&buf)
 	err := encoder.Encode(sessionData)
 	if err != nil {
-		log.Println("Error encoding session:", err)
+		log.ErrorContext(ctx, "encoding session", "err", err)
 		return &SessionData{}, ""
 	}
 
-	// Store Gob-encoded session in Redis (expires in 30 minutes)
-	sessionLength := GetSessionLength()
-	err = rsm.redisClient.Set(ctx, sessionID, buf.Bytes(), sessionLength).Err()
+	// Store Gob-encoded session in Redis, expiring with the session
+	err = rsm.redisClient.Set(ctx, rsm.key(sessionID), buf.Bytes(), sessionTTL(sessionData)).Err()
 	if err != nil {
-		log.Println("Error storing session in Redis:", err)
+		log.ErrorContext(ctx, "storing session in Redis", "err", err)
 	}
 
 	// Set session ID in cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     rsm.cookieName,
-		Value:    sessionID,
-		Path:     "/",
-		HttpOnly: true,
-	})
+	http.SetCookie(w, sessionCookie(rsm.cookieName, sessionID, sessionData.Expires))
 
 	return sessionData, sessionID
 }
@@ -118,7 +84,7 @@ func (rsm *RedisSessionManager) GetSession(r *http.Request) (*SessionData, strin
 
 	// If session ID is not in the cookie, return nil
 	ctx := context.Background()
-	val, err := rsm.redisClient.Get(ctx, cookie.Value).Bytes()
+	val, err := rsm.redisClient.Get(ctx, rsm.key(cookie.Value)).Bytes()
 	if err != nil {
 		return nil, ""
 	}
@@ -128,7 +94,7 @@ func (rsm *RedisSessionManager) GetSession(r *http.Request) (*SessionData, strin
 	decoder := gob.NewDecoder(bytes.NewReader(val))
 	err = decoder.Decode(&sessionData)
 	if err != nil {
-		log.Println("Error decoding session:", err)
+		log.Error("decoding session", "err", err)
 		return nil, ""
 	}
 
@@ -142,7 +108,7 @@ func (rsm *RedisSessionManager) SetValue(sessionID string, key string, value int
 	// Get current session data
 	sessionData, err := rsm.GetSessionByID(ctx, sessionID)
 	if err != nil {
-		log.Println("Session not found:", sessionID)
+		log.Debug("session not found")
 		return
 	}
 
@@ -154,14 +120,14 @@ func (rsm *RedisSessionManager) SetValue(sessionID string, key string, value int
 	encoder := gob.NewEncoder(&buf)
 	err = encoder.Encode(sessionData)
 	if err != nil {
-		log.Println("Error encoding session data:", err)
+		log.ErrorContext(ctx, "encoding session data", "err", err)
 		return
 	}
 
 	// Save the updated session back to Redis
-	err = rsm.redisClient.Set(ctx, sessionID, buf.Bytes(), 30*time.Minute).Err()
+	err = rsm.redisClient.Set(ctx, rsm.key(sessionID), buf.Bytes(), sessionTTL(sessionData)).Err()
 	if err != nil {
-		log.Println("Error storing updated session:", err)
+		log.ErrorContext(ctx, "storing updated session", "err", err)
 	}
 }
 
@@ -177,7 +143,7 @@ func (rsm *RedisSessionManager) GetValue(sessionID string, key string) (interfac
 
 // GetSessionByID fetches session data directly using session ID
 func (rsm *RedisSessionManager) GetSessionByID(ctx context.Context, sessionID string) (*SessionData, error) {
-	val, err := rsm.redisClient.Get(ctx, sessionID).Bytes() // Get session as bytes
+	val, err := rsm.redisClient.Get(ctx, rsm.key(sessionID)).Bytes() // Get session as bytes
 	if err != nil {
 		return nil, err
 	}
@@ -212,13 +178,7 @@ func (rsm *RedisSessionManager) Remove(sessionID string, key string) error {
 	}
 
 	// Save the updated session back to Redis with the same expiration
-	ttl, err := rsm.redisClient.TTL(ctx, sessionID).Result()
-	if err != nil {
-		ttl = 30 * time.Minute // Default if TTL can't be retrieved
-	}
-
-	// Save the updated session back to Redis
-	err = rsm.redisClient.Set(ctx, sessionID, buf.Bytes(), ttl).Err()
+	err = rsm.redisClient.Set(ctx, rsm.key(sessionID), buf.Bytes(), sessionTTL(sessionData)).Err()
 	if err != nil {
 		return err
 	}
@@ -238,18 +198,57 @@ func (rsm *RedisSessionManager) Save(sessionID string, session *SessionData) err
 		return err
 	}
 
-	// Get current TTL if possible
-	ttl, err := rsm.redisClient.TTL(ctx, sessionID).Result()
-	if err != nil || ttl < 0 {
-		ttl = GetSessionLength() // Default if TTL can't be retrieved
-	}
-
-	// Save to Redis with the same expiration
-	return rsm.redisClient.Set(ctx, sessionID, buf.Bytes(), ttl).Err()
+	// Save to Redis, expiring with the session
+	return rsm.redisClient.Set(ctx, rsm.key(sessionID), buf.Bytes(), sessionTTL(session)).Err()
 }
 
 // Delete removes the entire session
 func (rsm *RedisSessionManager) Delete(sessionID string) error {
 	ctx := context.Background()
-	return rsm.redisClient.Del(ctx, sessionID).Err()
+	return rsm.redisClient.Del(ctx, rsm.key(sessionID)).Err()
+}
+
+// userIndexKey is the Redis set holding a user's session IDs
+func (rsm *RedisSessionManager) userIndexKey(userID string) string {
+	return rsm.prefix + "user:" + userID
+}
+
+// IndexUserSession adds the session to the user's set and keeps the set alive
+// at least as long as the longest possible session
+func (rsm *RedisSessionManager) IndexUserSession(userID string, sessionID string) error {
+	ctx := context.Background()
+	key := rsm.userIndexKey(userID)
+
+	ttl := GetIdleTimeout()
+	if absolute := GetAbsoluteTimeout(); absolute > ttl {
+		ttl = absolute
+	}
+
+	pipe := rsm.redisClient.TxPipeline()
+	pipe.SAdd(ctx, key, sessionID)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// UnindexUserSession removes the session from the user's set
+func (rsm *RedisSessionManager) UnindexUserSession(userID string, sessionID string) error {
+	return rsm.redisClient.SRem(context.Background(), rsm.userIndexKey(userID), sessionID).Err()
+}
+
+// UserSessionIDs returns the indexed session IDs of the user
+func (rsm *RedisSessionManager) UserSessionIDs(userID string) ([]string, error) {
+	return rsm.redisClient.SMembers(context.Background(), rsm.userIndexKey(userID)).Result()
+}
+
+// sessionTTL keeps the Redis key alive exactly as long as the session itself
+func sessionTTL(session *SessionData) time.Duration {
+	if session.Expires.IsZero() {
+		return GetIdleTimeout()
+	}
+	ttl := time.Until(session.Expires)
+	if ttl <= 0 {
+		ttl = time.Second // Already expired; let Redis drop it right away
+	}
+	return ttl
 }
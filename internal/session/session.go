@@ -2,7 +2,6 @@ package session
 
 import (
 	"context"
-	"log"
 	"net/http"
 	"time"
 
@@ -22,12 +21,21 @@ const (
 	fieldErrorsPrefix fieldErrorsKey  = "_gohst_field_errors_" // for field-specific errors
 )
 
-// Session is what your handlers will actually use
+// Session is what your handlers will actually use.
+// Writes are buffered in memory and persisted by Flush, which
+// SessionMiddleware calls once per request.
 type Session struct {
 	id      string
 	data    *SessionData
 	manager *SessionManager
 	w       http.ResponseWriter
+
+	dirty       bool     // values changed since the last flush
+	persisted   bool     // the store already holds this session ID
+	headersSent bool     // response headers are written, cookies can no longer be set
+	staleIDs    []string // IDs replaced by Regenerate, deleted on flush
+	reindex     bool     // auth data or the ID changed, update the user index on flush
+	ephemeral   bool     // lives for one request only, never saved and sets no cookie
 }
 
 // FromContext pulls the *Session out of the context (or nil)
@@ -38,11 +46,41 @@ func FromContext(ctx context.Context) *Session {
 	return nil
 }
 
+// NewEphemeral returns a session that lives only for the current request.
+// It is never saved and sets no cookie; bearer-token requests use one to
+// carry auth data through the same helpers as browser sessions.
+func NewEphemeral() *Session {
+	now := time.Now()
+	id := GenerateSessionID()
+	data := &SessionData{
+		ID:        id,
+		Values:    make(map[string]any),
+		CreatedAt: now,
+		LastSeen:  now,
+	}
+	return &Session{id: id, data: data, ephemeral: true}
+}
+
+// WithSession returns a copy of ctx that carries sess for FromContext
+func WithSession(ctx context.Context, sess *Session) context.Context {
+	return context.WithValue(ctx, sessionKey, sess)
+}
+
+// Ephemeral reports whether the session is request-scoped and never saved
+func (s *Session) Ephemeral() bool {
+	return s.ephemeral
+}
+
 // ID returns the session ID
 func (s *Session) ID() string {
 	return s.id
 }
 
+// Manager returns the session manager that owns this session
+func (s *Session) Manager() *SessionManager {
+	return s.manager
+}
+
 // Get returns a value (and whether it was present)
 func (s *Session) Get(key string) (any, bool) {
 	if s.data == nil {
@@ -64,10 +102,11 @@ func (s *Session) GetCSRF() (any, bool) {
 // Set the CSRF Token in the session
 func (s *Session) SetCSRF(csrf string) *Session {
 	if csrf == "" {
-		log.Println("CSRF token is nil, generating a new one")
+		log.Debug("CSRF token is empty, generating a new one")
 		csrf, _ = utils.GenerateCSRF() // Generate a new CSRF token
 	}
 	s.data.Values[string(CSRFKey)] = csrf
+	s.dirty = true
 	return s
 }
 
@@ -79,48 +118,124 @@ func (s *Session) RemoveCSRF() {
 	s.Remove(string(CSRFKey))
 }
 
-// Set writes a value, persists to the store, and re-sets the cookie
-func (s *Session) Set(key string, val any) {
+// Set writes a value; it is persisted and the cookie refreshed on Flush.
+// Values the stores cannot gob-encode, usually because the type was never
+// passed to gob.Register, are rejected with ErrUnencodableValue.
+func (s *Session) Set(key string, val any) error {
+	if err := checkEncodable(key, val); err != nil {
+		log.Error("rejected session value", "err", err)
+		return err
+	}
+
 	s.data.Values[key] = val
-	s.manager.store.SetValue(s.id, key, val)
-	// refresh cookie so client sees it
+	s.dirty = true
+	if key == userKey {
+		s.reindex = true
+	}
+	return nil
+}
+
+// Dirty reports whether the session has changes that have not been flushed
+func (s *Session) Dirty() bool {
+	return s.dirty
+}
+
+// Flush persists pending changes with a single store write and re-sets the
+// cookie. It does nothing when the session is unchanged or ephemeral. Once the response
+// headers are out the cookie can no longer change, so later flushes only
+// update the store.
+func (s *Session) Flush() error {
+	if !s.dirty || s.ephemeral {
+		return nil
+	}
+
+	// Every write counts as activity and slides the idle expiry
+	s.data.touch(time.Now())
 
+	if err := s.manager.Save(s.id, s.data); err != nil {
+		log.Error("saving session", "err", err)
+		return err
+	}
+	s.dirty = false
+	s.persisted = true
+
+	for _, id := range s.staleIDs {
+		s.manager.Delete(id)
+	}
+	s.staleIDs = nil
+
+	if s.reindex {
+		s.indexUser()
+		s.reindex = false
+	}
+
+	if s.headersSent {
+		log.Warn("session changed after the response headers were written; cookie not updated")
+		return nil
+	}
 	s.setSessionCookie()
+	return nil
 }
 
 // setSessionCookie is a helper to standardize cookie settings
 func (s *Session) setSessionCookie() {
-	// Get environment from config
-	app := config.GetAppConfig()
-	isProduction := app.IsProduction()
-
 	cookieName := SessionName()
 	if s.manager != nil && s.manager.cookieName != "" {
 		cookieName = s.manager.cookieName
 	}
 
+	value := s.id
+	if cs, ok := s.manager.store.(CookieValueStore); ok {
+		sealed, err := cs.CookieValue(s.data)
+		if err != nil {
+			log.Error("writing session cookie", "err", err)
+			return
+		}
+		value = sealed
+	}
+
+	http.SetCookie(s.w, sessionCookie(cookieName, value, s.data.Expires))
+}
+
+// sessionCookie builds the session cookie with the flags every store uses
+func sessionCookie(name string, value string, expires time.Time) *http.Cookie {
+	isProduction := config.GetAppConfig().IsProduction()
+
 	cookie := &http.Cookie{
-		Name:     cookieName,
-		Value:    s.id,
+		Name:     name,
+		Value:    value,
 		Path:     "/",
 		HttpOnly: true,
 		Secure:   isProduction,
 		SameSite: http.SameSiteLaxMode, // Set to Lax by default
-		Expires:  time.Now().Add(GetSessionLength()),
+		Expires:  expires,
 	}
 
 	// Set to strict for production
 	if isProduction {
 		cookie.SameSite = http.SameSiteStrictMode
 	}
+	return cookie
+}
 
+// SetCookie adds a cookie to the response alongside the session cookie, e.g.
+// the remember-me cookie. It reports false once the response headers are out.
+func (s *Session) SetCookie(cookie *http.Cookie) bool {
+	if s.ephemeral {
+		return false
+	}
+	if s.headersSent {
+		log.Warn("cookie set after the response headers were written; ignored", "cookie", cookie.Name)
+		return false
+	}
 	http.SetCookie(s.w, cookie)
+	return true
 }
 
 // SetFlash stores a flash message that will be displayed once
-func (s *Session) SetFlash(key string, val any) {
+func (s *Session) SetFlash(key string, val any) error {
 	flashKey := string(flashKey) + key
-	s.Set(flashKey, val)
+	return s.Set(flashKey, val)
 }
 
 // GetFlash retrieves a flash message and removes it from the session
@@ -161,8 +276,8 @@ func (s *Session) GetAllFlash() map[string]any {
 }
 
 // SetOld stores a form value for repopulation after a redirect
-func (s *Session) SetOld(key string, val any) {
-	s.Set(string(oldKey)+key, val)
+func (s *Session) SetOld(key string, val any) error {
+	return s.Set(string(oldKey)+key, val)
 }
 
 // GetOld retrieves a form value and removes it from the session
@@ -225,12 +340,12 @@ func (s *Session) PeekAllOld() map[string]any {
 // Add these methods to your session package
 
 // SetFieldErrors stores a slice of error messages for a field
-func (s *Session) SetFieldErrors(field string, errors []string) {
-	s.Set(string(fieldErrorsPrefix)+field, errors)
+func (s *Session) SetFieldErrors(field string, errors []string) error {
+	return s.Set(string(fieldErrorsPrefix)+field, errors)
 }
 
 // AddFieldError appends an error message to the slice for a field, creating the slice if needed
-func (s *Session) AddFieldError(field string, errMsg string) {
+func (s *Session) AddFieldError(field string, errMsg string) error {
 	key := string(fieldErrorsPrefix) + field
 	val, ok := s.Get(key)
 	var errs []string
@@ -240,7 +355,7 @@ func (s *Session) AddFieldError(field string, errMsg string) {
 		}
 	}
 	errs = append(errs, errMsg)
-	s.SetFieldErrors(field, errs)
+	return s.SetFieldErrors(field, errs)
 }
 
 // GetFieldErrors retrieves a slice of error messages for a field and removes it from the session
@@ -307,7 +422,7 @@ func (s *Session) GetFieldError(field string) (string, bool) {
 	}
 	// Clear after retrieving
 	s.Remove(string(fieldErrorsPrefix) + field)
-	return val.(string), true
+	return firstFieldError(val)
 }
 
 // PeekFieldError retrieves a field-specific error without removing it from the session
@@ -316,7 +431,21 @@ func (s *Session) PeekFieldError(field string) (string, bool) {
 	if !ok {
 		return "", false
 	}
-	return val.(string), true
+	return firstFieldError(val)
+}
+
+// firstFieldError accepts both a single message and the []string stored by
+// SetFieldErrors, returning false for anything else instead of panicking
+func firstFieldError(val any) (string, bool) {
+	switch v := val.(type) {
+	case string:
+		return v, true
+	case []string:
+		if len(v) > 0 {
+			return v[0], true
+		}
+	}
+	return "", false
 }
 
 // PeekAllFieldError retrieves all field errors without removing them from the session
@@ -339,8 +468,8 @@ func (s *Session) GetAllFieldError() map[string]string {
 		if len(key) > prefixLen && key[:prefixLen] == prefix {
 			// Extract the actual field name without prefix
 			fieldName := key[prefixLen:]
-			if strVal, ok := val.(string); ok {
-				fieldErrors[fieldName] = strVal
+			if msg, ok := firstFieldError(val); ok {
+				fieldErrors[fieldName] = msg
 			}
 			// Remove after retrieving
 			s.Remove(key)
@@ -372,15 +501,27 @@ func (s *Session) getKeysByPrefix(prefix string) map[string]any {
 	return result
 }
 
-// Remove removes a key from the session
+// Remove removes a key from the session; the change is persisted on Flush
 func (s *Session) Remove(key string) {
 	if s.data == nil {
 		return
 	}
+	if _, ok := s.data.Values[key]; !ok {
+		return
+	}
 	delete(s.data.Values, key)
+	s.dirty = true
+}
 
-	// Persist the change to the storage backend
-	s.manager.store.Remove(s.id, key)
+// replaceID swaps in a fresh session ID and queues the old one for deletion
+func (s *Session) replaceID() {
+	if s.persisted {
+		s.staleIDs = append(s.staleIDs, s.id)
+	}
+	s.id = GenerateSessionID()
+	s.persisted = false
+	s.dirty = true
+	s.reindex = true
 }
 
 // Regenerate creates a new session ID while preserving important data
@@ -389,14 +530,20 @@ func (s *Session) Regenerate() {
 	csrfToken, hasCSRF := s.Get("csrfToken")
 
 	// Generate new session ID
-	oldID := s.id
-	s.id = GenerateSessionID() // Your existing method
+	s.replaceID()
 
-	// Create new session data
+	// Create new session data, keeping the original creation time so the
+	// absolute timeout cannot be extended by regenerating
 	oldValues := s.data.Values
 	s.data = &SessionData{
-		Values: make(map[string]interface{}),
+		ID:          s.id,
+		Values:      make(map[string]interface{}),
+		CreatedAt:   s.data.CreatedAt,
+		IP:          s.data.IP,
+		UserAgent:   s.data.UserAgent,
+		Fingerprint: s.data.Fingerprint,
 	}
+	s.data.touch(time.Now())
 
 	// Copy ALL old values to new session
 	for k, v := range oldValues {
@@ -408,35 +555,22 @@ func (s *Session) Regenerate() {
 		s.data.Values["csrfToken"] = csrfToken
 	}
 
-	// Save and delete
-	s.manager.Save(s.id, s.data)
-	s.manager.Delete(oldID)
-
-	// Update cookie
-	s.setSessionCookie()
+	// The new session is saved, the old one deleted and the cookie
+	// reissued when the session is flushed
 }
 
 // RegenerateNew creates a completely new session with no preserved values
 func (s *Session) RegenerateNew() {
 	// Generate new session ID
-	oldID := s.id
-	s.id = GenerateSessionID()
+	s.replaceID()
 
-	// Create completely empty session data with a valid expiry
-	s.data = &SessionData{
-		Values:  make(map[string]interface{}),
-		Expires: time.Now().Add(GetSessionLength()),
-	}
+	// Create completely empty session data with fresh timeouts
+	s.data, _ = newSessionData()
+	s.data.ID = s.id
 
 	// Generate fresh CSRF token for security
 	s.SetCSRF("")
 
-	// Save the new empty session
-	s.manager.Save(s.id, s.data)
-
-	// Delete the old session completely from storage
-	s.manager.Delete(oldID)
-
-	// Update cookie with new session ID
-	s.setSessionCookie()
+	// The old session is deleted from storage and the cookie reissued
+	// with the new ID when the session is flushed
 }
@@ -0,0 +1,79 @@
+package session
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrUnencodableValue is returned by Set when a value cannot be gob-encoded
+// by the session stores.
+var ErrUnencodableValue = errors.New("session: value cannot be gob-encoded")
+
+// Key is a typed session key. Declare keys once and use them instead of raw
+// strings so reads and writes agree on the value type:
+//
+//	var CartKey = session.NewKey[*Cart]("cart")
+//
+//	CartKey.Set(sess, cart)
+//	cart, ok := CartKey.Get(sess)
+type Key[T any] struct {
+	name string
+}
+
+// NewKey declares a typed session key
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Name returns the underlying session key
+func (k Key[T]) Name() string {
+	return k.name
+}
+
+// Get returns the value and whether it was present with the expected type
+func (k Key[T]) Get(sess *Session) (T, bool) {
+	return GetAs[T](sess, k.name)
+}
+
+// Set stores the value, failing if it cannot be gob-encoded
+func (k Key[T]) Set(sess *Session, val T) error {
+	return sess.Set(k.name, val)
+}
+
+// Remove deletes the value from the session
+func (k Key[T]) Remove(sess *Session) {
+	sess.Remove(k.name)
+}
+
+// GetAs returns the value stored under key as T. It reports false when the
+// key is missing or holds a different type, instead of panicking.
+func GetAs[T any](sess *Session, key string) (T, bool) {
+	var zero T
+	if sess == nil {
+		return zero, false
+	}
+	val, ok := sess.Get(key)
+	if !ok {
+		return zero, false
+	}
+	typed, ok := val.(T)
+	if !ok {
+		return zero, false
+	}
+	return typed, true
+}
+
+// checkEncodable gob-encodes the value the way the stores will, inside an
+// interface map, so types that were never registered fail here rather than
+// later inside a store's encoder.
+func checkEncodable(key string, val any) error {
+	if val == nil {
+		return nil
+	}
+	if err := gob.NewEncoder(io.Discard).Encode(map[string]any{key: val}); err != nil {
+		return fmt.Errorf("%w: %q holds %T (did you gob.Register it?): %v", ErrUnencodableValue, key, val, err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type unregisteredValue struct {
+	Name string
+}
+
+func newTestSession(t *testing.T) *Session {
+	t.Helper()
+	initTestConfig()
+	store, storeType := NewMemorySessionManager("_test_session")
+	sm := NewSessionManagerWithStore(store, storeType, "_test_session")
+	data, id := newSessionData()
+	return &Session{id: id, data: data, manager: sm}
+}
+
+func TestSet_RejectsUnregisteredType(t *testing.T) {
+	sess := newTestSession(t)
+
+	err := sess.Set("thing", &unregisteredValue{Name: "x"})
+	if !errors.Is(err, ErrUnencodableValue) {
+		t.Fatalf("expected ErrUnencodableValue, got %v", err)
+	}
+	if _, ok := sess.Get("thing"); ok {
+		t.Fatal("unencodable value should not be stored")
+	}
+	if sess.Dirty() {
+		t.Fatal("rejected Set should not mark the session dirty")
+	}
+}
+
+func TestKey_RoundTrip(t *testing.T) {
+	sess := newTestSession(t)
+	count := NewKey[int]("count")
+
+	if _, ok := count.Get(sess); ok {
+		t.Fatal("expected missing key")
+	}
+	if err := count.Set(sess, 3); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, ok := count.Get(sess); !ok || got != 3 {
+		t.Fatalf("expected 3, got %v (%v)", got, ok)
+	}
+
+	// A different type under the same name reports false instead of panicking
+	if _, ok := GetAs[string](sess, count.Name()); ok {
+		t.Fatal("expected type mismatch to report false")
+	}
+
+	count.Remove(sess)
+	if _, ok := count.Get(sess); ok {
+		t.Fatal("expected key to be removed")
+	}
+}
+
+func TestKey_SurvivesStore(t *testing.T) {
+	sess := newTestSession(t)
+	tags := NewKey[[]string]("tags")
+	if err := tags.Set(sess, []string{"a", "b"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	sess.manager.store.Save(sess.id, sess.data)
+
+	data, err := sess.manager.store.GetSessionByID(context.Background(), sess.id)
+	if err != nil {
+		t.Fatalf("GetSessionByID: %v", err)
+	}
+	loaded := &Session{id: sess.id, data: data, manager: sess.manager}
+	if got, ok := tags.Get(loaded); !ok || len(got) != 2 || got[1] != "b" {
+		t.Fatalf("unexpected value after reload: %v (%v)", got, ok)
+	}
+}
+
+func TestGetFieldError_AcceptsSlices(t *testing.T) {
+	sess := newTestSession(t)
+	sess.AddFieldError("email", "is required")
+	sess.AddFieldError("email", "is invalid")
+
+	if msg, ok := sess.PeekFieldError("email"); !ok || msg != "is required" {
+		t.Fatalf("expected first error, got %q (%v)", msg, ok)
+	}
+	if all := sess.GetAllFieldError(); all["email"] != "is required" {
+		t.Fatalf("unexpected field errors: %v", all)
+	}
+}
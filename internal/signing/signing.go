@@ -0,0 +1,161 @@
+// Package signing creates and verifies tamper-proof, expiring URLs.
+//
+// A signed URL carries an "expires" Unix timestamp and an HMAC-SHA256
+// "signature" over its path and every other query parameter, so links sent
+// by email can be trusted without storing anything server-side.
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"gohst/internal/config"
+	"gohst/internal/logger"
+)
+
+var log = logger.Component("signing")
+
+const (
+	EXPIRES_PARAM   = "expires"
+	SIGNATURE_PARAM = "signature"
+
+	// KEY_MIN_LENGTH is the shortest APP_KEY accepted without a warning
+	KEY_MIN_LENGTH = 32
+)
+
+var (
+	// ErrInvalidSignature is returned for URLs that were not signed by us or were altered.
+	ErrInvalidSignature = errors.New("signing: invalid signature")
+	// ErrExpired is returned for correctly signed URLs past their expiry.
+	ErrExpired = errors.New("signing: link has expired")
+)
+
+// Signer signs and verifies URLs with HMAC-SHA256.
+type Signer struct {
+	keys [][]byte
+	now  func() time.Time
+}
+
+// NewSigner creates a signer that signs with key and also accepts oldKeys
+func NewSigner(key []byte, oldKeys ...[]byte) *Signer {
+	return &Signer{
+		keys: append([][]byte{key}, oldKeys...),
+		now:  time.Now,
+	}
+}
+
+// WithClock replaces the time source, for tests
+func (s *Signer) WithClock(now func() time.Time) *Signer {
+	s.now = now
+	return s
+}
+
+// Sign returns path with params plus the expires and signature parameters
+func (s *Signer) Sign(path string, params url.Values, ttl time.Duration) string {
+	signed := url.Values{}
+	for key, vals := range params {
+		signed[key] = append([]string(nil), vals...)
+	}
+	signed.Del(SIGNATURE_PARAM)
+	signed.Set(EXPIRES_PARAM, strconv.FormatInt(s.now().Add(ttl).Unix(), 10))
+
+	signed.Set(SIGNATURE_PARAM, s.signature(s.keys[0], path, signed))
+	return path + "?" + signed.Encode()
+}
+
+// Verify checks the signature and expiry of a URL and returns its parameters
+// without the signing ones
+func (s *Signer) Verify(u *url.URL) (url.Values, error) {
+	params := u.Query()
+	sig := params.Get(SIGNATURE_PARAM)
+	if sig == "" {
+		return nil, ErrInvalidSignature
+	}
+	params.Del(SIGNATURE_PARAM)
+
+	valid := false
+	for _, key := range s.keys {
+		if hmac.Equal([]byte(sig), []byte(s.signature(key, u.Path, params))) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, ErrInvalidSignature
+	}
+
+	expires, err := strconv.ParseInt(params.Get(EXPIRES_PARAM), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if !s.now().Before(time.Unix(expires, 0)) {
+		return nil, ErrExpired
+	}
+
+	params.Del(EXPIRES_PARAM)
+	return params, nil
+}
+
+// signature is the HMAC of the path and the canonical (sorted) query
+func (s *Signer) signature(key []byte, path string, params url.Values) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(path))
+	mac.Write([]byte{'?'})
+	mac.Write([]byte(params.Encode()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultSigner *Signer
+)
+
+// Default returns the signer built from config.Signing (APP_KEY). Without a
+// key a random one is generated, so links stop working after a restart.
+func Default() *Signer {
+	defaultOnce.Do(func() {
+		defaultSigner = newFromConfig(config.Signing)
+	})
+	return defaultSigner
+}
+
+// SetDefault replaces the app-wide signer, e.g. in tests
+func SetDefault(s *Signer) {
+	defaultOnce.Do(func() {})
+	defaultSigner = s
+}
+
+func newFromConfig(conf *config.SigningConfig) *Signer {
+	if conf == nil || conf.Key == "" {
+		log.Warn("APP_KEY is not set; using a temporary signing key, signed links will stop working after a restart")
+		key := make([]byte, KEY_MIN_LENGTH)
+		rand.Read(key)
+		return NewSigner(key)
+	}
+	if len(conf.Key) < KEY_MIN_LENGTH {
+		log.Warn("APP_KEY is too short; use a longer random key", "min_length", KEY_MIN_LENGTH)
+	}
+
+	var oldKeys [][]byte
+	for _, k := range conf.OldKeys {
+		oldKeys = append(oldKeys, []byte(k))
+	}
+	return NewSigner([]byte(conf.Key), oldKeys...)
+}
+
+// Sign signs a URL with the default signer
+func Sign(path string, params url.Values, ttl time.Duration) string {
+	return Default().Sign(path, params, ttl)
+}
+
+// Verify checks a URL with the default signer
+func Verify(u *url.URL) (url.Values, error) {
+	return Default().Verify(u)
+}
@@ -0,0 +1,78 @@
+package signing
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	return u
+}
+
+func TestSigner_RoundTrip(t *testing.T) {
+	s := NewSigner([]byte("0123456789abcdef0123456789abcdef"))
+	signed := s.Sign("/auth/verify/confirm", url.Values{"id": {"42"}}, time.Hour)
+
+	params, err := s.Verify(mustParse(t, signed))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if params.Get("id") != "42" {
+		t.Fatalf("expected id 42, got %v", params)
+	}
+	if params.Has(EXPIRES_PARAM) || params.Has(SIGNATURE_PARAM) {
+		t.Fatalf("signing params should be stripped: %v", params)
+	}
+}
+
+func TestSigner_RejectsTampering(t *testing.T) {
+	s := NewSigner([]byte("0123456789abcdef0123456789abcdef"))
+	u := mustParse(t, s.Sign("/auth/verify/confirm", url.Values{"id": {"42"}}, time.Hour))
+
+	tampered := *u
+	q := tampered.Query()
+	q.Set("id", "43")
+	tampered.RawQuery = q.Encode()
+	if _, err := s.Verify(&tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for changed params, got %v", err)
+	}
+
+	moved := *u
+	moved.Path = "/other"
+	if _, err := s.Verify(&moved); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for changed path, got %v", err)
+	}
+
+	other := NewSigner([]byte("another-key-another-key-another-k"))
+	if _, err := other.Verify(u); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for a different key, got %v", err)
+	}
+}
+
+func TestSigner_Expiry(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := NewSigner([]byte("0123456789abcdef0123456789abcdef")).WithClock(func() time.Time { return now })
+	u := mustParse(t, s.Sign("/x", nil, time.Minute))
+
+	now = now.Add(2 * time.Minute)
+	if _, err := s.Verify(u); !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected ErrExpired, got %v", err)
+	}
+}
+
+func TestSigner_AcceptsOldKeys(t *testing.T) {
+	oldKey := []byte("old-old-old-old-old-old-old-old-")
+	u := mustParse(t, NewSigner(oldKey).Sign("/x", nil, time.Hour))
+
+	rotated := NewSigner([]byte("new-new-new-new-new-new-new-new-"), oldKey)
+	if _, err := rotated.Verify(u); err != nil {
+		t.Fatalf("expected old key to verify, got %v", err)
+	}
+}
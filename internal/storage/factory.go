@@ -1,13 +1,28 @@
 package storage
 
-import "gohst/internal/config"
+import (
+	"context"
+
+	"gohst/internal/config"
+	"gohst/internal/health"
+)
 
 // NewFileStore creates the appropriate FileStore based on the STORAGE_DRIVER
 // environment variable. Switching between backends is a config change only.
 //
 //	STORAGE_DRIVER=local  → LocalFileStore  (development)
 //	STORAGE_DRIVER=s3     → S3FileStore     (Linode / AWS / production)
+//
+// The store is reported to the readiness probe with a write/delete Probe.
 func NewFileStore() FileStore {
+	store := newFileStore()
+	health.Register("storage", func(ctx context.Context) error {
+		return Probe(ctx, store)
+	})
+	return store
+}
+
+func newFileStore() FileStore {
 	driver := config.GetEnv("STORAGE_DRIVER", "local").(string)
 
 	switch driver {
@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PROBE_DIR holds the short-lived files written by Probe
+const PROBE_DIR = ".health"
+
+// Probe checks a store is writable by storing a small private file and
+// deleting it again
+func Probe(ctx context.Context, store FileStore) error {
+	path := fmt.Sprintf("%s/probe-%d", PROBE_DIR, time.Now().UnixNano())
+	if _, err := store.Store(ctx, path, strings.NewReader("ok"), StoreOptions{ContentType: "text/plain"}); err != nil {
+		return err
+	}
+	return store.Delete(ctx, path)
+}
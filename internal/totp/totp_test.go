@@ -0,0 +1,73 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 key from the RFC 6238 test vectors
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		step := Step(time.Unix(tt.unix, 0))
+		if got := hotp(key, uint64(step), 8); got != tt.want {
+			t.Errorf("T=%d: got %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	// Six digit codes are the last six digits
+	if got, _ := Code(rfcSecret, time.Unix(59, 0)); got != "287082" {
+		t.Errorf("6-digit code = %s, want 287082", got)
+	}
+}
+
+func TestValidate_SkewAndStep(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	prev, _ := Code(rfcSecret, now.Add(-PERIOD))
+
+	step, ok := Validate(rfcSecret, prev, now)
+	if !ok || step != Step(now)-1 {
+		t.Fatalf("expected previous step to validate, got step %d ok %v", step, ok)
+	}
+
+	old, _ := Code(rfcSecret, now.Add(-3*PERIOD))
+	if _, ok := Validate(rfcSecret, old, now); ok {
+		t.Fatal("expected a code three steps old to be rejected")
+	}
+	if _, ok := Validate(rfcSecret, "12345", now); ok {
+		t.Fatal("expected short code to be rejected")
+	}
+}
+
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	if len(secret) != 32 {
+		t.Fatalf("expected a 32 character secret, got %q", secret)
+	}
+
+	uri := URI("Gohst App", "user@example.com", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Gohst%20App:user@example.com?") {
+		t.Fatalf("unexpected URI label: %s", uri)
+	}
+	if !strings.Contains(uri, "secret="+secret) || !strings.Contains(uri, "issuer=Gohst%20App") {
+		t.Fatalf("unexpected URI params: %s", uri)
+	}
+}
@@ -74,29 +74,65 @@ func HashPasswordWithParams(password string, params *Argon2Params) (string, erro
     return encodedHash, nil
 }
 
-// CheckPassword verifies if a password matches an Argon2id hash
-func CheckPassword(password, encodedHash string) (bool, error) {
+// CheckPassword verifies a password against a stored hash. Besides Argon2id
+// hashes it accepts bcrypt and PBKDF2 hashes imported from other systems.
+// needsRehash reports a match whose hash is not Argon2id or whose Argon2
+// parameters are below DefaultArgon2Params; callers should then store a
+// fresh HashPassword result while they still have the plain password.
+func CheckPassword(password, encodedHash string) (match bool, needsRehash bool, err error) {
     if password == "" || encodedHash == "" {
-        return false, errors.New("password or hash is empty")
+        return false, false, errors.New("password or hash is empty")
     }
 
+    switch {
+    case strings.HasPrefix(encodedHash, "$argon2id$"):
+        params, match, err := checkArgon2(password, encodedHash)
+        if err != nil || !match {
+            return false, false, err
+        }
+        return true, params.isWeakerThan(DefaultArgon2Params()), nil
+    case isBcryptHash(encodedHash):
+        match, err = checkBcrypt(password, encodedHash)
+    case isPBKDF2Hash(encodedHash):
+        match, err = checkPBKDF2(password, encodedHash)
+    default:
+        return false, false, errors.New("unsupported hash format")
+    }
+
+    // Every match of a foreign hash is migrated to Argon2id
+    return match, match, err
+}
+
+// isWeakerThan reports whether hashes made with p are cheaper to crack than
+// ones made with policy. Parallelism only spreads the same work over more
+// lanes, so it is not compared.
+func (p *Argon2Params) isWeakerThan(policy *Argon2Params) bool {
+    return p.Memory < policy.Memory ||
+        p.Iterations < policy.Iterations ||
+        p.SaltLength < policy.SaltLength ||
+        p.KeyLength < policy.KeyLength
+}
+
+// checkArgon2 verifies a password against an Argon2id hash in PHC format and
+// returns the parameters the hash was made with
+func checkArgon2(password, encodedHash string) (*Argon2Params, bool, error) {
     // Parse the hash string
     vals := strings.Split(encodedHash, "$")
     if len(vals) != 6 {
-        return false, errors.New("invalid hash format")
+        return nil, false, errors.New("invalid hash format")
     }
 
     if vals[1] != "argon2id" {
-        return false, fmt.Errorf("incompatible hash algorithm: %s", vals[1])
+        return nil, false, fmt.Errorf("incompatible hash algorithm: %s", vals[1])
     }
 
     var version int
     _, err := fmt.Sscanf(vals[2], "v=%d", &version)
     if err != nil {
-        return false, errors.New("invalid hash version")
+        return nil, false, errors.New("invalid hash version")
     }
     if version != 19 {
-        return false, fmt.Errorf("incompatible hash version: %d", version)
+        return nil, false, fmt.Errorf("incompatible hash version: %d", version)
     }
 
     // Parse parameters
@@ -109,18 +145,19 @@ func CheckPassword(password, encodedHash string) (bool, error) {
         &params.Parallelism,
     )
     if err != nil {
-        return false, errors.New("invalid hash parameters")
+        return nil, false, errors.New("invalid hash parameters")
     }
 
     // Decode salt and hash
     salt, err := base64.RawStdEncoding.DecodeString(vals[4])
     if err != nil {
-        return false, errors.New("invalid salt encoding")
+        return nil, false, errors.New("invalid salt encoding")
     }
+    params.SaltLength = uint32(len(salt))
 
     decodedHash, err := base64.RawStdEncoding.DecodeString(vals[5])
     if err != nil {
-        return false, errors.New("invalid hash encoding")
+        return nil, false, errors.New("invalid hash encoding")
     }
     params.KeyLength = uint32(len(decodedHash))
 
@@ -135,5 +172,5 @@ func CheckPassword(password, encodedHash string) (bool, error) {
     )
 
     // Constant-time comparison to prevent timing attacks
-    return subtle.ConstantTimeCompare(decodedHash, comparisonHash) == 1, nil
+    return params, subtle.ConstantTimeCompare(decodedHash, comparisonHash) == 1, nil
 }
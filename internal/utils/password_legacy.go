@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hashes imported from other systems are verified here so users can log in
+// with their existing password; CheckPassword then asks for an Argon2id rehash.
+
+// isBcryptHash reports whether encodedHash is a modular crypt bcrypt hash
+func isBcryptHash(encodedHash string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(encodedHash, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkBcrypt(password, encodedHash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("invalid bcrypt hash: %w", err)
+	}
+	return true, nil
+}
+
+// pbkdf2Digests maps the digest names used in PBKDF2 hash formats
+var pbkdf2Digests = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// isPBKDF2Hash reports whether encodedHash is a Django
+// ("pbkdf2_sha256$iter$salt$hash") or passlib ("$pbkdf2-sha256$iter$salt$hash")
+// PBKDF2 hash
+func isPBKDF2Hash(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "pbkdf2_") || strings.HasPrefix(encodedHash, "$pbkdf2")
+}
+
+func checkPBKDF2(password, encodedHash string) (bool, error) {
+	var (
+		digest string
+		fields []string
+		salt   []byte
+		want   []byte
+		err    error
+	)
+
+	if rest, ok := strings.CutPrefix(encodedHash, "$"); ok {
+		// passlib: $pbkdf2[-digest]$iter$salt$hash in adapted base64
+		fields = strings.Split(rest, "$")
+		if len(fields) != 4 {
+			return false, errors.New("invalid pbkdf2 hash format")
+		}
+		digest = "sha1"
+		if name, ok := strings.CutPrefix(fields[0], "pbkdf2-"); ok {
+			digest = name
+		}
+		if salt, err = decodeAB64(fields[2]); err != nil {
+			return false, errors.New("invalid salt encoding")
+		}
+		if want, err = decodeAB64(fields[3]); err != nil {
+			return false, errors.New("invalid hash encoding")
+		}
+	} else {
+		// Django: pbkdf2_digest$iter$salt$hash with a plain salt
+		fields = strings.Split(encodedHash, "$")
+		if len(fields) != 4 {
+			return false, errors.New("invalid pbkdf2 hash format")
+		}
+		digest = strings.TrimPrefix(fields[0], "pbkdf2_")
+		salt = []byte(fields[2])
+		if want, err = base64.StdEncoding.DecodeString(fields[3]); err != nil {
+			return false, errors.New("invalid hash encoding")
+		}
+	}
+
+	newHash, ok := pbkdf2Digests[digest]
+	if !ok {
+		return false, fmt.Errorf("incompatible pbkdf2 digest: %s", digest)
+	}
+	iterations, err := strconv.Atoi(fields[1])
+	if err != nil || iterations < 1 {
+		return false, errors.New("invalid pbkdf2 iterations")
+	}
+	if len(want) == 0 {
+		return false, errors.New("invalid hash encoding")
+	}
+
+	got, err := pbkdf2.Key(newHash, password, salt, iterations, len(want))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+// decodeAB64 decodes passlib's adapted base64, which uses "." for "+" and
+// drops the padding
+func decodeAB64(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.ReplaceAll(s, ".", "+"))
+}
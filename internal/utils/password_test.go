@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "correct horse"
+
+func TestCheckPassword_Argon2(t *testing.T) {
+	hash, err := HashPassword(testPassword)
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+
+	match, needsRehash, err := CheckPassword(testPassword, hash)
+	if err != nil || !match {
+		t.Fatalf("expected a match, got %v (%v)", match, err)
+	}
+	if needsRehash {
+		t.Fatal("a hash made with the default params must not need a rehash")
+	}
+
+	if match, _, _ := CheckPassword("wrong", hash); match {
+		t.Fatal("expected a mismatch for the wrong password")
+	}
+}
+
+func TestCheckPassword_WeakArgon2NeedsRehash(t *testing.T) {
+	weak := DefaultArgon2Params()
+	weak.Memory = 8 * 1024
+	weak.Iterations = 1
+	hash, err := HashPasswordWithParams(testPassword, weak)
+	if err != nil {
+		t.Fatalf("HashPasswordWithParams: %v", err)
+	}
+
+	match, needsRehash, err := CheckPassword(testPassword, hash)
+	if err != nil || !match || !needsRehash {
+		t.Fatalf("expected a match needing a rehash, got %v, %v (%v)", match, needsRehash, err)
+	}
+
+	// A mismatch never asks for a rehash
+	if _, needsRehash, _ := CheckPassword("wrong", hash); needsRehash {
+		t.Fatal("a wrong password must not ask for a rehash")
+	}
+}
+
+func TestCheckPassword_Bcrypt(t *testing.T) {
+	generated, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+
+	for _, hash := range []string{
+		string(generated),
+		"$2y$" + strings.TrimPrefix(string(generated), "$2a$"),
+	} {
+		match, needsRehash, err := CheckPassword(testPassword, hash)
+		if err != nil || !match || !needsRehash {
+			t.Errorf("%s: expected a match needing a rehash, got %v, %v (%v)", hash[:4], match, needsRehash, err)
+		}
+		if match, _, err := CheckPassword("wrong", hash); match || err != nil {
+			t.Errorf("%s: expected a clean mismatch, got %v (%v)", hash[:4], match, err)
+		}
+	}
+}
+
+func TestCheckPassword_PBKDF2(t *testing.T) {
+	// Generated with Python's hashlib.pbkdf2_hmac in Django and passlib formats
+	for _, hash := range []string{
+		"pbkdf2_sha256$1000$seasalt$mQnueSakb748zqBAC1tmWVZsZbi2zPGZarEzTGdfmso=",
+		"$pbkdf2-sha512$1000$AAECAwQFBgcICQoLDA0ODw$Xpx07WjVx4vCIvrmBRj8uOoVVtGqJqtUv2J5bhizSQs7osCteF7W4A61dZDqSIqQjO.dxO6p5FT/Uy7QRBXSXA",
+		"$pbkdf2$1000$AAECAwQFBgcICQoLDA0ODw$ndhWw3a4srcTtr4HQFTLiKRlVuA",
+	} {
+		match, needsRehash, err := CheckPassword(testPassword, hash)
+		if err != nil || !match || !needsRehash {
+			t.Errorf("%s: expected a match needing a rehash, got %v, %v (%v)", hash, match, needsRehash, err)
+		}
+		if match, _, _ := CheckPassword("wrong", hash); match {
+			t.Errorf("%s: expected a mismatch", hash)
+		}
+	}
+}
+
+func TestCheckPassword_Invalid(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"plaintext",
+		"$md5$abc",
+		"$argon2id$v=19$bad",
+		"pbkdf2_md5$1000$salt$aGFzaA==",
+		"pbkdf2_sha256$0$salt$aGFzaA==",
+	} {
+		if match, needsRehash, err := CheckPassword(testPassword, hash); match || needsRehash || err == nil {
+			t.Errorf("%q: expected an error, got %v, %v (%v)", hash, match, needsRehash, err)
+		}
+	}
+}